@@ -1,68 +1,372 @@
 package main
 
 import (
+	redisbroker "TaskManager/internal/broker/redis"
+	"TaskManager/internal/cache/lru"
 	"TaskManager/internal/cache/redis"
+	"TaskManager/internal/cache/tiered"
 	apiserver "TaskManager/internal/delivery/http_server"
+	"TaskManager/internal/metrics"
+	"TaskManager/internal/scheduler"
+	"TaskManager/internal/storage/memory"
 	"TaskManager/internal/storage/postgres"
+	"TaskManager/internal/storage/sqlite"
+	"TaskManager/internal/tracing"
+	"TaskManager/internal/webhook"
+	"context"
+	"database/sql/driver"
+	"errors"
 	"flag"
+	"fmt"
+	"io"
 	"log"
+	"net"
+	"os"
+	"os/signal"
+	"strings"
+	"syscall"
+	"time"
 
 	"github.com/BurntSushi/toml"
+	goredis "github.com/go-redis/redis/v8"
+	"github.com/lib/pq"
+)
+
+// Delays between attempts to reach the database and Redis at startup.
+const (
+	connectBackoff    = 500 * time.Millisecond
+	maxConnectBackoff = 10 * time.Second
 )
 
 var (
 	configPath string
 	caching    int
+	storage    string
 )
 
 func init() {
 	flag.StringVar(&configPath, "config-path", "configs/taskmanager.toml", "path to config file")
 	flag.IntVar(&caching, "caching", -1, "should the server cache requests, 0 - false, 1 - true")
+	flag.StringVar(&storage, "storage", "", "storage backend, postgres, sqlite or memory; by default chosen by the database_url scheme")
+
+	flag.Usage = func() {
+		fmt.Fprintf(flag.CommandLine.Output(), "Usage: %s [flags] [migrate up|down|status|force]\n", os.Args[0])
+		flag.PrintDefaults()
+	}
+}
+
+// config mirrors the sections of the TOML config file.
+type config struct {
+	APIServer *apiserver.Config `toml:"apiserver"`
+	Cache     *cacheConfig      `toml:"cache"`
+	Redis     *redis.Config     `toml:"redis"`
+	Scheduler *scheduler.Config `toml:"scheduler"`
+	Webhooks  *webhook.Config   `toml:"webhooks"`
+	Metrics   *metrics.Config   `toml:"metrics"`
+	Tracing   *tracing.Config   `toml:"tracing"`
+
+	// Storage takes precedence over the older [postgres] section.
+	Storage  *storageConfig `toml:"storage"`
+	Postgres *storageConfig `toml:"postgres"`
+}
+
+type storageConfig struct {
+	DataBaseURL  string         `toml:"database_url"`
+	AutoMigrate  *bool          `toml:"auto_migrate"`
+	QueryTimeout *time.Duration `toml:"query_timeout"`
+}
+
+type cacheConfig struct {
+	// Backend is redis, lru or tiered (a local LRU in front of Redis).
+	Backend    string        `toml:"backend"`
+	MaxEntries int           `toml:"max_entries"`
+	LocalTTL   time.Duration `toml:"local_ttl"`
+}
+
+type storageBackend interface {
+	apiserver.Storage
+	Open() error
+	Close() error
 }
 
 func main() {
 	flag.Parse()
 
-	// Server config
-	c := apiserver.NewConfig()
+	c := &config{
+		APIServer: apiserver.NewConfig(),
+		Cache: &cacheConfig{
+			Backend:    "redis",
+			MaxEntries: lru.NewConfig().MaxEntries,
+			LocalTTL:   5 * time.Second,
+		},
+		Redis:     redis.NewConfig(),
+		Scheduler: scheduler.NewConfig(),
+		Webhooks:  webhook.NewConfig(),
+		Metrics:   metrics.NewConfig(),
+		Tracing:   tracing.NewConfig(),
+		Storage:   &storageConfig{},
+		Postgres:  &storageConfig{},
+	}
 	_, err := toml.DecodeFile(configPath, c)
 	if err != nil {
 		log.Fatal(err)
 	}
 
-	// New server with config
-	s, err := apiserver.New(c)
-
+	db, err := newStorage(c)
 	if err != nil {
 		log.Fatal(err)
 	}
 
-	// Postgres config
-	pgConfig := postgres.NewConfig()
-	_, err = toml.DecodeFile(configPath, pgConfig)
-	if err != nil {
+	if flag.Arg(0) == "migrate" {
+		if err := runMigrate(db, flag.Args()[1:]); err != nil {
+			log.Fatal(err)
+		}
+		return
+	}
+
+	if err := serve(c, db); err != nil {
 		log.Fatal(err)
 	}
+}
+
+// serve opens the dependencies, runs the server until SIGINT or SIGTERM
+// and closes the dependencies again once it has drained.
+func serve(c *config, db storageBackend) error {
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	// New server with config
+	s, err := apiserver.New(c.APIServer)
+
+	if err != nil {
+		return err
+	}
+
+	// Metrics cover the connection pool of the storage, so they come first.
+	if c.Metrics.Enabled {
+		if err := s.UseMetrics(c.Metrics); err != nil {
+			return err
+		}
+	}
 
-	// New postgres client
-	db := postgres.New(pgConfig)
-	s.UseDB(db)
+	if c.Tracing.Enabled {
+		shutdownTracing, err := tracing.Setup(ctx, c.Tracing)
+		if err != nil {
+			return err
+		}
+		// Deferred first, so the spans of the shutdown are flushed too.
+		defer func() {
+			flushCtx, cancel := context.WithTimeout(context.Background(), c.APIServer.ShutdownTimeout)
+			defer cancel()
+			closeLogged("tracing", func() error { return shutdownTracing(flushCtx) })
+		}()
 
-	if caching == -1 && c.Caching ||
+		if err := s.UseTracing(c.Tracing); err != nil {
+			return err
+		}
+	}
+
+	if err := connect(ctx, "database", c.APIServer.ConnectTimeout, db.Open); err != nil {
+		return err
+	}
+	defer closeLogged("database", db.Close)
+	if err := s.UseDB(db); err != nil {
+		return err
+	}
+
+	if c.Scheduler.Enabled {
+		if err := s.UseScheduler(c.Scheduler); err != nil {
+			return err
+		}
+	}
+
+	if c.Webhooks.Enabled {
+		if err := s.UseWebhooks(c.Webhooks); err != nil {
+			return err
+		}
+	}
+
+	if caching == -1 && c.APIServer.Caching ||
 		caching == 1 {
-		redisConfig := redis.NewConfig()
-		_, err = toml.DecodeFile(configPath, redisConfig)
+		cache, closeCache, err := newCache(ctx, c)
 		if err != nil {
-			log.Fatal(err)
+			return err
 		}
+		defer closeLogged("cache", closeCache)
+		if err := s.UseCache(cache); err != nil {
+			return err
+		}
+
+		// Instances sharing the Redis cache also share task events, so
+		// that every stream of a user receives them.
+		if c.Cache.Backend != "lru" {
+			broker := redisbroker.New(&redisbroker.Config{
+				Addr:     c.Redis.Addr,
+				Password: c.Redis.Password,
+				DB:       c.Redis.DB,
+			})
+			if err := connect(ctx, "Redis broker", c.APIServer.ConnectTimeout, broker.Open); err != nil {
+				return err
+			}
+			defer closeLogged("Redis broker", broker.Close)
+			s.UseBroker(broker)
+		}
+	}
+
+	return s.Run(ctx)
+}
 
-		cache := redis.New(redisConfig)
-		s.UseCache(cache)
+// newStorage creates the backend selected by the -storage flag or, by
+// default, by the database URL scheme.
+func newStorage(c *config) (storageBackend, error) {
+	sc := c.Storage
+	if sc.DataBaseURL == "" {
+		sc = c.Postgres
 	}
 
-	e := s.Start()
+	if storage == "" {
+		storage = storageDriver(sc.DataBaseURL)
+	}
+
+	switch storage {
+	case "postgres":
+		pgConfig := postgres.NewConfig()
+		pgConfig.DataBaseURL = sc.DataBaseURL
+		if sc.AutoMigrate != nil {
+			pgConfig.AutoMigrate = *sc.AutoMigrate
+		}
+		if sc.QueryTimeout != nil {
+			pgConfig.QueryTimeout = *sc.QueryTimeout
+		}
+
+		// New postgres client
+		return postgres.New(pgConfig), nil
+	case "sqlite":
+		sqliteConfig := sqlite.NewConfig()
+		sqliteConfig.DataBaseURL = sc.DataBaseURL
+		if sc.AutoMigrate != nil {
+			sqliteConfig.AutoMigrate = *sc.AutoMigrate
+		}
+		if sc.QueryTimeout != nil {
+			sqliteConfig.QueryTimeout = *sc.QueryTimeout
+		}
+
+		return sqlite.New(sqliteConfig), nil
+	case "memory":
+		return memory.New(), nil
+	default:
+		return nil, fmt.Errorf("unknown storage %q", storage)
+	}
+}
+
+// newCache creates and connects the cache backend named in the [cache]
+// section and returns a function releasing it.
+func newCache(ctx context.Context, c *config) (apiserver.Cache, func() error, error) {
+	lruConfig := lru.NewConfig()
+	lruConfig.MaxEntries = c.Cache.MaxEntries
+
+	switch c.Cache.Backend {
+	case "redis":
+		cache := redis.New(c.Redis)
+		if err := connect(ctx, "Redis", c.APIServer.ConnectTimeout, cache.Open); err != nil {
+			return nil, nil, err
+		}
+		return cache, cache.Close, nil
+	case "lru":
+		return lru.New(lruConfig), func() error { return nil }, nil
+	case "tiered":
+		remote := redis.New(c.Redis)
+		if err := connect(ctx, "Redis", c.APIServer.ConnectTimeout, remote.Open); err != nil {
+			return nil, nil, err
+		}
+		return tiered.New(lru.New(lruConfig), remote, c.Cache.LocalTTL), remote.Close, nil
+	default:
+		return nil, nil, fmt.Errorf("unknown cache backend %q", c.Cache.Backend)
+	}
+}
+
+// storageDriver picks the storage backend from the database URL scheme.
+// Key/value DSNs without a scheme are treated as Postgres.
+func storageDriver(databaseURL string) string {
+	switch {
+	case strings.HasPrefix(databaseURL, "sqlite://"), strings.HasPrefix(databaseURL, "sqlite3://"):
+		return "sqlite"
+	case strings.HasPrefix(databaseURL, "memory://"):
+		return "memory"
+	default:
+		return "postgres"
+	}
+}
+
+// connect calls open until it succeeds, backing off exponentially between
+// attempts, so that the server can start before its dependencies are up.
+// It gives up after timeout, when ctx is done or at the first error that
+// retrying cannot fix, such as a bad DSN, failed authentication or a dirty
+// schema.
+func connect(ctx context.Context, name string, timeout time.Duration, open func() error) error {
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	delay := connectBackoff
+	for {
+		err := open()
+		if err == nil {
+			return nil
+		}
+		if !transient(err) {
+			return fmt.Errorf("connecting to %s: %w", name, err)
+		}
+
+		log.Printf("Error connecting to %s: %v, retrying in %s", name, err, delay)
+		select {
+		case <-ctx.Done():
+			return fmt.Errorf("connecting to %s: %w", name, err)
+		case <-time.After(delay):
+		}
+
+		if delay *= 2; delay > maxConnectBackoff {
+			delay = maxConnectBackoff
+		}
+	}
+}
+
+// transient reports whether err comes from a dependency that is not
+// reachable yet, as while it is still starting.
+func transient(err error) bool {
+	// A malformed address is reported as a network error too.
+	var addrErr *net.AddrError
+	var parseErr *net.ParseError
+	if errors.As(err, &addrErr) || errors.As(err, &parseErr) {
+		return false
+	}
+
+	var netErr net.Error
+	if errors.As(err, &netErr) ||
+		errors.Is(err, syscall.ECONNREFUSED) || errors.Is(err, syscall.ECONNRESET) ||
+		errors.Is(err, io.EOF) || errors.Is(err, io.ErrUnexpectedEOF) ||
+		errors.Is(err, driver.ErrBadConn) {
+		return true
+	}
+
+	// Postgres refuses connections with 57P03 while it starts up.
+	var pqErr *pq.Error
+	if errors.As(err, &pqErr) {
+		return pqErr.Code.Class() == "08" || pqErr.Code == "57P03"
+	}
+
+	// Redis answers LOADING until it has read its dataset from disk.
+	var redisErr goredis.Error
+	if errors.As(err, &redisErr) {
+		return strings.HasPrefix(redisErr.Error(), "LOADING ")
+	}
+
+	return false
+}
 
-	if e != nil {
-		log.Fatal(e)
+// closeLogged closes a dependency on shutdown, logging rather than
+// returning its error.
+func closeLogged(name string, close func() error) {
+	if err := close(); err != nil {
+		log.Printf("Error closing %s: %v", name, err)
 	}
 }
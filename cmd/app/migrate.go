@@ -0,0 +1,91 @@
+package main
+
+import (
+	"TaskManager/internal/storage/schema"
+	"errors"
+	"fmt"
+	"strconv"
+)
+
+type migratable interface {
+	Connect() error
+	Migrator() (*schema.Migrator, error)
+	Close() error
+}
+
+// runMigrate implements the `migrate up|down|status|force` subcommand.
+//
+//	migrate up [N]     apply all or the next N pending migrations
+//	migrate down [N]   revert the last N migrations, 1 by default
+//	migrate status     print the schema version and dirty flag
+//	migrate force V    set the version to V, -1 for no migration applied,
+//	                   and clear the dirty flag
+func runMigrate(db storageBackend, args []string) error {
+	target, ok := db.(migratable)
+	if !ok {
+		return errors.New("storage has no schema to migrate")
+	}
+
+	if len(args) == 0 {
+		return errors.New("usage: migrate up|down|status|force")
+	}
+
+	n := 0
+	if len(args) > 1 {
+		var err error
+		if n, err = strconv.Atoi(args[1]); err != nil {
+			return fmt.Errorf("invalid migration argument %q", args[1])
+		}
+	}
+	switch args[0] {
+	case "up", "down":
+		if n < 0 {
+			return fmt.Errorf("invalid migration count %q", args[1])
+		}
+	case "force":
+		// -1 stands for a schema without any migration applied.
+		if n < -1 {
+			return fmt.Errorf("invalid schema version %q", args[1])
+		}
+	}
+
+	if err := target.Connect(); err != nil {
+		return err
+	}
+	defer target.Close()
+
+	m, err := target.Migrator()
+	if err != nil {
+		return err
+	}
+	defer m.Close()
+
+	switch args[0] {
+	case "up":
+		err = m.Up(n)
+	case "down":
+		if n == 0 {
+			n = 1
+		}
+		err = m.Down(n)
+	case "force":
+		if len(args) != 2 {
+			return errors.New("usage: migrate force VERSION")
+		}
+		err = m.Force(n)
+	case "status":
+	default:
+		return fmt.Errorf("unknown migrate command %q", args[0])
+	}
+	if err != nil {
+		return err
+	}
+
+	version, dirty, err := m.Status()
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("version %d, dirty %t\n", version, dirty)
+	return nil
+}
@@ -15,6 +15,23 @@ const docTemplate = `{
     "host": "{{.Host}}",
     "basePath": "{{.BasePath}}",
     "paths": {
+        "/healthz": {
+            "get": {
+                "description": "Handling the request to check that the process is alive. It does not check the dependencies, so that a database outage does not get the server restarted.",
+                "produces": [
+                    "application/json"
+                ],
+                "summary": "Handling the liveness probe",
+                "responses": {
+                    "200": {
+                        "description": "The server is alive",
+                        "schema": {
+                            "$ref": "#/definitions/apiserver.HealthResponse"
+                        }
+                    }
+                }
+            }
+        },
         "/login": {
             "post": {
                 "description": "Handling login using given login and password",
@@ -53,9 +70,103 @@ const docTemplate = `{
                 }
             }
         },
+        "/logout": {
+            "post": {
+                "description": "Revokes the access token used for the request and, if given, the refresh token of the session.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "summary": "Handling logout",
+                "parameters": [
+                    {
+                        "description": "Refresh token of the session",
+                        "name": "input",
+                        "in": "body",
+                        "schema": {
+                            "$ref": "#/definitions/apiserver.RefreshRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/apiserver.StatusResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/apiserver.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/apiserver.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/logout-all": {
+            "post": {
+                "description": "Revokes all refresh tokens of the authenticated user and every access token issued to them so far.",
+                "produces": [
+                    "application/json"
+                ],
+                "summary": "Handling logout from every session",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/apiserver.StatusResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/apiserver.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/apiserver.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/readyz": {
+            "get": {
+                "description": "Handling the request to check that the server can serve requests: the storage and, if enabled, the cache are pinged concurrently, each bounded by the readiness timeout. The server is not ready once it is shutting down.",
+                "produces": [
+                    "application/json"
+                ],
+                "summary": "Handling the readiness probe",
+                "responses": {
+                    "200": {
+                        "description": "The server is ready",
+                        "schema": {
+                            "$ref": "#/definitions/apiserver.HealthResponse"
+                        }
+                    },
+                    "503": {
+                        "description": "A dependency is down or the server is shutting down",
+                        "schema": {
+                            "$ref": "#/definitions/apiserver.HealthResponse"
+                        }
+                    }
+                }
+            }
+        },
         "/register": {
             "post": {
-                "description": "Handling user registration using given username and password",
+                "description": "Handling user registration using given username and password. Passwords are at most 72 bytes.",
                 "consumes": [
                     "application/json"
                 ],
@@ -91,29 +202,20 @@ const docTemplate = `{
                 }
             }
         },
-        "/tasks": {
+        "/tags": {
             "get": {
-                "description": "Handling the request to fetch tasks for the authenticated user",
+                "description": "Handling the request to list the tags of the authenticated user, ordered by name",
                 "produces": [
                     "application/json"
                 ],
-                "summary": "Handling fetching tasks",
-                "parameters": [
-                    {
-                        "type": "integer",
-                        "description": "User ID",
-                        "name": "userID",
-                        "in": "path",
-                        "required": true
-                    }
-                ],
+                "summary": "Handling fetching tags",
                 "responses": {
                     "200": {
-                        "description": "List of tasks",
+                        "description": "User tags",
                         "schema": {
                             "type": "array",
                             "items": {
-                                "$ref": "#/definitions/models.Task"
+                                "$ref": "#/definitions/models.Tag"
                             }
                         }
                     },
@@ -138,30 +240,30 @@ const docTemplate = `{
                 }
             },
             "post": {
-                "description": "Handling the request to create a task for the authenticated user",
+                "description": "Handling the request to create a tag for the authenticated user. Names are unique per user.",
                 "consumes": [
                     "application/json"
                 ],
                 "produces": [
                     "application/json"
                 ],
-                "summary": "Handling task creation",
+                "summary": "Handling tag creation",
                 "parameters": [
                     {
-                        "description": "Task data",
+                        "description": "Tag name",
                         "name": "input",
                         "in": "body",
                         "required": true,
                         "schema": {
-                            "$ref": "#/definitions/models.Task"
+                            "$ref": "#/definitions/apiserver.TagRequest"
                         }
                     }
                 ],
                 "responses": {
-                    "200": {
-                        "description": "Created task",
+                    "201": {
+                        "description": "Created tag",
                         "schema": {
-                            "$ref": "#/definitions/models.Task"
+                            "$ref": "#/definitions/models.Tag"
                         }
                     },
                     "400": {
@@ -176,6 +278,12 @@ const docTemplate = `{
                             "$ref": "#/definitions/apiserver.ErrorResponse"
                         }
                     },
+                    "409": {
+                        "description": "Error response with details",
+                        "schema": {
+                            "$ref": "#/definitions/apiserver.ErrorResponse"
+                        }
+                    },
                     "500": {
                         "description": "Error response with details",
                         "schema": {
@@ -185,34 +293,39 @@ const docTemplate = `{
                 }
             }
         },
-        "/tasks/{userID}/{taskID}": {
-            "get": {
-                "description": "Handling the request to fetch a specific task for the authenticated user",
+        "/tags/{id}": {
+            "put": {
+                "description": "Handling the request to rename a tag of the authenticated user",
+                "consumes": [
+                    "application/json"
+                ],
                 "produces": [
                     "application/json"
                 ],
-                "summary": "Handling fetching a task",
+                "summary": "Handling tag rename",
                 "parameters": [
                     {
                         "type": "integer",
-                        "description": "User ID",
-                        "name": "userID",
+                        "description": "Tag ID",
+                        "name": "id",
                         "in": "path",
                         "required": true
                     },
                     {
-                        "type": "integer",
-                        "description": "Task ID",
-                        "name": "taskID",
-                        "in": "path",
-                        "required": true
+                        "description": "New tag name",
+                        "name": "input",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/apiserver.TagRequest"
+                        }
                     }
                 ],
                 "responses": {
                     "200": {
-                        "description": "Fetched task",
+                        "description": "Renamed tag",
                         "schema": {
-                            "$ref": "#/definitions/models.Task"
+                            "$ref": "#/definitions/models.Tag"
                         }
                     },
                     "400": {
@@ -232,48 +345,168 @@ const docTemplate = `{
                         "schema": {
                             "$ref": "#/definitions/apiserver.ErrorResponse"
                         }
+                    },
+                    "409": {
+                        "description": "Error response with details",
+                        "schema": {
+                            "$ref": "#/definitions/apiserver.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Error response with details",
+                        "schema": {
+                            "$ref": "#/definitions/apiserver.ErrorResponse"
+                        }
                     }
                 }
             },
-            "put": {
-                "description": "Handling the request to update a specific task for the authenticated user",
-                "consumes": [
-                    "application/json"
-                ],
+            "delete": {
+                "description": "Handling the request to delete a tag of the authenticated user. The tag is detached from every task.",
                 "produces": [
                     "application/json"
                 ],
-                "summary": "Handling updating a task",
+                "summary": "Handling deleting a tag",
                 "parameters": [
                     {
                         "type": "integer",
-                        "description": "User ID",
-                        "name": "userID",
+                        "description": "Tag ID",
+                        "name": "id",
                         "in": "path",
                         "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Tag deleted successfully",
+                        "schema": {
+                            "$ref": "#/definitions/apiserver.StatusResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Error response with details",
+                        "schema": {
+                            "$ref": "#/definitions/apiserver.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Error response with details",
+                        "schema": {
+                            "$ref": "#/definitions/apiserver.ErrorResponse"
+                        }
                     },
+                    "404": {
+                        "description": "Error response with details",
+                        "schema": {
+                            "$ref": "#/definitions/apiserver.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Error response with details",
+                        "schema": {
+                            "$ref": "#/definitions/apiserver.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/tasks": {
+            "get": {
+                "description": "Handling the request to fetch a page of tasks for the authenticated user",
+                "produces": [
+                    "application/json"
+                ],
+                "summary": "Handling fetching tasks",
+                "parameters": [
                     {
                         "type": "integer",
-                        "description": "Task ID",
-                        "name": "taskID",
-                        "in": "path",
-                        "required": true
+                        "default": 50,
+                        "description": "Page size, 1 to 200",
+                        "name": "limit",
+                        "in": "query"
                     },
                     {
-                        "description": "Updated task data",
-                        "name": "input",
-                        "in": "body",
-                        "required": true,
-                        "schema": {
-                            "$ref": "#/definitions/models.Task"
-                        }
+                        "type": "string",
+                        "description": "Cursor returned as next_cursor by the previous page",
+                        "name": "cursor",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Only tasks scheduled at or after this RFC3339 time",
+                        "name": "scheduled_from",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Only tasks scheduled before this RFC3339 time",
+                        "name": "scheduled_to",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Only tasks created at or after this RFC3339 time",
+                        "name": "created_from",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Only tasks created before this RFC3339 time",
+                        "name": "created_to",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Case-insensitive title substring",
+                        "name": "title",
+                        "in": "query"
+                    },
+                    {
+                        "type": "array",
+                        "items": {
+                            "type": "string"
+                        },
+                        "collectionFormat": "multi",
+                        "description": "Statuses to include",
+                        "name": "status",
+                        "in": "query"
+                    },
+                    {
+                        "type": "array",
+                        "items": {
+                            "type": "string"
+                        },
+                        "collectionFormat": "multi",
+                        "description": "Tag names to filter by",
+                        "name": "tag",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "default": "all",
+                        "description": "Whether tasks need all of the tags or any of them: all or any",
+                        "name": "tag_mode",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "default": "id",
+                        "description": "Sort field: id, created_at, scheduled_for, title or priority (then due date, undated last in either order)",
+                        "name": "sort",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "default": "asc",
+                        "description": "Sort order: asc or desc",
+                        "name": "order",
+                        "in": "query"
                     }
                 ],
                 "responses": {
                     "200": {
-                        "description": "Task updated successfully",
+                        "description": "Page of tasks",
                         "schema": {
-                            "$ref": "#/definitions/apiserver.StatusResponse"
+                            "$ref": "#/definitions/models.TaskPage"
                         }
                     },
                     "400": {
@@ -296,33 +529,31 @@ const docTemplate = `{
                     }
                 }
             },
-            "delete": {
-                "description": "Handling the request to delete a specific task for the authenticated user",
+            "post": {
+                "description": "Handling the request to create a task for the authenticated user",
+                "consumes": [
+                    "application/json"
+                ],
                 "produces": [
                     "application/json"
                 ],
-                "summary": "Handling deleting a task",
+                "summary": "Handling task creation",
                 "parameters": [
                     {
-                        "type": "integer",
-                        "description": "User ID",
-                        "name": "userID",
-                        "in": "path",
-                        "required": true
-                    },
-                    {
-                        "type": "integer",
-                        "description": "Task ID",
-                        "name": "taskID",
-                        "in": "path",
-                        "required": true
+                        "description": "Task data",
+                        "name": "input",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/models.Task"
+                        }
                     }
                 ],
                 "responses": {
                     "200": {
-                        "description": "Task deleted successfully",
+                        "description": "Created task",
                         "schema": {
-                            "$ref": "#/definitions/apiserver.ErrorResponse"
+                            "$ref": "#/definitions/models.Task"
                         }
                     },
                     "400": {
@@ -345,18 +576,1496 @@ const docTemplate = `{
                     }
                 }
             }
-        }
-    },
-    "definitions": {
-        "apiserver.ErrorResponse": {
-            "description": "API response with an error message.",
-            "type": "object",
+        },
+        "/tasks/order": {
+            "get": {
+                "description": "Handling the request to list the unfinished tasks of the authenticated user so that every task comes after the tasks blocking it. Tasks that are not blocked can be worked on now; among those the most urgent come first.",
+                "produces": [
+                    "application/json"
+                ],
+                "summary": "Handling fetching tasks in dependency order",
+                "responses": {
+                    "200": {
+                        "description": "Unfinished tasks in dependency order",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/models.Task"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Error response with details",
+                        "schema": {
+                            "$ref": "#/definitions/apiserver.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Error response with details",
+                        "schema": {
+                            "$ref": "#/definitions/apiserver.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Error response with details",
+                        "schema": {
+                            "$ref": "#/definitions/apiserver.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/tasks/stream": {
+            "get": {
+                "description": "Handling the request to receive the task events of the authenticated user as Server-Sent Events. Every event is a JSON object as delivered to webhooks. Events are not replayed, so clients reload the tasks when they (re)connect. The stream ends when the access token expires or the server shuts down; as EventSource cannot set headers, the token may be passed in the access_token query parameter.",
+                "produces": [
+                    "text/event-stream"
+                ],
+                "summary": "Handling the task event stream",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Access token, instead of the Authorization header",
+                        "name": "access_token",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Stream of task events",
+                        "schema": {
+                            "$ref": "#/definitions/models.Event"
+                        }
+                    },
+                    "400": {
+                        "description": "Error response with details",
+                        "schema": {
+                            "$ref": "#/definitions/apiserver.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Error response with details",
+                        "schema": {
+                            "$ref": "#/definitions/apiserver.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Error response with details",
+                        "schema": {
+                            "$ref": "#/definitions/apiserver.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/tasks/ws": {
+            "get": {
+                "description": "Handling the request to receive the task events of the authenticated user over a WebSocket. Every event is a text message with a JSON object as delivered to webhooks; messages from the client are ignored. Events are not replayed, so clients reload the tasks when they (re)connect. The socket is closed when the access token expires or the server shuts down; as browsers cannot set headers on WebSockets, the token may be passed in the access_token query parameter.",
+                "summary": "Handling the task event WebSocket",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Access token, instead of the Authorization header",
+                        "name": "access_token",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "101": {
+                        "description": "Switching to the WebSocket protocol"
+                    },
+                    "400": {
+                        "description": "Error response with details",
+                        "schema": {
+                            "$ref": "#/definitions/apiserver.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Error response with details",
+                        "schema": {
+                            "$ref": "#/definitions/apiserver.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Error response with details",
+                        "schema": {
+                            "$ref": "#/definitions/apiserver.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/tasks/{id}": {
+            "get": {
+                "description": "Handling the request to fetch a specific task for the authenticated user. The response carries an ETag of the task version.",
+                "produces": [
+                    "application/json"
+                ],
+                "summary": "Handling fetching a task",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Task ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "ETag of a cached copy",
+                        "name": "If-None-Match",
+                        "in": "header"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Fetched task",
+                        "schema": {
+                            "$ref": "#/definitions/models.Task"
+                        }
+                    },
+                    "304": {
+                        "description": "Cached copy is current"
+                    },
+                    "400": {
+                        "description": "Error response with details",
+                        "schema": {
+                            "$ref": "#/definitions/apiserver.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Error response with details",
+                        "schema": {
+                            "$ref": "#/definitions/apiserver.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Error response with details",
+                        "schema": {
+                            "$ref": "#/definitions/apiserver.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Error response with details",
+                        "schema": {
+                            "$ref": "#/definitions/apiserver.ErrorResponse"
+                        }
+                    }
+                }
+            },
+            "put": {
+                "description": "Handling the request to update a specific task for the authenticated user",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "summary": "Handling updating a task",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Task ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "ETag the update is conditional on",
+                        "name": "If-Match",
+                        "in": "header"
+                    },
+                    {
+                        "description": "Updated task data",
+                        "name": "input",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/models.Task"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Task updated successfully",
+                        "schema": {
+                            "$ref": "#/definitions/apiserver.StatusResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Error response with details",
+                        "schema": {
+                            "$ref": "#/definitions/apiserver.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Error response with details",
+                        "schema": {
+                            "$ref": "#/definitions/apiserver.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Error response with details",
+                        "schema": {
+                            "$ref": "#/definitions/apiserver.ErrorResponse"
+                        }
+                    },
+                    "409": {
+                        "description": "Error response with details",
+                        "schema": {
+                            "$ref": "#/definitions/apiserver.ErrorResponse"
+                        }
+                    },
+                    "412": {
+                        "description": "Error response with details",
+                        "schema": {
+                            "$ref": "#/definitions/apiserver.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Error response with details",
+                        "schema": {
+                            "$ref": "#/definitions/apiserver.ErrorResponse"
+                        }
+                    }
+                }
+            },
+            "delete": {
+                "description": "Handling the request to delete a specific task for the authenticated user, together with its subtasks",
+                "produces": [
+                    "application/json"
+                ],
+                "summary": "Handling deleting a task",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Task ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "ETag the delete is conditional on",
+                        "name": "If-Match",
+                        "in": "header"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Task deleted successfully",
+                        "schema": {
+                            "$ref": "#/definitions/apiserver.StatusResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Error response with details",
+                        "schema": {
+                            "$ref": "#/definitions/apiserver.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Error response with details",
+                        "schema": {
+                            "$ref": "#/definitions/apiserver.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Error response with details",
+                        "schema": {
+                            "$ref": "#/definitions/apiserver.ErrorResponse"
+                        }
+                    },
+                    "412": {
+                        "description": "Error response with details",
+                        "schema": {
+                            "$ref": "#/definitions/apiserver.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Error response with details",
+                        "schema": {
+                            "$ref": "#/definitions/apiserver.ErrorResponse"
+                        }
+                    }
+                }
+            },
+            "patch": {
+                "description": "Handling the request to change some fields of a task. Accepts an RFC 7396 merge patch (application/merge-patch+json or application/json) or an RFC 6902 JSON patch (application/json-patch+json) on top-level fields.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "summary": "Handling partial task update",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Task ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "ETag the update is conditional on",
+                        "name": "If-Match",
+                        "in": "header"
+                    },
+                    {
+                        "description": "Merge patch object or JSON patch array",
+                        "name": "input",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "type": "object"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Updated task",
+                        "schema": {
+                            "$ref": "#/definitions/models.Task"
+                        }
+                    },
+                    "400": {
+                        "description": "Error response with details",
+                        "schema": {
+                            "$ref": "#/definitions/apiserver.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Error response with details",
+                        "schema": {
+                            "$ref": "#/definitions/apiserver.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Error response with details",
+                        "schema": {
+                            "$ref": "#/definitions/apiserver.ErrorResponse"
+                        }
+                    },
+                    "409": {
+                        "description": "Error response with details",
+                        "schema": {
+                            "$ref": "#/definitions/apiserver.ErrorResponse"
+                        }
+                    },
+                    "412": {
+                        "description": "Error response with details",
+                        "schema": {
+                            "$ref": "#/definitions/apiserver.ErrorResponse"
+                        }
+                    },
+                    "415": {
+                        "description": "Error response with details",
+                        "schema": {
+                            "$ref": "#/definitions/apiserver.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Error response with details",
+                        "schema": {
+                            "$ref": "#/definitions/apiserver.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/tasks/{id}/checklist": {
+            "post": {
+                "description": "Handling the request to append an item to the checklist of a task",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "summary": "Handling adding a checklist item",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Task ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Item text",
+                        "name": "input",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/apiserver.ChecklistItemRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Added item",
+                        "schema": {
+                            "$ref": "#/definitions/models.ChecklistItem"
+                        }
+                    },
+                    "400": {
+                        "description": "Error response with details",
+                        "schema": {
+                            "$ref": "#/definitions/apiserver.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Error response with details",
+                        "schema": {
+                            "$ref": "#/definitions/apiserver.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Error response with details",
+                        "schema": {
+                            "$ref": "#/definitions/apiserver.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Error response with details",
+                        "schema": {
+                            "$ref": "#/definitions/apiserver.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/tasks/{id}/checklist/order": {
+            "put": {
+                "description": "Handling the request to reorder the checklist of a task. The body must list every item of the checklist exactly once.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "summary": "Handling reordering a checklist",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Task ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Item IDs in the new order",
+                        "name": "input",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/apiserver.ChecklistOrderRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Task with the reordered checklist",
+                        "schema": {
+                            "$ref": "#/definitions/models.Task"
+                        }
+                    },
+                    "400": {
+                        "description": "Error response with details",
+                        "schema": {
+                            "$ref": "#/definitions/apiserver.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Error response with details",
+                        "schema": {
+                            "$ref": "#/definitions/apiserver.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Error response with details",
+                        "schema": {
+                            "$ref": "#/definitions/apiserver.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Error response with details",
+                        "schema": {
+                            "$ref": "#/definitions/apiserver.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/tasks/{id}/checklist/{itemID}": {
+            "delete": {
+                "description": "Handling the request to remove an item from the checklist of a task",
+                "produces": [
+                    "application/json"
+                ],
+                "summary": "Handling deleting a checklist item",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Task ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Checklist item ID",
+                        "name": "itemID",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Checklist item deleted successfully",
+                        "schema": {
+                            "$ref": "#/definitions/apiserver.StatusResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Error response with details",
+                        "schema": {
+                            "$ref": "#/definitions/apiserver.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Error response with details",
+                        "schema": {
+                            "$ref": "#/definitions/apiserver.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Error response with details",
+                        "schema": {
+                            "$ref": "#/definitions/apiserver.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Error response with details",
+                        "schema": {
+                            "$ref": "#/definitions/apiserver.ErrorResponse"
+                        }
+                    }
+                }
+            },
+            "patch": {
+                "description": "Handling the request to change the text of a checklist item or mark it done",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "summary": "Handling updating a checklist item",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Task ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Checklist item ID",
+                        "name": "itemID",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Fields to change",
+                        "name": "input",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/apiserver.ChecklistItemPatchRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Updated item",
+                        "schema": {
+                            "$ref": "#/definitions/models.ChecklistItem"
+                        }
+                    },
+                    "400": {
+                        "description": "Error response with details",
+                        "schema": {
+                            "$ref": "#/definitions/apiserver.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Error response with details",
+                        "schema": {
+                            "$ref": "#/definitions/apiserver.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Error response with details",
+                        "schema": {
+                            "$ref": "#/definitions/apiserver.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Error response with details",
+                        "schema": {
+                            "$ref": "#/definitions/apiserver.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/tasks/{id}/children": {
+            "get": {
+                "description": "Handling the request to list the direct subtasks of a task, oldest first",
+                "produces": [
+                    "application/json"
+                ],
+                "summary": "Handling fetching subtasks",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Task ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Subtasks",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/models.Task"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Error response with details",
+                        "schema": {
+                            "$ref": "#/definitions/apiserver.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Error response with details",
+                        "schema": {
+                            "$ref": "#/definitions/apiserver.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Error response with details",
+                        "schema": {
+                            "$ref": "#/definitions/apiserver.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Error response with details",
+                        "schema": {
+                            "$ref": "#/definitions/apiserver.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/tasks/{id}/dependencies/{dependsOnID}": {
+            "put": {
+                "description": "Handling the request to mark a task as blocked by another task of the same user. Dependencies that would form a cycle are rejected.",
+                "produces": [
+                    "application/json"
+                ],
+                "summary": "Handling adding a dependency",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Task ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "description": "ID of the task it depends on",
+                        "name": "dependsOnID",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Dependent task",
+                        "schema": {
+                            "$ref": "#/definitions/models.Task"
+                        }
+                    },
+                    "400": {
+                        "description": "Error response with details",
+                        "schema": {
+                            "$ref": "#/definitions/apiserver.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Error response with details",
+                        "schema": {
+                            "$ref": "#/definitions/apiserver.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Error response with details",
+                        "schema": {
+                            "$ref": "#/definitions/apiserver.ErrorResponse"
+                        }
+                    },
+                    "409": {
+                        "description": "Error response with details",
+                        "schema": {
+                            "$ref": "#/definitions/apiserver.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Error response with details",
+                        "schema": {
+                            "$ref": "#/definitions/apiserver.ErrorResponse"
+                        }
+                    }
+                }
+            },
+            "delete": {
+                "description": "Handling the request to remove a dependency between two tasks. Removing a missing dependency has no effect.",
+                "produces": [
+                    "application/json"
+                ],
+                "summary": "Handling removing a dependency",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Task ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "description": "ID of the task it depends on",
+                        "name": "dependsOnID",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Dependent task",
+                        "schema": {
+                            "$ref": "#/definitions/models.Task"
+                        }
+                    },
+                    "400": {
+                        "description": "Error response with details",
+                        "schema": {
+                            "$ref": "#/definitions/apiserver.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Error response with details",
+                        "schema": {
+                            "$ref": "#/definitions/apiserver.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Error response with details",
+                        "schema": {
+                            "$ref": "#/definitions/apiserver.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Error response with details",
+                        "schema": {
+                            "$ref": "#/definitions/apiserver.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/tasks/{id}/following": {
+            "patch": {
+                "description": "Handling the request to change an occurrence of a recurring task together with the unfinished occurrences after it, which become a series of their own. Changing the rule or the time recreates the later occurrences; an empty rule stops the series, and a rule on a task that does not recur yet makes it recurring.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "summary": "Handling updating following occurrences",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Task ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Fields to change",
+                        "name": "input",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/apiserver.OccurrencePatchRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Updated occurrence",
+                        "schema": {
+                            "$ref": "#/definitions/models.Task"
+                        }
+                    },
+                    "400": {
+                        "description": "Error response with details",
+                        "schema": {
+                            "$ref": "#/definitions/apiserver.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Error response with details",
+                        "schema": {
+                            "$ref": "#/definitions/apiserver.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Error response with details",
+                        "schema": {
+                            "$ref": "#/definitions/apiserver.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Error response with details",
+                        "schema": {
+                            "$ref": "#/definitions/apiserver.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/tasks/{id}/occurrence": {
+            "patch": {
+                "description": "Handling the request to change a single occurrence of a recurring task. The series and its other occurrences are left as they are; a rescheduled occurrence keeps its due date offset.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "summary": "Handling updating an occurrence",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Task ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Fields to change",
+                        "name": "input",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/apiserver.OccurrencePatchRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Updated occurrence",
+                        "schema": {
+                            "$ref": "#/definitions/models.Task"
+                        }
+                    },
+                    "400": {
+                        "description": "Error response with details",
+                        "schema": {
+                            "$ref": "#/definitions/apiserver.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Error response with details",
+                        "schema": {
+                            "$ref": "#/definitions/apiserver.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Error response with details",
+                        "schema": {
+                            "$ref": "#/definitions/apiserver.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Error response with details",
+                        "schema": {
+                            "$ref": "#/definitions/apiserver.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/tasks/{id}/tags/{tagID}": {
+            "put": {
+                "description": "Handling the request to attach a tag to a task. Attaching a tag the task already carries has no effect.",
+                "produces": [
+                    "application/json"
+                ],
+                "summary": "Handling attaching a tag",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Task ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Tag ID",
+                        "name": "tagID",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Tagged task",
+                        "schema": {
+                            "$ref": "#/definitions/models.Task"
+                        }
+                    },
+                    "400": {
+                        "description": "Error response with details",
+                        "schema": {
+                            "$ref": "#/definitions/apiserver.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Error response with details",
+                        "schema": {
+                            "$ref": "#/definitions/apiserver.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Error response with details",
+                        "schema": {
+                            "$ref": "#/definitions/apiserver.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Error response with details",
+                        "schema": {
+                            "$ref": "#/definitions/apiserver.ErrorResponse"
+                        }
+                    }
+                }
+            },
+            "delete": {
+                "description": "Handling the request to detach a tag from a task. Detaching a tag the task does not carry has no effect.",
+                "produces": [
+                    "application/json"
+                ],
+                "summary": "Handling detaching a tag",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Task ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Tag ID",
+                        "name": "tagID",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Untagged task",
+                        "schema": {
+                            "$ref": "#/definitions/models.Task"
+                        }
+                    },
+                    "400": {
+                        "description": "Error response with details",
+                        "schema": {
+                            "$ref": "#/definitions/apiserver.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Error response with details",
+                        "schema": {
+                            "$ref": "#/definitions/apiserver.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Error response with details",
+                        "schema": {
+                            "$ref": "#/definitions/apiserver.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Error response with details",
+                        "schema": {
+                            "$ref": "#/definitions/apiserver.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/tasks/{id}/transition": {
+            "post": {
+                "description": "Handling the request to move a task to another lifecycle status. Illegal moves are rejected, and so is a move racing another change of the task.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "summary": "Handling task status transition",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Task ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Target status",
+                        "name": "input",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/apiserver.TransitionRequest"
+                        }
+                    },
+                    {
+                        "type": "string",
+                        "description": "ETag the transition is conditional on",
+                        "name": "If-Match",
+                        "in": "header"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Transitioned task",
+                        "schema": {
+                            "$ref": "#/definitions/models.Task"
+                        }
+                    },
+                    "400": {
+                        "description": "Error response with details",
+                        "schema": {
+                            "$ref": "#/definitions/apiserver.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Error response with details",
+                        "schema": {
+                            "$ref": "#/definitions/apiserver.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Error response with details",
+                        "schema": {
+                            "$ref": "#/definitions/apiserver.ErrorResponse"
+                        }
+                    },
+                    "409": {
+                        "description": "Error response with details",
+                        "schema": {
+                            "$ref": "#/definitions/apiserver.ErrorResponse"
+                        }
+                    },
+                    "412": {
+                        "description": "Error response with details",
+                        "schema": {
+                            "$ref": "#/definitions/apiserver.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Error response with details",
+                        "schema": {
+                            "$ref": "#/definitions/apiserver.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/token/refresh": {
+            "post": {
+                "description": "Exchanges a refresh token for a new access token and a new refresh token. The presented refresh token is revoked.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "summary": "Handling access token refresh",
+                "parameters": [
+                    {
+                        "description": "Refresh token",
+                        "name": "input",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/apiserver.RefreshRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/apiserver.TokenResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/apiserver.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/apiserver.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/apiserver.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/webhooks": {
+            "get": {
+                "description": "Handling the request to list the webhooks of the authenticated user. Secrets are not included.",
+                "produces": [
+                    "application/json"
+                ],
+                "summary": "Handling fetching webhooks",
+                "responses": {
+                    "200": {
+                        "description": "User webhooks",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/models.Webhook"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Error response with details",
+                        "schema": {
+                            "$ref": "#/definitions/apiserver.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Error response with details",
+                        "schema": {
+                            "$ref": "#/definitions/apiserver.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Error response with details",
+                        "schema": {
+                            "$ref": "#/definitions/apiserver.ErrorResponse"
+                        }
+                    }
+                }
+            },
+            "post": {
+                "description": "Handling the request to subscribe a URL to task events. The URL must point to a public address. Deliveries are POST requests signed with HMAC-SHA256: the X-Webhook-Signature header is \"sha256=\" and the hex HMAC, keyed with the secret, of the X-Webhook-Timestamp header, a dot and the body. The secret is only returned here.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "summary": "Handling webhook creation",
+                "parameters": [
+                    {
+                        "description": "Webhook data",
+                        "name": "input",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/apiserver.WebhookRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Created webhook",
+                        "schema": {
+                            "$ref": "#/definitions/models.Webhook"
+                        }
+                    },
+                    "400": {
+                        "description": "Error response with details",
+                        "schema": {
+                            "$ref": "#/definitions/apiserver.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Error response with details",
+                        "schema": {
+                            "$ref": "#/definitions/apiserver.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Error response with details",
+                        "schema": {
+                            "$ref": "#/definitions/apiserver.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/webhooks/{id}": {
+            "put": {
+                "description": "Handling the request to change the URL, events or secret of a webhook. An empty secret keeps the current one.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "summary": "Handling updating a webhook",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Webhook ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Webhook data",
+                        "name": "input",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/apiserver.WebhookRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Updated webhook",
+                        "schema": {
+                            "$ref": "#/definitions/models.Webhook"
+                        }
+                    },
+                    "400": {
+                        "description": "Error response with details",
+                        "schema": {
+                            "$ref": "#/definitions/apiserver.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Error response with details",
+                        "schema": {
+                            "$ref": "#/definitions/apiserver.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Error response with details",
+                        "schema": {
+                            "$ref": "#/definitions/apiserver.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Error response with details",
+                        "schema": {
+                            "$ref": "#/definitions/apiserver.ErrorResponse"
+                        }
+                    }
+                }
+            },
+            "delete": {
+                "description": "Handling the request to delete a webhook. Its pending deliveries are dropped.",
+                "produces": [
+                    "application/json"
+                ],
+                "summary": "Handling deleting a webhook",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Webhook ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Webhook deleted successfully",
+                        "schema": {
+                            "$ref": "#/definitions/apiserver.StatusResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Error response with details",
+                        "schema": {
+                            "$ref": "#/definitions/apiserver.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Error response with details",
+                        "schema": {
+                            "$ref": "#/definitions/apiserver.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Error response with details",
+                        "schema": {
+                            "$ref": "#/definitions/apiserver.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Error response with details",
+                        "schema": {
+                            "$ref": "#/definitions/apiserver.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        }
+    },
+    "definitions": {
+        "apiserver.ChecklistItemPatchRequest": {
+            "description": "Checklist item fields to change; omitted fields are kept.",
+            "type": "object",
+            "properties": {
+                "done": {
+                    "type": "boolean"
+                },
+                "text": {
+                    "type": "string"
+                }
+            }
+        },
+        "apiserver.ChecklistItemRequest": {
+            "description": "Text of a new checklist item.",
+            "type": "object",
+            "properties": {
+                "text": {
+                    "type": "string"
+                }
+            }
+        },
+        "apiserver.ChecklistOrderRequest": {
+            "description": "IDs of every checklist item of the task in the new order.",
+            "type": "object",
+            "properties": {
+                "item_ids": {
+                    "type": "array",
+                    "items": {
+                        "type": "integer"
+                    }
+                }
+            }
+        },
+        "apiserver.DependencyStatus": {
+            "description": "Whether a dependency answered, \"up\" or \"down\", how long the check took in milliseconds, and the error if it failed.",
+            "type": "object",
+            "properties": {
+                "error": {
+                    "type": "string"
+                },
+                "latency_ms": {
+                    "type": "number"
+                },
+                "status": {
+                    "type": "string"
+                }
+            }
+        },
+        "apiserver.ErrorResponse": {
+            "description": "API response with an error message.",
+            "type": "object",
             "properties": {
                 "error": {
                     "type": "string"
                 }
             }
         },
+        "apiserver.HealthResponse": {
+            "description": "Overall status, \"ok\" or \"unavailable\", and the status of each dependency checked.",
+            "type": "object",
+            "properties": {
+                "checks": {
+                    "type": "object",
+                    "additionalProperties": {
+                        "$ref": "#/definitions/apiserver.DependencyStatus"
+                    }
+                },
+                "status": {
+                    "type": "string"
+                }
+            }
+        },
+        "apiserver.OccurrencePatchRequest": {
+            "description": "Fields to change on an occurrence, or on it and the occurrences following it; omitted fields are kept.",
+            "type": "object",
+            "properties": {
+                "description": {
+                    "type": "string"
+                },
+                "priority": {
+                    "type": "string",
+                    "enum": [
+                        "P0",
+                        "P1",
+                        "P2",
+                        "P3",
+                        "P4"
+                    ]
+                },
+                "rrule": {
+                    "type": "string"
+                },
+                "scheduled_for": {
+                    "type": "string"
+                },
+                "title": {
+                    "type": "string"
+                }
+            }
+        },
+        "apiserver.RefreshRequest": {
+            "description": "Refresh token to rotate or revoke.",
+            "type": "object",
+            "properties": {
+                "refresh_token": {
+                    "type": "string"
+                }
+            }
+        },
         "apiserver.StatusResponse": {
             "description": "Successful API response with a message.",
             "type": "object",
@@ -366,36 +2075,284 @@ const docTemplate = `{
                 }
             }
         },
+        "apiserver.TagRequest": {
+            "description": "Name of a tag to create or rename to.",
+            "type": "object",
+            "properties": {
+                "name": {
+                    "type": "string"
+                }
+            }
+        },
         "apiserver.TokenResponse": {
-            "description": "API response with an access token.",
+            "description": "API response with an access token, a refresh token and the access token lifetime in seconds.",
             "type": "object",
             "properties": {
+                "expires_in": {
+                    "type": "integer"
+                },
+                "refresh_token": {
+                    "type": "string"
+                },
                 "token": {
                     "type": "string"
                 }
             }
         },
+        "apiserver.TransitionRequest": {
+            "description": "Target status of a task lifecycle transition.",
+            "type": "object",
+            "properties": {
+                "status": {
+                    "$ref": "#/definitions/models.TaskStatus"
+                }
+            }
+        },
+        "apiserver.WebhookRequest": {
+            "description": "Webhook URL, secret and the events it receives.",
+            "type": "object",
+            "properties": {
+                "events": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "secret": {
+                    "type": "string"
+                },
+                "url": {
+                    "type": "string"
+                }
+            }
+        },
+        "models.ChecklistItem": {
+            "description": "Checklist item of a task with its text, completion and position.",
+            "type": "object",
+            "properties": {
+                "done": {
+                    "type": "boolean"
+                },
+                "id": {
+                    "type": "integer"
+                },
+                "position": {
+                    "type": "integer"
+                },
+                "text": {
+                    "type": "string"
+                }
+            }
+        },
+        "models.Event": {
+            "description": "Event is the payload delivered to webhooks.",
+            "type": "object",
+            "properties": {
+                "event": {
+                    "$ref": "#/definitions/models.EventType"
+                },
+                "occurred_at": {
+                    "type": "string"
+                },
+                "reminder": {
+                    "$ref": "#/definitions/models.Reminder"
+                },
+                "task": {
+                    "$ref": "#/definitions/models.Task"
+                }
+            }
+        },
+        "models.EventType": {
+            "type": "string",
+            "enum": [
+                "task.created",
+                "task.updated",
+                "task.deleted",
+                "task.completed",
+                "task.reminder"
+            ],
+            "x-enum-varnames": [
+                "EventTaskCreated",
+                "EventTaskUpdated",
+                "EventTaskDeleted",
+                "EventTaskCompleted",
+                "EventTaskReminder"
+            ]
+        },
+        "models.Reminder": {
+            "description": "Reminder is fired for a task that is not finished when its scheduled time, less the offset, arrives.",
+            "type": "object",
+            "properties": {
+                "fired_at": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "integer"
+                },
+                "offset": {
+                    "type": "integer"
+                },
+                "remind_at": {
+                    "type": "string"
+                },
+                "task_id": {
+                    "type": "integer"
+                },
+                "title": {
+                    "type": "string"
+                }
+            }
+        },
+        "models.Tag": {
+            "description": "Tag with its ID and name.",
+            "type": "object",
+            "properties": {
+                "id": {
+                    "type": "integer"
+                },
+                "name": {
+                    "type": "string"
+                }
+            }
+        },
         "models.Task": {
-            "description": "Task details with ID, title, description, creation time, management time, and associated user ID.",
+            "description": "Task details with ID, title, description, creation time, management time, due date, priority, status, tags, parent task, checklist, dependencies, recurrence, and associated user ID. Overdue, progress and blocked are computed by the server.",
             "type": "object",
             "properties": {
+                "blocked": {
+                    "type": "boolean"
+                },
+                "blocked_by": {
+                    "type": "array",
+                    "items": {
+                        "type": "integer"
+                    }
+                },
+                "checklist": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.ChecklistItem"
+                    }
+                },
                 "created_at": {
                     "type": "string"
                 },
                 "description": {
                     "type": "string"
                 },
+                "due_at": {
+                    "type": "string"
+                },
                 "id": {
                     "type": "integer"
                 },
+                "occurrence_at": {
+                    "type": "string"
+                },
+                "overdue": {
+                    "type": "boolean"
+                },
+                "parent_id": {
+                    "type": "integer"
+                },
+                "priority": {
+                    "type": "string",
+                    "enum": [
+                        "P0",
+                        "P1",
+                        "P2",
+                        "P3",
+                        "P4"
+                    ]
+                },
+                "progress": {
+                    "type": "integer"
+                },
+                "rrule": {
+                    "type": "string"
+                },
                 "scheduled_for": {
                     "type": "string"
                 },
+                "series_id": {
+                    "type": "integer"
+                },
+                "status": {
+                    "$ref": "#/definitions/models.TaskStatus"
+                },
+                "tags": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.Tag"
+                    }
+                },
                 "title": {
                     "type": "string"
                 },
                 "user_id": {
                     "type": "integer"
+                },
+                "version": {
+                    "type": "integer"
+                }
+            }
+        },
+        "models.TaskPage": {
+            "description": "Page of tasks with the cursor of the next page and the number of tasks matching the filters.",
+            "type": "object",
+            "properties": {
+                "next_cursor": {
+                    "type": "string"
+                },
+                "tasks": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.Task"
+                    }
+                },
+                "total": {
+                    "type": "integer"
+                }
+            }
+        },
+        "models.TaskStatus": {
+            "type": "string",
+            "enum": [
+                "todo",
+                "in_progress",
+                "blocked",
+                "done",
+                "cancelled"
+            ],
+            "x-enum-varnames": [
+                "StatusTodo",
+                "StatusInProgress",
+                "StatusBlocked",
+                "StatusDone",
+                "StatusCancelled"
+            ]
+        },
+        "models.Webhook": {
+            "description": "Webhook subscription. The secret is only returned when the webhook is created.",
+            "type": "object",
+            "properties": {
+                "created_at": {
+                    "type": "string"
+                },
+                "events": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "id": {
+                    "type": "integer"
+                },
+                "secret": {
+                    "type": "string"
+                },
+                "url": {
+                    "type": "string"
                 }
             }
         }
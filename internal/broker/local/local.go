@@ -0,0 +1,70 @@
+// Package local fans events out to the subscribers of a user within the
+// process.
+package local
+
+import "sync"
+
+// bufferSize is how many events a subscriber may fall behind by.
+const bufferSize = 64
+
+// Local delivers every event published for a user to the user's current
+// subscribers. A subscriber that falls behind by more than bufferSize
+// events is dropped and its channel closed, so that it reconnects and
+// reloads instead of silently missing events.
+type Local struct {
+	mu          sync.Mutex
+	subscribers map[int]map[chan []byte]bool
+}
+
+func New() *Local {
+	return &Local{
+		subscribers: make(map[int]map[chan []byte]bool),
+	}
+}
+
+func (l *Local) Publish(userID int, payload []byte) error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	for ch := range l.subscribers[userID] {
+		select {
+		case ch <- payload:
+		default:
+			l.unsubscribe(userID, ch)
+		}
+	}
+	return nil
+}
+
+// Subscribe returns a channel receiving the events of the user and a
+// function ending the subscription.
+func (l *Local) Subscribe(userID int) (<-chan []byte, func()) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	ch := make(chan []byte, bufferSize)
+	if l.subscribers[userID] == nil {
+		l.subscribers[userID] = make(map[chan []byte]bool)
+	}
+	l.subscribers[userID][ch] = true
+
+	return ch, func() {
+		l.mu.Lock()
+		defer l.mu.Unlock()
+		l.unsubscribe(userID, ch)
+	}
+}
+
+// unsubscribe removes and closes a subscriber channel unless it was
+// removed before. The caller must hold l.mu.
+func (l *Local) unsubscribe(userID int, ch chan []byte) {
+	if !l.subscribers[userID][ch] {
+		return
+	}
+
+	delete(l.subscribers[userID], ch)
+	if len(l.subscribers[userID]) == 0 {
+		delete(l.subscribers, userID)
+	}
+	close(ch)
+}
@@ -0,0 +1,130 @@
+// Package redis fans events out through Redis pub/sub, so that the
+// subscribers of a user receive them whichever instance they are
+// connected to.
+package redis
+
+import (
+	"TaskManager/internal/broker/local"
+	"context"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/go-redis/redis/v8"
+)
+
+// channelPrefix starts the Redis channel of a user's events. Instances
+// only subscribe to the channels of the users they serve streams to.
+const channelPrefix = "taskmanager:events:"
+
+type Config struct {
+	Addr     string
+	Password string
+	DB       int
+}
+
+// Redis publishes events to Redis and delivers the events received from
+// it to the local subscribers.
+type Redis struct {
+	*local.Local
+	config *Config
+	redis  *redis.Client
+	pubsub *redis.PubSub
+
+	mu sync.Mutex
+	// subscribers counts the local subscribers of each user whose channel
+	// is subscribed to.
+	subscribers map[int]int
+}
+
+func New(config *Config) *Redis {
+	return &Redis{
+		Local:       local.New(),
+		config:      config,
+		subscribers: make(map[int]int),
+	}
+}
+
+// Open connects to Redis and starts receiving the events of the users
+// subscribed to. It may be called again after an error.
+func (r *Redis) Open() error {
+	if r.redis == nil {
+		r.redis = redis.NewClient(&redis.Options{
+			Addr:     r.config.Addr,
+			Password: r.config.Password,
+			DB:       r.config.DB,
+		})
+	}
+
+	if err := r.redis.Ping(context.Background()).Err(); err != nil {
+		return err
+	}
+
+	r.pubsub = r.redis.Subscribe(context.Background())
+	go r.receive()
+	return nil
+}
+
+func (r *Redis) Close() error {
+	r.pubsub.Close()
+	return r.redis.Close()
+}
+
+// Publish sends an event to every instance serving a stream of the user,
+// this one included. If Redis cannot be reached, it is at least delivered
+// locally.
+func (r *Redis) Publish(userID int, payload []byte) error {
+	if err := r.redis.Publish(context.Background(), userChannel(userID), payload).Err(); err != nil {
+		r.Local.Publish(userID, payload)
+		return err
+	}
+	return nil
+}
+
+// Subscribe is local.Subscribe that also subscribes to the user's channel
+// while the user has subscribers on this instance.
+func (r *Redis) Subscribe(userID int) (<-chan []byte, func()) {
+	r.mu.Lock()
+	r.subscribers[userID]++
+	if r.subscribers[userID] == 1 {
+		// go-redis keeps the channel and subscribes again when it
+		// reconnects, so a failure here is retried.
+		r.pubsub.Subscribe(context.Background(), userChannel(userID))
+	}
+	r.mu.Unlock()
+
+	events, cancel := r.Local.Subscribe(userID)
+
+	var once sync.Once
+	return events, func() {
+		once.Do(func() {
+			cancel()
+
+			r.mu.Lock()
+			defer r.mu.Unlock()
+			r.subscribers[userID]--
+			if r.subscribers[userID] == 0 {
+				delete(r.subscribers, userID)
+				r.pubsub.Unsubscribe(context.Background(), userChannel(userID))
+			}
+		})
+	}
+}
+
+// receive delivers the events from Redis until the subscription is
+// closed. go-redis reconnects the subscription if the connection drops.
+func (r *Redis) receive() {
+	for message := range r.pubsub.Channel() {
+		id, found := strings.CutPrefix(message.Channel, channelPrefix)
+		userID, err := strconv.Atoi(id)
+		if !found || err != nil {
+			continue
+		}
+		r.Local.Publish(userID, []byte(message.Payload))
+	}
+}
+
+// userChannel returns the Redis channel of a user's events.
+func userChannel(userID int) string {
+	return channelPrefix + strconv.Itoa(userID)
+}
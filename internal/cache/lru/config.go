@@ -0,0 +1,13 @@
+package lru
+
+type Config struct {
+	// MaxEntries bounds the number of cached keys; the least recently used
+	// key is evicted first.
+	MaxEntries int `toml:"max_entries"`
+}
+
+func NewConfig() *Config {
+	return &Config{
+		MaxEntries: 10000,
+	}
+}
@@ -0,0 +1,119 @@
+package lru
+
+import (
+	"container/list"
+	"context"
+	"errors"
+	"sync"
+	"time"
+)
+
+var ErrNotFound = errors.New("key not found")
+
+type entry struct {
+	key       string
+	value     string
+	expiresAt time.Time
+}
+
+func (e *entry) expired(now time.Time) bool {
+	return !e.expiresAt.IsZero() && now.After(e.expiresAt)
+}
+
+// LRU is an in-process, size-bounded cache with per-entry expiration.
+type LRU struct {
+	config *Config
+
+	mu      sync.Mutex
+	order   *list.List // front is most recently used
+	entries map[string]*list.Element
+}
+
+func New(config *Config) *LRU {
+	return &LRU{
+		config:  config,
+		order:   list.New(),
+		entries: make(map[string]*list.Element),
+	}
+}
+
+func (c *LRU) Get(ctx context.Context, key string) (string, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	el, ok := c.entries[key]
+	if !ok {
+		return "", ErrNotFound
+	}
+
+	e := el.Value.(*entry)
+	if e.expired(time.Now()) {
+		c.remove(el)
+		return "", ErrNotFound
+	}
+
+	c.order.MoveToFront(el)
+	return e.value, nil
+}
+
+// Set stores value under key. A zero expiration keeps the entry until it
+// is evicted.
+func (c *LRU) Set(ctx context.Context, key, value string, expiration time.Duration) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	var expiresAt time.Time
+	if expiration > 0 {
+		expiresAt = time.Now().Add(expiration)
+	}
+
+	if el, ok := c.entries[key]; ok {
+		e := el.Value.(*entry)
+		e.value = value
+		e.expiresAt = expiresAt
+		c.order.MoveToFront(el)
+		return nil
+	}
+
+	c.entries[key] = c.order.PushFront(&entry{
+		key:       key,
+		value:     value,
+		expiresAt: expiresAt,
+	})
+
+	for c.config.MaxEntries > 0 && c.order.Len() > c.config.MaxEntries {
+		c.remove(c.order.Back())
+	}
+
+	return nil
+}
+
+func (c *LRU) Del(ctx context.Context, key string) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if el, ok := c.entries[key]; ok {
+		c.remove(el)
+	}
+
+	return nil
+}
+
+// Ping always succeeds, as the cache is in-process.
+func (c *LRU) Ping(ctx context.Context) error {
+	return nil
+}
+
+// Len returns the number of entries, including expired ones not yet
+// evicted.
+func (c *LRU) Len() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return c.order.Len()
+}
+
+func (c *LRU) remove(el *list.Element) {
+	c.order.Remove(el)
+	delete(c.entries, el.Value.(*entry).key)
+}
@@ -0,0 +1,141 @@
+package lru
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestEvictsLeastRecentlyUsed(t *testing.T) {
+	ctx := context.Background()
+	c := New(&Config{MaxEntries: 3})
+
+	for _, key := range []string{"a", "b", "c"} {
+		c.Set(ctx, key, key, 0)
+	}
+
+	// Reading a makes b the least recently used.
+	if value, err := c.Get(ctx, "a"); err != nil || value != "a" {
+		t.Fatalf("Get(a) = %q, %v", value, err)
+	}
+	c.Set(ctx, "d", "d", 0)
+
+	if _, err := c.Get(ctx, "b"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("Get(b) = %v, want ErrNotFound after eviction", err)
+	}
+	for _, key := range []string{"a", "c", "d"} {
+		if value, err := c.Get(ctx, key); err != nil || value != key {
+			t.Errorf("Get(%s) = %q, %v, want it kept", key, value, err)
+		}
+	}
+	if n := c.Len(); n != 3 {
+		t.Errorf("Len() = %d, want 3", n)
+	}
+
+	// Overwriting refreshes the key too: a, c and d were read in that
+	// order, so c goes once a is written again.
+	c.Set(ctx, "a", "a2", 0)
+	c.Set(ctx, "e", "e", 0)
+	if _, err := c.Get(ctx, "c"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("Get(c) = %v, want ErrNotFound after eviction", err)
+	}
+	if value, _ := c.Get(ctx, "a"); value != "a2" {
+		t.Errorf("Get(a) = %q, want the overwritten value a2", value)
+	}
+}
+
+func TestUnbounded(t *testing.T) {
+	ctx := context.Background()
+	c := New(&Config{})
+
+	for i := 0; i < 100; i++ {
+		c.Set(ctx, fmt.Sprint(i), "x", 0)
+	}
+	if n := c.Len(); n != 100 {
+		t.Errorf("Len() = %d, want 100 without MaxEntries", n)
+	}
+}
+
+func TestExpiration(t *testing.T) {
+	ctx := context.Background()
+	c := New(NewConfig())
+
+	c.Set(ctx, "short", "1", 20*time.Millisecond)
+	c.Set(ctx, "long", "2", time.Hour)
+	c.Set(ctx, "forever", "3", 0)
+
+	if value, err := c.Get(ctx, "short"); err != nil || value != "1" {
+		t.Fatalf("Get(short) before expiry = %q, %v", value, err)
+	}
+
+	time.Sleep(30 * time.Millisecond)
+
+	if _, err := c.Get(ctx, "short"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("Get(short) after expiry = %v, want ErrNotFound", err)
+	}
+	if n := c.Len(); n != 2 {
+		t.Errorf("Len() = %d, want the expired entry dropped on read", n)
+	}
+	for key, want := range map[string]string{"long": "2", "forever": "3"} {
+		if value, err := c.Get(ctx, key); err != nil || value != want {
+			t.Errorf("Get(%s) = %q, %v, want %q", key, value, err, want)
+		}
+	}
+
+	// Setting a key again replaces its expiration.
+	c.Set(ctx, "long", "2", 20*time.Millisecond)
+	c.Set(ctx, "forever", "3", 20*time.Millisecond)
+	c.Set(ctx, "forever", "3", 0)
+	time.Sleep(30 * time.Millisecond)
+	if _, err := c.Get(ctx, "long"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("Get(long) = %v, want the new expiration applied", err)
+	}
+	if _, err := c.Get(ctx, "forever"); err != nil {
+		t.Errorf("Get(forever) = %v, want the expiration cleared", err)
+	}
+}
+
+func TestDel(t *testing.T) {
+	ctx := context.Background()
+	c := New(NewConfig())
+
+	c.Set(ctx, "key", "1", 0)
+	c.Del(ctx, "key")
+	c.Del(ctx, "missing")
+
+	if _, err := c.Get(ctx, "key"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("Get after Del = %v, want ErrNotFound", err)
+	}
+	if n := c.Len(); n != 0 {
+		t.Errorf("Len() = %d, want 0", n)
+	}
+}
+
+func TestConcurrentUse(t *testing.T) {
+	ctx := context.Background()
+	c := New(&Config{MaxEntries: 10})
+
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			for j := 0; j < 200; j++ {
+				key := fmt.Sprint((i + j) % 20)
+				c.Set(ctx, key, key, time.Minute)
+				c.Get(ctx, key)
+				if j%7 == 0 {
+					c.Del(ctx, key)
+				}
+			}
+		}(i)
+	}
+	wg.Wait()
+
+	if n := c.Len(); n > 10 {
+		t.Errorf("Len() = %d, want at most MaxEntries", n)
+	}
+}
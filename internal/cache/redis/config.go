@@ -1,10 +1,19 @@
 package redis
 
+import "time"
+
 type Config struct {
-	addr     string `toml:"addr"`
-	password string `toml:"password"`
+	Addr     string `toml:"addr"`
+	Password string `toml:"password"`
+	DB       int    `toml:"db"`
+	// Timeout bounds every cache call; zero leaves calls bounded only by
+	// the caller's context.
+	Timeout time.Duration `toml:"timeout"`
 }
 
 func NewConfig() *Config {
-	return &Config{}
+	return &Config{
+		Addr:    "localhost:6379",
+		Timeout: time.Second,
+	}
 }
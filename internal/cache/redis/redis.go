@@ -18,33 +18,60 @@ func New(config *Config) *Redis {
 	}
 }
 
-func (r *Redis) Open() {
-	r.redis = redis.NewClient(&redis.Options{
-		Addr:     r.config.addr,
-		Password: r.config.password,
-		DB:       0,
-	})
+// Open connects to Redis and checks that it answers. It may be called
+// again after an error.
+func (r *Redis) Open() error {
+	if r.redis == nil {
+		r.redis = redis.NewClient(&redis.Options{
+			Addr:     r.config.Addr,
+			Password: r.config.Password,
+			DB:       r.config.DB,
+		})
+	}
+
+	return r.redis.Ping(context.Background()).Err()
 }
 
-func (r *Redis) Close() {
-	r.redis.Close()
+func (r *Redis) Close() error {
+	return r.redis.Close()
 }
 
-func (r *Redis) Del(key, value string, expiration time.Duration) error {
-	res := r.redis.Del(context.TODO(), key)
+func (r *Redis) Ping(ctx context.Context) error {
+	return r.redis.Ping(ctx).Err()
+}
+
+func (r *Redis) Del(ctx context.Context, key string) error {
+	ctx, cancel := r.withTimeout(ctx)
+	defer cancel()
+
+	res := r.redis.Del(ctx, key)
 	return res.Err()
 }
 
-func (r *Redis) Set(key, value string, expiration time.Duration) error {
-	res := r.redis.Set(context.TODO(), key, value, expiration)
+func (r *Redis) Set(ctx context.Context, key, value string, expiration time.Duration) error {
+	ctx, cancel := r.withTimeout(ctx)
+	defer cancel()
+
+	res := r.redis.Set(ctx, key, value, expiration)
 	return res.Err()
 }
 
-func (r *Redis) Get(key string) (string, error) {
-	res := r.redis.Get(context.TODO(), key)
+func (r *Redis) Get(ctx context.Context, key string) (string, error) {
+	ctx, cancel := r.withTimeout(ctx)
+	defer cancel()
+
+	res := r.redis.Get(ctx, key)
 	if res.Err() != nil {
 		return "", res.Err()
 	}
 
 	return res.Val(), nil
 }
+
+// withTimeout bounds a cache call by Timeout, if it is set.
+func (r *Redis) withTimeout(ctx context.Context) (context.Context, context.CancelFunc) {
+	if r.config.Timeout <= 0 {
+		return ctx, func() {}
+	}
+	return context.WithTimeout(ctx, r.config.Timeout)
+}
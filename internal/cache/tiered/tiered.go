@@ -0,0 +1,93 @@
+package tiered
+
+import (
+	"context"
+	"time"
+)
+
+type Cache interface {
+	Get(ctx context.Context, key string) (string, error)
+	Set(ctx context.Context, key string, value string, expiration time.Duration) error
+	Del(ctx context.Context, key string) error
+	Ping(ctx context.Context) error
+}
+
+// Tiered puts a local cache in front of a shared remote one. Reads are
+// served locally when possible; writes and deletes go to both tiers.
+// Local copies live for at most localTTL, which bounds how long a node can
+// serve a value another node has already changed in the remote tier.
+type Tiered struct {
+	local    Cache
+	remote   Cache
+	localTTL time.Duration
+	// skipLocal matches the keys kept in the remote tier only.
+	skipLocal func(key string) bool
+}
+
+func New(local, remote Cache, localTTL time.Duration) *Tiered {
+	return &Tiered{
+		local:    local,
+		remote:   remote,
+		localTTL: localTTL,
+	}
+}
+
+// SkipLocal keeps the keys skip matches out of the local tier, for values
+// every node must see as soon as another node changes them.
+func (t *Tiered) SkipLocal(skip func(key string) bool) {
+	t.skipLocal = skip
+}
+
+func (t *Tiered) Get(ctx context.Context, key string) (string, error) {
+	if t.remoteOnly(key) {
+		return t.remote.Get(ctx, key)
+	}
+
+	if value, err := t.local.Get(ctx, key); err == nil {
+		return value, nil
+	}
+
+	value, err := t.remote.Get(ctx, key)
+	if err != nil {
+		return "", err
+	}
+
+	t.local.Set(ctx, key, value, t.localTTL)
+
+	return value, nil
+}
+
+func (t *Tiered) Set(ctx context.Context, key, value string, expiration time.Duration) error {
+	if err := t.remote.Set(ctx, key, value, expiration); err != nil {
+		return err
+	}
+	if t.remoteOnly(key) {
+		return nil
+	}
+
+	return t.local.Set(ctx, key, value, t.ttl(expiration))
+}
+
+func (t *Tiered) Del(ctx context.Context, key string) error {
+	t.local.Del(ctx, key)
+
+	return t.remote.Del(ctx, key)
+}
+
+// Ping checks the remote tier; the local one is always available.
+func (t *Tiered) Ping(ctx context.Context) error {
+	return t.remote.Ping(ctx)
+}
+
+// remoteOnly tells whether key bypasses the local tier.
+func (t *Tiered) remoteOnly(key string) bool {
+	return t.skipLocal != nil && t.skipLocal(key)
+}
+
+// ttl caps expiration at localTTL.
+func (t *Tiered) ttl(expiration time.Duration) time.Duration {
+	if expiration == 0 || expiration > t.localTTL {
+		return t.localTTL
+	}
+	return expiration
+}
@@ -0,0 +1,59 @@
+package tiered
+
+import (
+	"TaskManager/internal/cache/lru"
+	"context"
+	"strings"
+	"testing"
+	"time"
+)
+
+// newNodes returns two tiered caches sharing a remote tier, as two
+// instances sharing Redis would.
+func newNodes() (*Tiered, *Tiered) {
+	remote := lru.New(lru.NewConfig())
+	return New(lru.New(lru.NewConfig()), remote, time.Minute),
+		New(lru.New(lru.NewConfig()), remote, time.Minute)
+}
+
+func TestLocalCopyOutlivesRemoteChange(t *testing.T) {
+	ctx := context.Background()
+	a, b := newNodes()
+
+	a.Set(ctx, "key", "1", 0)
+	if value, _ := b.Get(ctx, "key"); value != "1" {
+		t.Fatalf("b reads %q, want 1", value)
+	}
+
+	// b serves its local copy until localTTL passes.
+	a.Set(ctx, "key", "2", 0)
+	if value, _ := b.Get(ctx, "key"); value != "1" {
+		t.Errorf("b reads %q, want its local copy 1", value)
+	}
+}
+
+func TestSkipLocal(t *testing.T) {
+	ctx := context.Background()
+	a, b := newNodes()
+	for _, node := range []*Tiered{a, b} {
+		node.SkipLocal(func(key string) bool { return strings.HasSuffix(key, ":generation") })
+	}
+
+	a.Set(ctx, "user:generation", "1", 0)
+	if value, _ := b.Get(ctx, "user:generation"); value != "1" {
+		t.Fatalf("b reads %q, want 1", value)
+	}
+
+	a.Set(ctx, "user:generation", "2", 0)
+	if value, _ := b.Get(ctx, "user:generation"); value != "2" {
+		t.Errorf("b reads %q, want the change made by a", value)
+	}
+	if _, err := b.local.Get(ctx, "user:generation"); err == nil {
+		t.Error("skipped key was kept in the local tier")
+	}
+
+	a.Del(ctx, "user:generation")
+	if _, err := b.Get(ctx, "user:generation"); err == nil {
+		t.Error("b reads a key deleted by a")
+	}
+}
@@ -1,43 +1,116 @@
 package apiserver
 
 import (
+	"TaskManager/internal/broker/local"
+	"TaskManager/internal/metrics"
 	"TaskManager/internal/models"
+	"TaskManager/internal/password"
+	"TaskManager/internal/scheduler"
+	"TaskManager/internal/tracing"
+	"TaskManager/internal/webhook"
+	"context"
+	"database/sql"
 	"errors"
+	"fmt"
 
 	// "TaskManager/internal/storage/postgres"
 	"net/http"
+	"sort"
 	"strconv"
+	"sync"
+	"sync/atomic"
 	"time"
 
-	"github.com/dgrijalva/jwt-go"
 	"github.com/gin-gonic/gin"
 	"github.com/sirupsen/logrus"
 )
 
 type Storage interface {
-	CreateUser(username, password string) (int, error)
-	GetUserByUsername(username string) (*models.User, error)
-	GetUserByUsernameAndPassword(username, password string) (*models.User, error)
-
-	GetTasks(userID int) ([]models.Task, error)
-	CreateTask(userID int, title, description string, scheduledFor time.Time) error
-	GetTaskByID(userID, taskID int) (*models.Task, error)
-	UpdateTask(userID int, task *models.Task) error
-	DeleteTask(userID, taskID int) error
+	Ping(ctx context.Context) error
+
+	CreateUser(ctx context.Context, username, passwordHash string) (int, error)
+	GetUserByUsername(ctx context.Context, username string) (*models.User, error)
+	UpdateUserPassword(ctx context.Context, userID int, passwordHash string) error
+
+	CreateRefreshToken(ctx context.Context, token *models.RefreshToken) error
+	RotateRefreshToken(ctx context.Context, oldHash string, next *models.RefreshToken) error
+	RevokeRefreshToken(ctx context.Context, userID int, tokenHash string) error
+	RevokeAllTokens(ctx context.Context, userID int) error
+	RevokeAccessToken(ctx context.Context, jti string, expiresAt time.Time) error
+	IsAccessTokenRevoked(ctx context.Context, userID int, jti string, issuedAt time.Time) (bool, error)
+
+	GetTasks(ctx context.Context, userID int, query *models.TaskQuery) (*models.TaskPage, error)
+	CreateTask(ctx context.Context, userID int, task *models.Task) error
+	GetTaskByID(ctx context.Context, userID, taskID int) (*models.Task, error)
+	UpdateTask(ctx context.Context, userID int, task *models.Task) error
+	PatchTask(ctx context.Context, userID, taskID int, patch *models.TaskPatch, version int) (*models.Task, error)
+	UpdateTaskStatus(ctx context.Context, userID, taskID int, status models.TaskStatus, version int) error
+	DeleteTask(ctx context.Context, userID, taskID, version int) ([]models.Task, error)
+
+	GetSubtasks(ctx context.Context, userID, taskID int) ([]models.Task, error)
+	AddChecklistItem(ctx context.Context, userID, taskID int, item *models.ChecklistItem) error
+	UpdateChecklistItem(ctx context.Context, userID, taskID, itemID int, patch *models.ChecklistItemPatch) (*models.ChecklistItem, error)
+	DeleteChecklistItem(ctx context.Context, userID, taskID, itemID int) error
+	ReorderChecklist(ctx context.Context, userID, taskID int, itemIDs []int) error
+
+	AddDependency(ctx context.Context, userID, taskID, dependsOnID int) error
+	RemoveDependency(ctx context.Context, userID, taskID, dependsOnID int) error
+
+	MaterializeOccurrences(ctx context.Context, userID, seriesID int, until time.Time) ([]models.Task, error)
+	MaterializeDueOccurrences(ctx context.Context, until time.Time) ([]models.Task, error)
+	UpdateFollowingOccurrences(ctx context.Context, userID, taskID int, patch *models.SeriesPatch) (*models.Task, error)
+
+	FireDueReminders(ctx context.Context, offsets []time.Duration, from, to time.Time) ([]models.Reminder, error)
+
+	GetWebhooks(ctx context.Context, userID int) ([]models.Webhook, error)
+	CreateWebhook(ctx context.Context, userID int, webhook *models.Webhook) error
+	UpdateWebhook(ctx context.Context, userID int, webhook *models.Webhook) error
+	DeleteWebhook(ctx context.Context, userID, webhookID int) error
+	EnqueueEvent(ctx context.Context, userID int, event models.EventType, payload []byte) error
+	ClaimDeliveries(ctx context.Context, now, leaseUntil time.Time, limit int) ([]models.WebhookDelivery, error)
+	CompleteDelivery(ctx context.Context, deliveryID int, at time.Time) error
+	RetryDelivery(ctx context.Context, deliveryID int, next *time.Time, lastError string) error
+	DeleteDeliveries(ctx context.Context, before time.Time) error
+
+	GetTags(ctx context.Context, userID int) ([]models.Tag, error)
+	CreateTag(ctx context.Context, userID int, tag *models.Tag) error
+	RenameTag(ctx context.Context, userID, tagID int, name string) error
+	DeleteTag(ctx context.Context, userID, tagID int) error
+	AttachTag(ctx context.Context, userID, taskID, tagID int) error
+	DetachTag(ctx context.Context, userID, taskID, tagID int) error
 }
 
 type Cache interface {
-	Get(key string) (string, error)
-	Set(key string, value string, expiration time.Duration) error
-	Del(key, value string, expiration time.Duration) error
+	Get(ctx context.Context, key string) (string, error)
+	Set(ctx context.Context, key string, value string, expiration time.Duration) error
+	Del(ctx context.Context, key string) error
+	Ping(ctx context.Context) error
+}
+
+// Broker carries task events to the streams of their user, including the
+// streams served by other instances.
+type Broker interface {
+	Publish(userID int, payload []byte) error
+	Subscribe(userID int) (<-chan []byte, func())
 }
 
 type APIServer struct {
-	config  *Config
-	logger  *logrus.Logger
-	router  *gin.Engine
-	storage Storage
-	cache   Cache
+	config    *Config
+	logger    *logrus.Logger
+	router    *gin.Engine
+	storage   Storage
+	cache     Cache
+	broker    Broker
+	hasher    *password.Hasher
+	scheduler *scheduler.Scheduler
+	webhooks  *webhook.Dispatcher
+	metrics   *metrics.Config
+	tracing   *tracing.Config
+	// closing is closed when the server starts shutting down, which ends
+	// the streams that would otherwise hold the drain up.
+	closing chan struct{}
+	// draining is set once shutdown begins, failing the readiness probe.
+	draining atomic.Bool
 }
 
 func New(config *Config) (*APIServer, error) {
@@ -45,14 +118,32 @@ func New(config *Config) (*APIServer, error) {
 		return nil, errors.New("Config is nil")
 	}
 
+	hasher, err := password.New(&password.Config{
+		Algorithm:     config.PasswordAlgorithm,
+		BcryptCost:    config.BcryptCost,
+		Argon2Time:    config.Argon2Time,
+		Argon2Memory:  config.Argon2Memory,
+		Argon2Threads: config.Argon2Threads,
+	})
+	if err != nil {
+		return nil, err
+	}
+
 	return &APIServer{
-		config: config,
-		logger: logrus.New(),
-		router: gin.Default(),
+		config:  config,
+		logger:  logrus.New(),
+		router:  gin.New(),
+		broker:  local.New(),
+		hasher:  hasher,
+		closing: make(chan struct{}),
 	}, nil
 }
 
-func (s *APIServer) Start() error {
+// Run serves the API until ctx is done. It then stops accepting requests
+// and waits up to ShutdownTimeout for the ones in flight and for the
+// scheduler and webhook dispatcher to finish, so that the storage can be
+// closed once it returns.
+func (s *APIServer) Run(ctx context.Context) error {
 	if err := s.configureLogger(); err != nil {
 		return err
 	}
@@ -65,17 +156,155 @@ func (s *APIServer) Start() error {
 		s.logger.Info(s.config)
 	}
 
-	return s.router.Run(s.config.BindAddr)
+	server := &http.Server{
+		Addr:    s.config.BindAddr,
+		Handler: s.router,
+	}
+	server.RegisterOnShutdown(func() { close(s.closing) })
+
+	workersCtx, stopWorkers := context.WithCancel(ctx)
+	defer stopWorkers()
+
+	var workers sync.WaitGroup
+	if s.scheduler != nil {
+		workers.Add(1)
+		go func() {
+			defer workers.Done()
+			s.scheduler.Run(workersCtx)
+		}()
+	}
+	if s.webhooks != nil {
+		workers.Add(1)
+		go func() {
+			defer workers.Done()
+			s.webhooks.Run(workersCtx)
+		}()
+	}
+
+	served := make(chan error, 1)
+	go func() {
+		served <- server.ListenAndServe()
+	}()
+
+	select {
+	case err := <-served:
+		stopWorkers()
+		workers.Wait()
+		return err
+	case <-ctx.Done():
+	}
+
+	s.logger.Info("Shutting down API server")
+
+	// Keep serving while the load balancer notices the failing readiness
+	// probe and stops sending requests.
+	s.draining.Store(true)
+	time.Sleep(s.config.ShutdownDelay)
+
+	drainCtx, cancel := context.WithTimeout(context.Background(), s.config.ShutdownTimeout)
+	defer cancel()
+
+	err := server.Shutdown(drainCtx)
+	if err != nil {
+		s.logger.Warn("Error draining requests: ", err)
+		server.Close()
+	}
+
+	workersDone := make(chan struct{})
+	go func() {
+		workers.Wait()
+		close(workersDone)
+	}()
+	select {
+	case <-workersDone:
+	case <-drainCtx.Done():
+		s.logger.Warn("Background workers did not stop in time")
+	}
+
+	s.logger.Info("API server stopped")
+	return err
 }
 
 func (s *APIServer) UseDB(storage Storage) error {
-	s.storage = storage
+	if s.metrics != nil {
+		// Only the SQL backends have a connection pool.
+		if db, ok := storage.(interface{ DB() *sql.DB }); ok {
+			if err := metrics.RegisterDB("taskmanager", db.DB()); err != nil {
+				return err
+			}
+		}
+	}
+	s.storage = &instrumentedStorage{Storage: storage, logger: s.logger}
 
 	return nil
 }
 
 func (s *APIServer) UseCache(cache Cache) error {
-	s.cache = cache
+	// A node must not keep serving responses of a generation another node
+	// has invalidated.
+	if tiered, ok := cache.(interface{ SkipLocal(func(key string) bool) }); ok {
+		tiered.SkipLocal(isTaskCacheGenerationKey)
+	}
+	s.cache = &instrumentedCache{cache}
+
+	return nil
+}
+
+// UseMetrics records metrics of the requests, storage and cache, and
+// serves them at the configured path. It must be called before UseDB and
+// UseCache.
+func (s *APIServer) UseMetrics(config *metrics.Config) error {
+	if config == nil {
+		return errors.New("Config is nil")
+	}
+	s.metrics = config
+
+	return nil
+}
+
+// UseTracing traces the requests and the storage and cache calls they
+// make. The tracer provider is set up with tracing.Setup.
+func (s *APIServer) UseTracing(config *tracing.Config) error {
+	if config == nil {
+		return errors.New("Config is nil")
+	}
+	s.tracing = config
+
+	return nil
+}
+
+// UseBroker replaces the in-process broker, which only reaches the streams
+// of this instance.
+func (s *APIServer) UseBroker(broker Broker) error {
+	s.broker = broker
+
+	return nil
+}
+
+// UseScheduler sets up the scheduler firing reminders and creating the
+// occurrences of recurring tasks, which is started together with the
+// server. It must be called after UseDB.
+func (s *APIServer) UseScheduler(config *scheduler.Config) error {
+	sched, err := scheduler.New(config, s.storage, s.logger)
+	if err != nil {
+		return err
+	}
+	sched.Handle(s.publishReminder)
+	sched.Materialize(s.config.RecurrenceHorizon, s.publishOccurrence)
+	s.scheduler = sched
+
+	return nil
+}
+
+// UseWebhooks enables publishing task events to webhooks and sets up the
+// dispatcher delivering them, which is started together with the server.
+// It must be called after UseDB.
+func (s *APIServer) UseWebhooks(config *webhook.Config) error {
+	dispatcher, err := webhook.New(config, s.storage, s.logger)
+	if err != nil {
+		return err
+	}
+	s.webhooks = dispatcher
 
 	return nil
 }
@@ -88,40 +317,106 @@ func (s *APIServer) configureLogger() error {
 	}
 
 	s.logger.SetLevel(level)
-	s.logger.SetFormatter(&logrus.TextFormatter{
-		ForceColors:     true,
-		TimestampFormat: "02.01.2006 15:04:05",
-		FullTimestamp:   true,
-	})
+
+	switch s.config.LogFormat {
+	case "", "text":
+		s.logger.SetFormatter(&logrus.TextFormatter{
+			ForceColors:     true,
+			TimestampFormat: "02.01.2006 15:04:05",
+			FullTimestamp:   true,
+		})
+	case "json":
+		s.logger.SetFormatter(&logrus.JSONFormatter{})
+	default:
+		return fmt.Errorf("unknown log format %q", s.config.LogFormat)
+	}
 
 	return nil
 }
 
 func (s *APIServer) configureRouter() {
-	// s.router.Use(s.LoggerMiddleware())
+	s.router.Use(s.RequestIDMiddleware(), s.LoggerMiddleware(), gin.Recovery())
 
 	s.router.LoadHTMLGlob("static/*")
 
+	if s.tracing != nil {
+		s.router.Use(tracing.Middleware())
+	}
+	if s.metrics != nil {
+		s.router.Use(metrics.Middleware())
+		s.router.GET(s.metrics.Path, gin.WrapH(metrics.Handler()))
+	}
+
+	s.router.GET("/healthz", s.handleHealth)
+	s.router.GET("/readyz", s.handleReady)
+
 	publicGroup := s.router.Group("/")
 	{
 		publicGroup.GET("/", s.handleIndex)
 		publicGroup.GET("/index", s.handleIndex)
 		publicGroup.POST("/login", s.handleLogin)
 		publicGroup.POST("/register", s.handleRegister)
+		publicGroup.POST("/token/refresh", s.handleRefreshToken)
+	}
+
+	sessionGroup := s.router.Group("/")
+	sessionGroup.Use(s.AuthMiddleware())
+	{
+		sessionGroup.POST("/logout", s.handleLogout)
+		sessionGroup.POST("/logout-all", s.handleLogoutAll)
 	}
 
 	privateGroup := s.router.Group("/tasks")
 	privateGroup.Use(s.AuthMiddleware())
+	if s.config.Caching && s.cache != nil {
+		privateGroup.Use(s.CacheMiddleware())
+	}
 	{
 		privateGroup.GET("", s.handleGetTasks)
 		privateGroup.POST("", s.handleCreateTask)
+		privateGroup.GET("/order", s.handleGetTaskOrder)
 		privateGroup.GET("/:id", s.handleGetTask)
 		privateGroup.PUT("/:id", s.handleUpdateTask)
+		privateGroup.PATCH("/:id", s.handlePatchTask)
 		privateGroup.DELETE("/:id", s.handleDeleteTask)
+		privateGroup.POST("/:id/transition", s.handleTransitionTask)
+		privateGroup.PUT("/:id/tags/:tagID", s.handleAttachTag)
+		privateGroup.DELETE("/:id/tags/:tagID", s.handleDetachTag)
+		privateGroup.PUT("/:id/dependencies/:dependsOnID", s.handleAddDependency)
+		privateGroup.DELETE("/:id/dependencies/:dependsOnID", s.handleRemoveDependency)
+		privateGroup.PATCH("/:id/occurrence", s.handlePatchOccurrence)
+		privateGroup.PATCH("/:id/following", s.handlePatchFollowing)
+		privateGroup.GET("/:id/children", s.handleGetSubtasks)
+		privateGroup.POST("/:id/checklist", s.handleAddChecklistItem)
+		privateGroup.PUT("/:id/checklist/order", s.handleReorderChecklist)
+		privateGroup.PATCH("/:id/checklist/:itemID", s.handleUpdateChecklistItem)
+		privateGroup.DELETE("/:id/checklist/:itemID", s.handleDeleteChecklistItem)
 	}
 
-	if s.config.Caching {
-		privateGroup.Use(s.CacheMiddleware())
+	// Streams are not cached and also accept the token in the query.
+	streamGroup := s.router.Group("/tasks")
+	streamGroup.Use(s.StreamAuthMiddleware())
+	{
+		streamGroup.GET("/stream", s.handleTaskStream)
+		streamGroup.GET("/ws", s.handleTaskWebSocket)
+	}
+
+	tagGroup := s.router.Group("/tags")
+	tagGroup.Use(s.AuthMiddleware())
+	{
+		tagGroup.GET("", s.handleGetTags)
+		tagGroup.POST("", s.handleCreateTag)
+		tagGroup.PUT("/:id", s.handleRenameTag)
+		tagGroup.DELETE("/:id", s.handleDeleteTag)
+	}
+
+	webhookGroup := s.router.Group("/webhooks")
+	webhookGroup.Use(s.AuthMiddleware())
+	{
+		webhookGroup.GET("", s.handleGetWebhooks)
+		webhookGroup.POST("", s.handleCreateWebhook)
+		webhookGroup.PUT("/:id", s.handleUpdateWebhook)
+		webhookGroup.DELETE("/:id", s.handleDeleteWebhook)
 	}
 }
 
@@ -148,28 +443,37 @@ func (s *APIServer) handleLogin(ctx *gin.Context) {
 		return
 	}
 
-	user, err := s.storage.GetUserByUsernameAndPassword(loginData.Username, loginData.Password)
+	user, err := s.storage.GetUserByUsername(ctx.Request.Context(), loginData.Username)
 	if err != nil || user == nil {
 		ctx.JSON(http.StatusUnauthorized, ErrorResponse{"Invalid username or password"})
 		return
 	}
 
-	token := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{
-		"sub": user.ID,
-		"exp": time.Now().Add(time.Hour * 24).Unix(),
-	})
+	ok, needsRehash, err := s.hasher.Verify(user.Password, loginData.Password)
+	if err != nil {
+		s.log(ctx).Error("Error verifying password: ", err)
+	}
+	if !ok {
+		ctx.JSON(http.StatusUnauthorized, ErrorResponse{"Invalid username or password"})
+		return
+	}
+
+	if needsRehash {
+		s.rehashPassword(ctx.Request.Context(), user.ID, loginData.Password)
+	}
 
-	tokenString, err := token.SignedString([]byte(s.config.JWTSecret))
+	tokens, err := s.issueTokens(ctx.Request.Context(), user.ID)
 	if err != nil {
+		s.log(ctx).Error("Error issuing tokens: ", err)
 		ctx.JSON(http.StatusInternalServerError, ErrorResponse{"Failed to generate token"})
 		return
 	}
 
-	ctx.JSON(http.StatusOK, TokenResponse{tokenString})
+	ctx.JSON(http.StatusOK, tokens)
 }
 
 // @Summary Handling user registration
-// @Description Handling user registration using given username and password
+// @Description Handling user registration using given username and password. Passwords are at most 72 bytes.
 // @Accept json
 // @Produce json
 // @Success 200 {object} TokenResponse "Successful response with an access token"
@@ -185,53 +489,108 @@ func (s *APIServer) handleRegister(ctx *gin.Context) {
 		ctx.JSON(http.StatusBadRequest, ErrorResponse{"Invalid registration data"})
 		return
 	}
+	if len(registrationData.Password) > password.MaxLength {
+		ctx.JSON(http.StatusBadRequest, ErrorResponse{password.ErrTooLong.Error()})
+		return
+	}
 
-	existingUser, err := s.storage.GetUserByUsername(registrationData.Username)
+	existingUser, err := s.storage.GetUserByUsername(ctx.Request.Context(), registrationData.Username)
 	if err == nil || existingUser != nil {
 		ctx.JSON(http.StatusConflict, ErrorResponse{"Username already exists"})
-		s.logger.Info(err)
+		s.log(ctx).Info(err)
+		return
+	}
+	if !errors.Is(err, models.ErrUserNotFound) {
+		s.log(ctx).Error("Error fetching user: ", err)
+		ctx.JSON(http.StatusInternalServerError, ErrorResponse{"Failed to create user"})
 		return
 	}
 
-	userID, err := s.storage.CreateUser(registrationData.Username, registrationData.Password)
+	passwordHash, err := s.hasher.Hash(registrationData.Password)
 	if err != nil {
+		s.log(ctx).Error("Error hashing password: ", err)
 		ctx.JSON(http.StatusInternalServerError, ErrorResponse{"Failed to create user"})
-		s.logger.Info(err)
 		return
 	}
 
-	token := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{
-		"sub": userID,
-		"exp": time.Now().Add(time.Hour * 24).Unix(),
-	})
+	userID, err := s.storage.CreateUser(ctx.Request.Context(), registrationData.Username, passwordHash)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, ErrorResponse{"Failed to create user"})
+		s.log(ctx).Info(err)
+		return
+	}
 
-	tokenString, err := token.SignedString([]byte(s.config.JWTSecret))
+	tokens, err := s.issueTokens(ctx.Request.Context(), userID)
 	if err != nil {
+		s.log(ctx).Error("Error issuing tokens: ", err)
 		ctx.JSON(http.StatusInternalServerError, ErrorResponse{"Failed to generate token"})
 		return
 	}
 
-	ctx.JSON(http.StatusOK, TokenResponse{tokenString})
+	ctx.JSON(http.StatusOK, tokens)
+}
+
+// rehashPassword replaces a legacy or outdated password hash after a
+// successful login. Failures are logged and do not fail the login.
+func (s *APIServer) rehashPassword(ctx context.Context, userID int, plain string) {
+	passwordHash, err := s.hasher.Hash(plain)
+	if err != nil {
+		s.log(ctx).Warn("Error rehashing password: ", err)
+		return
+	}
+
+	if err := s.storage.UpdateUserPassword(ctx, userID, passwordHash); err != nil {
+		s.log(ctx).Warn("Error storing rehashed password: ", err)
+		return
+	}
+
+	s.log(ctx).Infof("Upgraded password hash of user %d", userID)
 }
 
 // @Summary Handling fetching tasks
-// @Description Handling the request to fetch tasks for the authenticated user
+// @Description Handling the request to fetch a page of tasks for the authenticated user
 // @Produce json
-// @Param userID path int true "User ID"
-// @Success 200 {array} models.Task "List of tasks"
+// @Param limit query int false "Page size, 1 to 200" default(50)
+// @Param cursor query string false "Cursor returned as next_cursor by the previous page"
+// @Param scheduled_from query string false "Only tasks scheduled at or after this RFC3339 time"
+// @Param scheduled_to query string false "Only tasks scheduled before this RFC3339 time"
+// @Param created_from query string false "Only tasks created at or after this RFC3339 time"
+// @Param created_to query string false "Only tasks created before this RFC3339 time"
+// @Param title query string false "Case-insensitive title substring"
+// @Param status query []string false "Statuses to include" collectionFormat(multi)
+// @Param tag query []string false "Tag names to filter by" collectionFormat(multi)
+// @Param tag_mode query string false "Whether tasks need all of the tags or any of them: all or any" default(all)
+// @Param sort query string false "Sort field: id, created_at, scheduled_for, title or priority (then due date, undated last in either order)" default(id)
+// @Param order query string false "Sort order: asc or desc" default(asc)
+// @Success 200 {object} models.TaskPage "Page of tasks"
 // @Failure 400,401,500 {object} ErrorResponse "Error response with details"
 // @Router /tasks [get]
 func (s *APIServer) handleGetTasks(ctx *gin.Context) {
-	userID, _ := ctx.Get("userID")
+	userID, ok := userIDFromContext(ctx)
+	if !ok {
+		ctx.JSON(http.StatusBadRequest, ErrorResponse{"Wrong user id"})
+		return
+	}
 
-	tasks, err := s.storage.GetTasks(int(userID.(float64)))
+	query, err := parseTaskQuery(ctx)
 	if err != nil {
-		s.logger.Error("Error fetching tasks: ", err)
+		ctx.JSON(http.StatusBadRequest, ErrorResponse{err.Error()})
+		return
+	}
+
+	page, err := s.storage.GetTasks(ctx.Request.Context(), userID, query)
+	if errors.Is(err, models.ErrInvalidCursor) {
+		ctx.JSON(http.StatusBadRequest, ErrorResponse{"Invalid cursor"})
+		return
+	}
+	if err != nil {
+		s.log(ctx).Error("Error fetching tasks: ", err)
 		ctx.JSON(http.StatusInternalServerError, ErrorResponse{"Failed to fetch tasks"})
 		return
 	}
 
-	ctx.JSON(http.StatusOK, tasks)
+	expireCacheAtDue(ctx, page.Tasks)
+	ctx.JSON(http.StatusOK, page)
 }
 
 // @Summary Handling task creation
@@ -243,22 +602,45 @@ func (s *APIServer) handleGetTasks(ctx *gin.Context) {
 // @Failure 400,401,500 {object} ErrorResponse "Error response with details"
 // @Router /tasks [post]
 func (s *APIServer) handleCreateTask(ctx *gin.Context) {
-	var task models.Task
+	task := models.Task{Priority: models.DefaultPriority}
 	if err := ctx.ShouldBindJSON(&task); err != nil {
 		ctx.JSON(http.StatusBadRequest, ErrorResponse{"Invalid task data"})
 		return
 	}
 
-	userID, ok := ctx.Get("userID")
+	if task.Status != "" && !task.Status.Valid() {
+		ctx.JSON(http.StatusBadRequest, ErrorResponse{"Invalid task status"})
+		return
+	}
+
+	if task.RRule != "" {
+		if task.ScheduledFor.IsZero() {
+			ctx.JSON(http.StatusBadRequest, ErrorResponse{"Recurring tasks need scheduled_for"})
+			return
+		}
+		rule, err := models.NormalizeRRule(task.RRule)
+		if err != nil {
+			ctx.JSON(http.StatusBadRequest, ErrorResponse{err.Error()})
+			return
+		}
+		task.RRule = rule
+	}
 
+	userID, ok := userIDFromContext(ctx)
 	if !ok {
-		s.logger.Error("No userID")
+		s.log(ctx).Error("No userID")
 		ctx.JSON(http.StatusBadRequest, ErrorResponse{"Wrong user id"})
+		return
 	}
-	err := s.storage.CreateTask(int(userID.(float64)), task.Title, task.Description, task.ScheduledFor)
-	if err != nil {
-		s.logger.Error("Error creating task: ", err)
-		ctx.JSON(http.StatusInternalServerError, ErrorResponse{"Failed to create task"})
+
+	if err := s.storage.CreateTask(ctx.Request.Context(), userID, &task); err != nil {
+		s.respondTaskError(ctx, "Error creating task: ", err, "Failed to create task")
+		return
+	}
+	s.extendSeries(ctx.Request.Context(), userID, &task)
+	s.invalidateTasks(ctx.Request.Context(), userID)
+	if err := s.publishTask(ctx.Request.Context(), userID, models.EventTaskCreated, &task); err != nil {
+		s.respondEventError(ctx, err)
 		return
 	}
 
@@ -266,32 +648,34 @@ func (s *APIServer) handleCreateTask(ctx *gin.Context) {
 }
 
 // @Summary Handling fetching a task
-// @Description Handling the request to fetch a specific task for the authenticated user
+// @Description Handling the request to fetch a specific task for the authenticated user. The response carries an ETag of the task version.
 // @Produce json
-// @Param userID path int true "User ID"
-// @Param taskID path int true "Task ID"
+// @Param id path int true "Task ID"
+// @Param If-None-Match header string false "ETag of a cached copy"
 // @Success 200 {object} models.Task "Fetched task"
-// @Failure 400,401,404 {object} ErrorResponse "Error response with details"
-// @Router /tasks/{userID}/{taskID} [get]
+// @Success 304 "Cached copy is current"
+// @Failure 400,401,404,500 {object} ErrorResponse "Error response with details"
+// @Router /tasks/{id} [get]
 func (s *APIServer) handleGetTask(ctx *gin.Context) {
-	userID, err := strconv.Atoi(ctx.Param("userID"))
-	if err != nil {
-		ctx.JSON(http.StatusBadRequest, ErrorResponse{"Invalid user ID"})
+	userID, taskID, ok := taskParams(ctx)
+	if !ok {
 		return
 	}
-	taskID, err := strconv.Atoi(ctx.Param("taskID"))
+
+	task, err := s.storage.GetTaskByID(ctx.Request.Context(), userID, taskID)
 	if err != nil {
-		ctx.JSON(http.StatusBadRequest, ErrorResponse{"Invalid task ID"})
+		s.respondTaskError(ctx, "Error fetching task: ", err, "Failed to fetch task")
 		return
 	}
 
-	task, err := s.storage.GetTaskByID(userID, taskID)
-	if err != nil {
-		s.logger.Error("Error fetching task: ", err)
-		ctx.JSON(http.StatusNotFound, ErrorResponse{"Task not found"})
+	etag := taskETag(task)
+	ctx.Header("ETag", etag)
+	if etagMatches(ctx.GetHeader("If-None-Match"), etag, true) {
+		ctx.Status(http.StatusNotModified)
 		return
 	}
 
+	expireCacheAtDue(ctx, []models.Task{*task})
 	ctx.JSON(http.StatusOK, task)
 }
 
@@ -299,66 +683,350 @@ func (s *APIServer) handleGetTask(ctx *gin.Context) {
 // @Description Handling the request to update a specific task for the authenticated user
 // @Accept json
 // @Produce json
-// @Param userID path int true "User ID"
-// @Param taskID path int true "Task ID"
+// @Param id path int true "Task ID"
+// @Param If-Match header string false "ETag the update is conditional on"
 // @Param input body models.Task true "Updated task data"
 // @Success 200 {object} StatusResponse "Task updated successfully"
-// @Failure 400,401,500 {object} ErrorResponse "Error response with details"
-// @Router /tasks/{userID}/{taskID} [put]
+// @Failure 400,401,404,409,412,500 {object} ErrorResponse "Error response with details"
+// @Router /tasks/{id} [put]
 func (s *APIServer) handleUpdateTask(ctx *gin.Context) {
-	userID, err := strconv.Atoi(ctx.Param("userID"))
-	if err != nil {
-		ctx.JSON(http.StatusBadRequest, ErrorResponse{"Invalid user ID"})
+	userID, taskID, ok := taskParams(ctx)
+	if !ok {
 		return
 	}
-	taskID, err := strconv.Atoi(ctx.Param("id"))
+
+	current, err := s.storage.GetTaskByID(ctx.Request.Context(), userID, taskID)
 	if err != nil {
-		ctx.JSON(http.StatusBadRequest, ErrorResponse{"Invalid task ID"})
+		s.respondTaskError(ctx, "Error updating task: ", err, "Failed to update task")
 		return
 	}
 
-	var task models.Task
+	// Priority, due date and parent are kept unless the body sets them; a
+	// null due_at or parent_id clears it.
+	task := models.Task{Priority: current.Priority, DueAt: current.DueAt, ParentID: current.ParentID}
 	if err := ctx.ShouldBindJSON(&task); err != nil {
 		ctx.JSON(http.StatusBadRequest, ErrorResponse{"Invalid task data"})
 		return
 	}
+	// Derived fields are not written but go into the ETag.
+	task.Progress, task.Blocked = current.Progress, current.Blocked
+
+	if !checkIfMatch(ctx, current) {
+		return
+	}
+
+	if task.Status == "" {
+		task.Status = current.Status
+	} else if !s.checkTransition(ctx, current.Status, task.Status) {
+		return
+	}
 
 	task.ID = taskID
+	// Only a conditional request has the write checked against the
+	// version it was based on.
+	task.Version = 0
+	if ctx.GetHeader("If-Match") != "" {
+		task.Version = current.Version
+	}
 
-	if err := s.storage.UpdateTask(userID, &task); err != nil {
-		s.logger.Error("Error updating task: ", err)
-		ctx.JSON(http.StatusInternalServerError, ErrorResponse{"Failed to update task"})
+	if err := s.storage.UpdateTask(ctx.Request.Context(), userID, &task); err != nil {
+		s.respondTaskError(ctx, "Error updating task: ", err, "Failed to update task")
+		return
+	}
+	s.extendSeries(ctx.Request.Context(), userID, current)
+	s.invalidateTasks(ctx.Request.Context(), userID)
+	if err := s.publishStoredUpdate(ctx.Request.Context(), userID, taskID, current.Status); err != nil {
+		s.respondEventError(ctx, err)
 		return
 	}
 
+	ctx.Header("ETag", taskETag(&task))
 	ctx.JSON(http.StatusOK, StatusResponse{"Task updated successfully"})
 }
 
+// @Summary Handling partial task update
+// @Description Handling the request to change some fields of a task. Accepts an RFC 7396 merge patch (application/merge-patch+json or application/json) or an RFC 6902 JSON patch (application/json-patch+json) on top-level fields.
+// @Accept json
+// @Produce json
+// @Param id path int true "Task ID"
+// @Param If-Match header string false "ETag the update is conditional on"
+// @Param input body object true "Merge patch object or JSON patch array"
+// @Success 200 {object} models.Task "Updated task"
+// @Failure 400,401,404,409,412,415,500 {object} ErrorResponse "Error response with details"
+// @Router /tasks/{id} [patch]
+func (s *APIServer) handlePatchTask(ctx *gin.Context) {
+	userID, taskID, ok := taskParams(ctx)
+	if !ok {
+		return
+	}
+
+	body, err := ctx.GetRawData()
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, ErrorResponse{"Invalid patch data"})
+		return
+	}
+
+	current, err := s.storage.GetTaskByID(ctx.Request.Context(), userID, taskID)
+	if err != nil {
+		s.respondTaskError(ctx, "Error patching task: ", err, "Failed to update task")
+		return
+	}
+
+	if !checkIfMatch(ctx, current) {
+		return
+	}
+
+	doc, err := taskDocument(current)
+	if err != nil {
+		s.log(ctx).Error("Error patching task: ", err)
+		ctx.JSON(http.StatusInternalServerError, ErrorResponse{"Failed to update task"})
+		return
+	}
+
+	switch ctx.ContentType() {
+	case mergePatchContentType, "application/json":
+		err = applyMergePatch(doc, body)
+	case jsonPatchContentType:
+		err = applyJSONPatch(doc, body)
+	default:
+		ctx.JSON(http.StatusUnsupportedMediaType, ErrorResponse{"Unsupported patch format"})
+		return
+	}
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, ErrorResponse{err.Error()})
+		return
+	}
+
+	patch, err := taskPatch(current, doc)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, ErrorResponse{err.Error()})
+		return
+	}
+
+	if patch.Status != nil && !s.checkTransition(ctx, current.Status, *patch.Status) {
+		return
+	}
+
+	if patch.Empty() {
+		ctx.Header("ETag", taskETag(current))
+		ctx.JSON(http.StatusOK, current)
+		return
+	}
+
+	version := 0
+	if ctx.GetHeader("If-Match") != "" {
+		version = current.Version
+	}
+
+	task, err := s.storage.PatchTask(ctx.Request.Context(), userID, taskID, patch, version)
+	if err != nil {
+		s.respondTaskError(ctx, "Error patching task: ", err, "Failed to update task")
+		return
+	}
+	s.extendSeries(ctx.Request.Context(), userID, task)
+	s.invalidateTasks(ctx.Request.Context(), userID)
+	if err := s.publishUpdate(ctx.Request.Context(), userID, current.Status, task); err != nil {
+		s.respondEventError(ctx, err)
+		return
+	}
+
+	ctx.Header("ETag", taskETag(task))
+	ctx.JSON(http.StatusOK, task)
+}
+
+// @Summary Handling task status transition
+// @Description Handling the request to move a task to another lifecycle status. Illegal moves are rejected, and so is a move racing another change of the task.
+// @Accept json
+// @Produce json
+// @Param id path int true "Task ID"
+// @Param input body TransitionRequest true "Target status"
+// @Param If-Match header string false "ETag the transition is conditional on"
+// @Success 200 {object} models.Task "Transitioned task"
+// @Failure 400,401,404,409,412,500 {object} ErrorResponse "Error response with details"
+// @Router /tasks/{id}/transition [post]
+func (s *APIServer) handleTransitionTask(ctx *gin.Context) {
+	userID, taskID, ok := taskParams(ctx)
+	if !ok {
+		return
+	}
+
+	var req TransitionRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, ErrorResponse{"Invalid transition data"})
+		return
+	}
+
+	task, err := s.storage.GetTaskByID(ctx.Request.Context(), userID, taskID)
+	if err != nil {
+		s.respondTaskError(ctx, "Error fetching task: ", err, "Failed to transition task")
+		return
+	}
+
+	if !checkIfMatch(ctx, task) {
+		return
+	}
+	if !s.checkTransition(ctx, task.Status, req.Status) {
+		return
+	}
+
+	// The transition was checked against the status read, so the write is
+	// always conditional on the version it was read at.
+	err = s.storage.UpdateTaskStatus(ctx.Request.Context(), userID, taskID, req.Status, task.Version)
+	if errors.Is(err, models.ErrVersionConflict) && ctx.GetHeader("If-Match") == "" {
+		ctx.JSON(http.StatusConflict, ErrorResponse{"Task was modified concurrently"})
+		return
+	}
+	if err != nil {
+		s.respondTaskError(ctx, "Error transitioning task: ", err, "Failed to transition task")
+		return
+	}
+	s.extendSeries(ctx.Request.Context(), userID, task)
+	s.invalidateTasks(ctx.Request.Context(), userID)
+
+	previous := task.Status
+	task.Status = req.Status
+	task.Version++
+	if err := s.publishUpdate(ctx.Request.Context(), userID, previous, task); err != nil {
+		s.respondEventError(ctx, err)
+		return
+	}
+	ctx.Header("ETag", taskETag(task))
+	ctx.JSON(http.StatusOK, task)
+}
+
 // @Summary Handling deleting a task
-// @Description Handling the request to delete a specific task for the authenticated user
+// @Description Handling the request to delete a specific task for the authenticated user, together with its subtasks
 // @Produce json
-// @Param userID path int true "User ID"
-// @Param taskID path int true "Task ID"
-// @Success 200 {object} ErrorResponse "Task deleted successfully"
-// @Failure 400,401,500 {object} ErrorResponse "Error response with details"
-// @Router /tasks/{userID}/{taskID} [delete]
+// @Param id path int true "Task ID"
+// @Param If-Match header string false "ETag the delete is conditional on"
+// @Success 200 {object} StatusResponse "Task deleted successfully"
+// @Failure 400,401,404,412,500 {object} ErrorResponse "Error response with details"
+// @Router /tasks/{id} [delete]
 func (s *APIServer) handleDeleteTask(ctx *gin.Context) {
-	userID, err := strconv.Atoi(ctx.Param("userID"))
+	userID, taskID, ok := taskParams(ctx)
+	if !ok {
+		return
+	}
+
+	// The deleted task goes into the published event.
+	current, err := s.storage.GetTaskByID(ctx.Request.Context(), userID, taskID)
 	if err != nil {
-		ctx.JSON(http.StatusBadRequest, ErrorResponse{"Invalid user ID"})
+		s.respondTaskError(ctx, "Error deleting task: ", err, "Failed to delete task")
 		return
 	}
-	taskID, err := strconv.Atoi(ctx.Param("id"))
+
+	version := 0
+	if ctx.GetHeader("If-Match") != "" {
+		if !checkIfMatch(ctx, current) {
+			return
+		}
+		version = current.Version
+	}
+
+	deleted, err := s.storage.DeleteTask(ctx.Request.Context(), userID, taskID, version)
 	if err != nil {
-		ctx.JSON(http.StatusBadRequest, ErrorResponse{"Invalid task ID"})
+		s.respondTaskError(ctx, "Error deleting task: ", err, "Failed to delete task")
 		return
 	}
+	s.invalidateTasks(ctx.Request.Context(), userID)
 
-	if err := s.storage.DeleteTask(userID, taskID); err != nil {
-		s.logger.Error("Error deleting task: ", err)
-		ctx.JSON(http.StatusInternalServerError, ErrorResponse{"Failed to delete task"})
+	// The subtasks are deleted with the task and published after it.
+	if err := s.publishTask(ctx.Request.Context(), userID, models.EventTaskDeleted, current); err != nil {
+		s.respondEventError(ctx, err)
 		return
 	}
+	sort.Slice(deleted, func(i, j int) bool { return deleted[i].ID < deleted[j].ID })
+	for i := range deleted {
+		if deleted[i].ID == taskID {
+			continue
+		}
+		if err := s.publishTask(ctx.Request.Context(), userID, models.EventTaskDeleted, &deleted[i]); err != nil {
+			s.respondEventError(ctx, err)
+			return
+		}
+	}
+
+	ctx.JSON(http.StatusOK, StatusResponse{"Task deleted successfully"})
+}
+
+// checkTransition writes an error response and returns false if a task
+// may not be moved from one status to the other.
+func (s *APIServer) checkTransition(ctx *gin.Context, from, to models.TaskStatus) bool {
+	if !to.Valid() {
+		ctx.JSON(http.StatusBadRequest, ErrorResponse{"Invalid task status"})
+		return false
+	}
+
+	if from != to && !from.CanTransitionTo(to) {
+		ctx.JSON(http.StatusConflict, ErrorResponse{fmt.Sprintf("Cannot transition task from %s to %s", from, to)})
+		return false
+	}
+
+	return true
+}
+
+// respondTaskError maps storage errors to 4xx responses, and anything
+// unexpected to a logged 500.
+func (s *APIServer) respondTaskError(ctx *gin.Context, logMsg string, err error, failMsg string) {
+	switch {
+	case errors.Is(err, models.ErrTaskNotFound):
+		ctx.JSON(http.StatusNotFound, ErrorResponse{"Task not found"})
+		return
+	case errors.Is(err, models.ErrVersionConflict):
+		ctx.JSON(http.StatusPreconditionFailed, ErrorResponse{"Task has been modified"})
+		return
+	case errors.Is(err, models.ErrParentNotFound):
+		ctx.JSON(http.StatusBadRequest, ErrorResponse{"Parent task not found"})
+		return
+	case errors.Is(err, models.ErrTaskCycle), errors.Is(err, models.ErrTaskTooDeep):
+		ctx.JSON(http.StatusConflict, ErrorResponse{err.Error()})
+		return
+	case errors.Is(err, models.ErrChecklistItemNotFound):
+		ctx.JSON(http.StatusNotFound, ErrorResponse{"Checklist item not found"})
+		return
+	case errors.Is(err, models.ErrInvalidChecklistOrder):
+		ctx.JSON(http.StatusBadRequest, ErrorResponse{err.Error()})
+		return
+	case errors.Is(err, models.ErrDependencyNotFound):
+		ctx.JSON(http.StatusNotFound, ErrorResponse{"Dependency task not found"})
+		return
+	case errors.Is(err, models.ErrDependencyCycle):
+		ctx.JSON(http.StatusConflict, ErrorResponse{err.Error()})
+		return
+	case errors.Is(err, models.ErrNotRecurring):
+		ctx.JSON(http.StatusBadRequest, ErrorResponse{"Task is not recurring"})
+		return
+	}
+
+	s.log(ctx).Error(logMsg, err)
+	ctx.JSON(http.StatusInternalServerError, ErrorResponse{failMsg})
+}
+
+// userIDFromContext returns the ID of the user authenticated by AuthMiddleware.
+func userIDFromContext(ctx *gin.Context) (int, bool) {
+	userID, ok := ctx.Get("userID")
+	if !ok {
+		return 0, false
+	}
+
+	id, ok := userID.(float64)
+	return int(id), ok
+}
+
+// taskParams extracts the authenticated user ID and the :id path parameter,
+// writing a 400 response if either is missing.
+func taskParams(ctx *gin.Context) (int, int, bool) {
+	userID, ok := userIDFromContext(ctx)
+	if !ok {
+		ctx.JSON(http.StatusBadRequest, ErrorResponse{"Invalid user ID"})
+		return 0, 0, false
+	}
+
+	taskID, err := strconv.Atoi(ctx.Param("id"))
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, ErrorResponse{"Invalid task ID"})
+		return 0, 0, false
+	}
 
-	ctx.JSON(http.StatusOK, ErrorResponse{"Task deleted successfully"})
+	return userID, taskID, true
 }
@@ -0,0 +1,278 @@
+package apiserver
+
+import (
+	"TaskManager/internal/models"
+	"TaskManager/internal/storage/memory"
+	"bytes"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strconv"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"golang.org/x/crypto/bcrypt"
+)
+
+// TestMain runs the tests from a directory holding the templates the
+// router loads.
+func TestMain(m *testing.M) {
+	gin.SetMode(gin.TestMode)
+
+	dir, err := os.MkdirTemp("", "apiserver")
+	if err != nil {
+		panic(err)
+	}
+	if err := os.Mkdir(filepath.Join(dir, "static"), 0o755); err != nil {
+		panic(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "static", "index.html"), []byte("<html></html>"), 0o644); err != nil {
+		panic(err)
+	}
+	if err := os.Chdir(dir); err != nil {
+		panic(err)
+	}
+
+	code := m.Run()
+	os.RemoveAll(dir)
+	os.Exit(code)
+}
+
+// newTestServer returns a server backed by the in-memory storage. The
+// setup functions run before the router is configured.
+func newTestServer(t *testing.T, setup ...func(s *APIServer)) *APIServer {
+	t.Helper()
+
+	config := NewConfig()
+	config.JWTSecret = "test"
+	config.BcryptCost = bcrypt.MinCost
+
+	s, err := New(config)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := s.UseDB(memory.New()); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.configureLogger(); err != nil {
+		t.Fatal(err)
+	}
+	s.logger.SetOutput(io.Discard)
+	for _, f := range setup {
+		f(s)
+	}
+	s.configureRouter()
+
+	return s
+}
+
+// do sends a request with an optional JSON body and access token.
+func do(t *testing.T, s *APIServer, method, path, token string, body interface{}) *httptest.ResponseRecorder {
+	t.Helper()
+	return doWith(t, s, method, path, token, body, nil)
+}
+
+// doWith sends a request like do, with extra headers.
+func doWith(t *testing.T, s *APIServer, method, path, token string, body interface{}, header http.Header) *httptest.ResponseRecorder {
+	t.Helper()
+
+	var reader io.Reader
+	if body != nil {
+		data, err := json.Marshal(body)
+		if err != nil {
+			t.Fatal(err)
+		}
+		reader = bytes.NewReader(data)
+	}
+
+	req := httptest.NewRequest(method, path, reader)
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+	for key, values := range header {
+		req.Header[key] = values
+	}
+
+	w := httptest.NewRecorder()
+	s.router.ServeHTTP(w, req)
+	return w
+}
+
+// register creates a user and returns its access token.
+func register(t *testing.T, s *APIServer, username string) string {
+	t.Helper()
+
+	w := do(t, s, http.MethodPost, "/register", "", map[string]string{
+		"username": username,
+		"password": "password",
+	})
+	if w.Code != http.StatusOK {
+		t.Fatalf("register %s: status %d: %s", username, w.Code, w.Body)
+	}
+
+	var tokens TokenResponse
+	if err := json.Unmarshal(w.Body.Bytes(), &tokens); err != nil {
+		t.Fatal(err)
+	}
+	return tokens.Token
+}
+
+// createTask creates a task and returns it as stored.
+func createTask(t *testing.T, s *APIServer, token, title string) models.Task {
+	t.Helper()
+
+	w := do(t, s, http.MethodPost, "/tasks", token, map[string]string{"title": title})
+	if w.Code != http.StatusOK {
+		t.Fatalf("create task: status %d: %s", w.Code, w.Body)
+	}
+
+	var task models.Task
+	if err := json.Unmarshal(w.Body.Bytes(), &task); err != nil {
+		t.Fatal(err)
+	}
+	return task
+}
+
+func taskPath(id int) string {
+	return "/tasks/" + strconv.Itoa(id)
+}
+
+func TestTaskRoundTrip(t *testing.T) {
+	s := newTestServer(t)
+	token := register(t, s, "alice")
+
+	created := createTask(t, s, token, "Write tests")
+	if created.ID == 0 || created.Title != "Write tests" || created.Status != models.StatusTodo {
+		t.Fatalf("created task = %+v", created)
+	}
+
+	w := do(t, s, http.MethodGet, taskPath(created.ID), token, nil)
+	if w.Code != http.StatusOK {
+		t.Fatalf("get: status %d: %s", w.Code, w.Body)
+	}
+	var fetched models.Task
+	if err := json.Unmarshal(w.Body.Bytes(), &fetched); err != nil {
+		t.Fatal(err)
+	}
+	if fetched.ID != created.ID || fetched.Title != created.Title {
+		t.Errorf("fetched task = %+v, want %+v", fetched, created)
+	}
+
+	w = do(t, s, http.MethodPut, taskPath(created.ID), token, map[string]string{
+		"title":       "Write more tests",
+		"description": "handlers",
+	})
+	if w.Code != http.StatusOK {
+		t.Fatalf("update: status %d: %s", w.Code, w.Body)
+	}
+
+	w = do(t, s, http.MethodGet, taskPath(created.ID), token, nil)
+	if err := json.Unmarshal(w.Body.Bytes(), &fetched); err != nil {
+		t.Fatal(err)
+	}
+	if fetched.Title != "Write more tests" || fetched.Description != "handlers" {
+		t.Errorf("updated task = %+v", fetched)
+	}
+
+	w = do(t, s, http.MethodGet, "/tasks", token, nil)
+	if w.Code != http.StatusOK {
+		t.Fatalf("list: status %d: %s", w.Code, w.Body)
+	}
+	var page models.TaskPage
+	if err := json.Unmarshal(w.Body.Bytes(), &page); err != nil {
+		t.Fatal(err)
+	}
+	if len(page.Tasks) != 1 || page.Tasks[0].ID != created.ID {
+		t.Errorf("listed tasks = %+v, want the created one", page.Tasks)
+	}
+
+	w = do(t, s, http.MethodDelete, taskPath(created.ID), token, nil)
+	if w.Code != http.StatusOK {
+		t.Fatalf("delete: status %d: %s", w.Code, w.Body)
+	}
+
+	if w = do(t, s, http.MethodGet, taskPath(created.ID), token, nil); w.Code != http.StatusNotFound {
+		t.Errorf("get after delete: status %d, want 404", w.Code)
+	}
+	if w = do(t, s, http.MethodDelete, taskPath(created.ID), token, nil); w.Code != http.StatusNotFound {
+		t.Errorf("delete twice: status %d, want 404", w.Code)
+	}
+}
+
+func TestTaskOwnership(t *testing.T) {
+	s := newTestServer(t)
+	alice := register(t, s, "alice")
+	bob := register(t, s, "bob")
+
+	task := createTask(t, s, alice, "Alice's task")
+
+	tests := []struct {
+		method string
+		body   interface{}
+	}{
+		{http.MethodGet, nil},
+		{http.MethodPut, map[string]string{"title": "Taken over"}},
+		{http.MethodPatch, map[string]string{"title": "Taken over"}},
+		{http.MethodDelete, nil},
+	}
+	for _, tt := range tests {
+		w := do(t, s, tt.method, taskPath(task.ID), bob, tt.body)
+		if w.Code != http.StatusNotFound {
+			t.Errorf("%s of another user's task: status %d, want 404", tt.method, w.Code)
+		}
+	}
+
+	w := do(t, s, http.MethodGet, "/tasks", bob, nil)
+	var page models.TaskPage
+	if err := json.Unmarshal(w.Body.Bytes(), &page); err != nil {
+		t.Fatal(err)
+	}
+	if len(page.Tasks) != 0 {
+		t.Errorf("bob lists %+v, want no tasks", page.Tasks)
+	}
+
+	w = do(t, s, http.MethodGet, taskPath(task.ID), alice, nil)
+	if w.Code != http.StatusOK {
+		t.Fatalf("get own task: status %d", w.Code)
+	}
+	var fetched models.Task
+	if err := json.Unmarshal(w.Body.Bytes(), &fetched); err != nil {
+		t.Fatal(err)
+	}
+	if fetched.Title != "Alice's task" {
+		t.Errorf("task was changed by another user: %+v", fetched)
+	}
+}
+
+func TestInvalidTaskID(t *testing.T) {
+	s := newTestServer(t)
+	token := register(t, s, "alice")
+
+	for _, method := range []string{http.MethodGet, http.MethodPut, http.MethodPatch, http.MethodDelete} {
+		w := do(t, s, method, "/tasks/abc", token, map[string]string{"title": "x"})
+		if w.Code != http.StatusBadRequest {
+			t.Errorf("%s /tasks/abc: status %d, want 400", method, w.Code)
+		}
+	}
+
+	if w := do(t, s, http.MethodGet, taskPath(999), token, nil); w.Code != http.StatusNotFound {
+		t.Errorf("get missing task: status %d, want 404", w.Code)
+	}
+}
+
+func TestTasksRequireToken(t *testing.T) {
+	s := newTestServer(t)
+
+	if w := do(t, s, http.MethodGet, "/tasks", "", nil); w.Code != http.StatusUnauthorized {
+		t.Errorf("list without token: status %d, want 401", w.Code)
+	}
+	if w := do(t, s, http.MethodGet, "/tasks", "not-a-token", nil); w.Code != http.StatusUnauthorized {
+		t.Errorf("list with bad token: status %d, want 401", w.Code)
+	}
+}
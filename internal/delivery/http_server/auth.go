@@ -0,0 +1,193 @@
+package apiserver
+
+import (
+	"TaskManager/internal/models"
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/dgrijalva/jwt-go"
+	"github.com/gin-gonic/gin"
+)
+
+// issueTokens mints a short-lived access token and stores a new refresh
+// token for the user.
+func (s *APIServer) issueTokens(ctx context.Context, userID int) (*TokenResponse, error) {
+	refreshToken, refreshHash, err := newRefreshToken()
+	if err != nil {
+		return nil, err
+	}
+
+	err = s.storage.CreateRefreshToken(ctx, &models.RefreshToken{
+		UserID:    userID,
+		TokenHash: refreshHash,
+		ExpiresAt: time.Now().Add(s.config.RefreshTokenTTL),
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return s.tokenResponse(userID, refreshToken)
+}
+
+func (s *APIServer) tokenResponse(userID int, refreshToken string) (*TokenResponse, error) {
+	jti, err := randomString(16)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	token := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{
+		"sub": userID,
+		"jti": jti,
+		"iat": now.Unix(),
+		"exp": now.Add(s.config.AccessTokenTTL).Unix(),
+	})
+
+	tokenString, err := token.SignedString([]byte(s.config.JWTSecret))
+	if err != nil {
+		return nil, err
+	}
+
+	return &TokenResponse{
+		Token:        tokenString,
+		RefreshToken: refreshToken,
+		ExpiresIn:    int(s.config.AccessTokenTTL.Seconds()),
+	}, nil
+}
+
+// @Summary Handling access token refresh
+// @Description Exchanges a refresh token for a new access token and a new refresh token. The presented refresh token is revoked.
+// @Accept json
+// @Produce json
+// @Param input body RefreshRequest true "Refresh token"
+// @Success 200 {object} TokenResponse
+// @Failure 400,401,500 {object} ErrorResponse
+// @Router /token/refresh [post]
+func (s *APIServer) handleRefreshToken(ctx *gin.Context) {
+	var req RefreshRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil || req.RefreshToken == "" {
+		ctx.JSON(http.StatusBadRequest, ErrorResponse{"Invalid refresh data"})
+		return
+	}
+
+	refreshToken, refreshHash, err := newRefreshToken()
+	if err != nil {
+		s.log(ctx).Error("Error generating refresh token: ", err)
+		ctx.JSON(http.StatusInternalServerError, ErrorResponse{"Failed to generate token"})
+		return
+	}
+
+	next := &models.RefreshToken{
+		TokenHash: refreshHash,
+		ExpiresAt: time.Now().Add(s.config.RefreshTokenTTL),
+	}
+
+	err = s.storage.RotateRefreshToken(ctx.Request.Context(), hashToken(req.RefreshToken), next)
+	if errors.Is(err, models.ErrRefreshTokenReused) {
+		s.log(ctx).Warn("Refresh token reuse detected, all sessions revoked")
+	}
+	if errors.Is(err, models.ErrRefreshTokenInvalid) || errors.Is(err, models.ErrRefreshTokenReused) {
+		ctx.JSON(http.StatusUnauthorized, ErrorResponse{"Invalid or expired refresh token"})
+		return
+	}
+	if err != nil {
+		s.log(ctx).Error("Error rotating refresh token: ", err)
+		ctx.JSON(http.StatusInternalServerError, ErrorResponse{"Failed to refresh token"})
+		return
+	}
+
+	tokens, err := s.tokenResponse(next.UserID, refreshToken)
+	if err != nil {
+		s.log(ctx).Error("Error issuing tokens: ", err)
+		ctx.JSON(http.StatusInternalServerError, ErrorResponse{"Failed to generate token"})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, tokens)
+}
+
+// @Summary Handling logout
+// @Description Revokes the access token used for the request and, if given, the refresh token of the session.
+// @Accept json
+// @Produce json
+// @Param input body RefreshRequest false "Refresh token of the session"
+// @Success 200 {object} StatusResponse
+// @Failure 401,500 {object} ErrorResponse
+// @Router /logout [post]
+func (s *APIServer) handleLogout(ctx *gin.Context) {
+	userID, ok := userIDFromContext(ctx)
+	if !ok {
+		ctx.JSON(http.StatusBadRequest, ErrorResponse{"Wrong user id"})
+		return
+	}
+
+	if err := s.storage.RevokeAccessToken(ctx.Request.Context(), ctx.GetString("tokenID"), ctx.GetTime("tokenExpiresAt")); err != nil {
+		s.log(ctx).Error("Error revoking access token: ", err)
+		ctx.JSON(http.StatusInternalServerError, ErrorResponse{"Failed to log out"})
+		return
+	}
+
+	var req RefreshRequest
+	if err := ctx.ShouldBindJSON(&req); err == nil && req.RefreshToken != "" {
+		if err := s.storage.RevokeRefreshToken(ctx.Request.Context(), userID, hashToken(req.RefreshToken)); err != nil {
+			s.log(ctx).Error("Error revoking refresh token: ", err)
+			ctx.JSON(http.StatusInternalServerError, ErrorResponse{"Failed to log out"})
+			return
+		}
+	}
+
+	ctx.JSON(http.StatusOK, StatusResponse{"Logged out"})
+}
+
+// @Summary Handling logout from every session
+// @Description Revokes all refresh tokens of the authenticated user and every access token issued to them so far.
+// @Produce json
+// @Success 200 {object} StatusResponse
+// @Failure 401,500 {object} ErrorResponse
+// @Router /logout-all [post]
+func (s *APIServer) handleLogoutAll(ctx *gin.Context) {
+	userID, ok := userIDFromContext(ctx)
+	if !ok {
+		ctx.JSON(http.StatusBadRequest, ErrorResponse{"Wrong user id"})
+		return
+	}
+
+	if err := s.storage.RevokeAllTokens(ctx.Request.Context(), userID); err != nil {
+		s.log(ctx).Error("Error revoking tokens: ", err)
+		ctx.JSON(http.StatusInternalServerError, ErrorResponse{"Failed to log out"})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, StatusResponse{"Logged out from all sessions"})
+}
+
+// newRefreshToken returns a random refresh token and the hash under which
+// it is stored.
+func newRefreshToken() (string, string, error) {
+	token, err := randomString(32)
+	if err != nil {
+		return "", "", err
+	}
+
+	return token, hashToken(token), nil
+}
+
+func hashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
+func randomString(n int) (string, error) {
+	b := make([]byte, n)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
@@ -0,0 +1,172 @@
+package apiserver
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+)
+
+// login returns the status of a login with the given credentials.
+func login(t *testing.T, s *APIServer, username, password string) int {
+	t.Helper()
+
+	w := do(t, s, http.MethodPost, "/login", "", map[string]string{
+		"username": username,
+		"password": password,
+	})
+	return w.Code
+}
+
+func TestLoginRehashesLegacyPassword(t *testing.T) {
+	s := newTestServer(t)
+	ctx := context.Background()
+
+	// Rows written before passwords were hashed hold the plaintext.
+	if _, err := s.storage.CreateUser(ctx, "legacy", "password"); err != nil {
+		t.Fatal(err)
+	}
+
+	if code := login(t, s, "legacy", "wrong"); code != http.StatusUnauthorized {
+		t.Errorf("login with wrong password: status %d, want 401", code)
+	}
+	user, err := s.storage.GetUserByUsername(ctx, "legacy")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if user.Password != "password" {
+		t.Errorf("failed login replaced the stored password with %q", user.Password)
+	}
+
+	if code := login(t, s, "legacy", "password"); code != http.StatusOK {
+		t.Fatalf("login with legacy password: status %d, want 200", code)
+	}
+	if user, err = s.storage.GetUserByUsername(ctx, "legacy"); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasPrefix(user.Password, "$2") {
+		t.Errorf("stored password after login = %q, want a bcrypt hash", user.Password)
+	}
+
+	if code := login(t, s, "legacy", "password"); code != http.StatusOK {
+		t.Errorf("login after rehash: status %d, want 200", code)
+	}
+	if code := login(t, s, "legacy", user.Password); code != http.StatusUnauthorized {
+		t.Errorf("login with the hash itself: status %d, want 401", code)
+	}
+}
+
+// refresh exchanges a refresh token and returns the response status and,
+// on success, the new tokens.
+func refresh(t *testing.T, s *APIServer, refreshToken string) (int, TokenResponse) {
+	t.Helper()
+
+	var tokens TokenResponse
+	w := do(t, s, http.MethodPost, "/token/refresh", "", map[string]string{"refresh_token": refreshToken})
+	if w.Code == http.StatusOK {
+		if err := json.Unmarshal(w.Body.Bytes(), &tokens); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return w.Code, tokens
+}
+
+// loginTokens logs in and returns the issued tokens.
+func loginTokens(t *testing.T, s *APIServer, username string) TokenResponse {
+	t.Helper()
+
+	var tokens TokenResponse
+	mustDo(t, s, http.MethodPost, "/login", "", map[string]string{"username": username, "password": "password"}, &tokens)
+	return tokens
+}
+
+func TestRefreshRotation(t *testing.T) {
+	s := newTestServer(t)
+	register(t, s, "alice")
+	first := loginTokens(t, s, "alice")
+
+	code, second := refresh(t, s, first.RefreshToken)
+	if code != http.StatusOK {
+		t.Fatalf("refresh: status %d, want 200", code)
+	}
+	if second.RefreshToken == "" || second.RefreshToken == first.RefreshToken || second.Token == "" {
+		t.Fatalf("refresh returned %+v, want a new token pair", second)
+	}
+	if w := do(t, s, http.MethodGet, "/tasks", second.Token, nil); w.Code != http.StatusOK {
+		t.Errorf("refreshed access token: status %d, want 200", w.Code)
+	}
+
+	code, third := refresh(t, s, second.RefreshToken)
+	if code != http.StatusOK || third.RefreshToken == second.RefreshToken {
+		t.Fatalf("second refresh: status %d, tokens %+v", code, third)
+	}
+
+	for _, token := range []string{"not-a-token", strings.Repeat("x", 43)} {
+		if code, _ := refresh(t, s, token); code != http.StatusUnauthorized {
+			t.Errorf("refresh with unknown token: status %d, want 401", code)
+		}
+	}
+	if code, _ := refresh(t, s, ""); code != http.StatusBadRequest {
+		t.Errorf("refresh without token: status %d, want 400", code)
+	}
+
+	// An unknown token does not end the sessions.
+	if code, _ := refresh(t, s, third.RefreshToken); code != http.StatusOK {
+		t.Errorf("refresh of the latest token: status %d, want 200", code)
+	}
+}
+
+func TestRefreshReuseRevokesSessions(t *testing.T) {
+	s := newTestServer(t)
+	register(t, s, "alice")
+	stolen := loginTokens(t, s, "alice")
+	other := loginTokens(t, s, "alice")
+	bob := register(t, s, "bob")
+
+	code, rotated := refresh(t, s, stolen.RefreshToken)
+	if code != http.StatusOK {
+		t.Fatalf("refresh: status %d, want 200", code)
+	}
+
+	// Presenting the rotated token again means it leaked.
+	if code, _ := refresh(t, s, stolen.RefreshToken); code != http.StatusUnauthorized {
+		t.Errorf("reused refresh token: status %d, want 401", code)
+	}
+
+	for name, token := range map[string]string{"rotated": rotated.RefreshToken, "other session": other.RefreshToken} {
+		if code, _ := refresh(t, s, token); code != http.StatusUnauthorized {
+			t.Errorf("%s refresh token after reuse: status %d, want 401", name, code)
+		}
+	}
+	for name, token := range map[string]string{"rotated": rotated.Token, "other session": other.Token} {
+		if w := do(t, s, http.MethodGet, "/tasks", token, nil); w.Code != http.StatusUnauthorized {
+			t.Errorf("%s access token after reuse: status %d, want 401", name, w.Code)
+		}
+	}
+
+	if w := do(t, s, http.MethodGet, "/tasks", bob, nil); w.Code != http.StatusOK {
+		t.Errorf("another user's access token after reuse: status %d, want 200", w.Code)
+	}
+
+	// Logging in again starts a new session. Access tokens are revoked by
+	// the second, so the new one must come from a later second.
+	time.Sleep(time.Until(time.Now().Truncate(time.Second).Add(time.Second)))
+	fresh := loginTokens(t, s, "alice")
+	if code, _ := refresh(t, s, fresh.RefreshToken); code != http.StatusOK {
+		t.Errorf("refresh after logging in again: status %d, want 200", code)
+	}
+}
+
+func TestRefreshTokenExpires(t *testing.T) {
+	s := newTestServer(t, func(s *APIServer) {
+		s.config.RefreshTokenTTL = -time.Second
+	})
+	register(t, s, "alice")
+	tokens := loginTokens(t, s, "alice")
+
+	if code, _ := refresh(t, s, tokens.RefreshToken); code != http.StatusUnauthorized {
+		t.Errorf("expired refresh token: status %d, want 401", code)
+	}
+}
@@ -1,15 +1,63 @@
 package apiserver
 
+import (
+	"TaskManager/internal/password"
+	"time"
+)
+
 type Config struct {
 	BindAddr  string `toml:"bind_addr"`
 	LogLevel  string `toml:"log_level"`
+	LogFormat string `toml:"log_format"` // text or json
 	JWTSecret string `toml:"jwt_secret"`
 	Caching   bool   `toml:"caching_responses"`
+	// ConnectTimeout bounds how long startup retries reaching the
+	// database and Redis.
+	ConnectTimeout time.Duration `toml:"connect_timeout"`
+	// ShutdownTimeout bounds how long in-flight requests and background
+	// work are given to finish on shutdown.
+	ShutdownTimeout time.Duration `toml:"shutdown_timeout"`
+	// ShutdownDelay is how long the server keeps accepting requests after
+	// the readiness probe starts failing on shutdown.
+	ShutdownDelay time.Duration `toml:"shutdown_delay"`
+	// ReadinessTimeout bounds each dependency check of the readiness
+	// probe.
+	ReadinessTimeout time.Duration `toml:"readiness_timeout"`
+	// CacheTTL bounds how long a cached response is served.
+	CacheTTL time.Duration `toml:"cache_ttl"`
+	// RecurrenceHorizon is how far ahead occurrences of recurring tasks
+	// are created. With zero, the next occurrence is only created once
+	// the previous ones are finished.
+	RecurrenceHorizon time.Duration `toml:"recurrence_horizon"`
+
+	AccessTokenTTL  time.Duration `toml:"access_token_ttl"`
+	RefreshTokenTTL time.Duration `toml:"refresh_token_ttl"`
+
+	// PasswordAlgorithm is either "bcrypt" or "argon2id".
+	PasswordAlgorithm string `toml:"password_algorithm"`
+	BcryptCost        int    `toml:"bcrypt_cost"`
+	Argon2Time        uint32 `toml:"argon2_time"`
+	Argon2Memory      uint32 `toml:"argon2_memory"` // KiB
+	Argon2Threads     uint8  `toml:"argon2_threads"`
 }
 
 func NewConfig() *Config {
+	passwords := password.NewConfig()
+
 	return &Config{
-		BindAddr: ":8080",
-		LogLevel: "debug",
+		BindAddr:          ":8080",
+		LogLevel:          "debug",
+		LogFormat:         "text",
+		ConnectTimeout:    30 * time.Second,
+		ShutdownTimeout:   15 * time.Second,
+		ReadinessTimeout:  2 * time.Second,
+		CacheTTL:          time.Minute,
+		AccessTokenTTL:    15 * time.Minute,
+		RefreshTokenTTL:   30 * 24 * time.Hour,
+		PasswordAlgorithm: passwords.Algorithm,
+		BcryptCost:        passwords.BcryptCost,
+		Argon2Time:        passwords.Argon2Time,
+		Argon2Memory:      passwords.Argon2Memory,
+		Argon2Threads:     passwords.Argon2Threads,
 	}
 }
@@ -0,0 +1,94 @@
+package apiserver
+
+import (
+	"TaskManager/internal/models"
+	"context"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+// @Summary Handling adding a dependency
+// @Description Handling the request to mark a task as blocked by another task of the same user. Dependencies that would form a cycle are rejected.
+// @Produce json
+// @Param id path int true "Task ID"
+// @Param dependsOnID path int true "ID of the task it depends on"
+// @Success 200 {object} models.Task "Dependent task"
+// @Failure 400,401,404,409,500 {object} ErrorResponse "Error response with details"
+// @Router /tasks/{id}/dependencies/{dependsOnID} [put]
+func (s *APIServer) handleAddDependency(ctx *gin.Context) {
+	s.changeDependency(ctx, s.storage.AddDependency, "Error adding dependency: ", "Failed to add dependency")
+}
+
+// @Summary Handling removing a dependency
+// @Description Handling the request to remove a dependency between two tasks. Removing a missing dependency has no effect.
+// @Produce json
+// @Param id path int true "Task ID"
+// @Param dependsOnID path int true "ID of the task it depends on"
+// @Success 200 {object} models.Task "Dependent task"
+// @Failure 400,401,404,500 {object} ErrorResponse "Error response with details"
+// @Router /tasks/{id}/dependencies/{dependsOnID} [delete]
+func (s *APIServer) handleRemoveDependency(ctx *gin.Context) {
+	s.changeDependency(ctx, s.storage.RemoveDependency, "Error removing dependency: ", "Failed to remove dependency")
+}
+
+// changeDependency runs change for the two tasks of the request and
+// responds with the dependent task.
+func (s *APIServer) changeDependency(ctx *gin.Context, change func(ctx context.Context, userID, taskID, dependsOnID int) error, logMsg, failMsg string) {
+	userID, taskID, ok := taskParams(ctx)
+	if !ok {
+		return
+	}
+
+	dependsOnID, err := strconv.Atoi(ctx.Param("dependsOnID"))
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, ErrorResponse{"Invalid dependency task ID"})
+		return
+	}
+
+	if err := change(ctx.Request.Context(), userID, taskID, dependsOnID); err != nil {
+		s.respondTaskError(ctx, logMsg, err, failMsg)
+		return
+	}
+	s.invalidateTasks(ctx.Request.Context(), userID)
+
+	task, err := s.storage.GetTaskByID(ctx.Request.Context(), userID, taskID)
+	if err != nil {
+		s.respondTaskError(ctx, logMsg, err, failMsg)
+		return
+	}
+	if err := s.publishTask(ctx.Request.Context(), userID, models.EventTaskUpdated, task); err != nil {
+		s.respondEventError(ctx, err)
+		return
+	}
+
+	ctx.Header("ETag", taskETag(task))
+	ctx.JSON(http.StatusOK, task)
+}
+
+// @Summary Handling fetching tasks in dependency order
+// @Description Handling the request to list the unfinished tasks of the authenticated user so that every task comes after the tasks blocking it. Tasks that are not blocked can be worked on now; among those the most urgent come first.
+// @Produce json
+// @Success 200 {array} models.Task "Unfinished tasks in dependency order"
+// @Failure 400,401,500 {object} ErrorResponse "Error response with details"
+// @Router /tasks/order [get]
+func (s *APIServer) handleGetTaskOrder(ctx *gin.Context) {
+	userID, ok := userIDFromContext(ctx)
+	if !ok {
+		ctx.JSON(http.StatusBadRequest, ErrorResponse{"Wrong user id"})
+		return
+	}
+
+	page, err := s.storage.GetTasks(ctx.Request.Context(), userID, &models.TaskQuery{
+		Statuses: []models.TaskStatus{models.StatusTodo, models.StatusInProgress, models.StatusBlocked},
+	})
+	if err != nil {
+		s.log(ctx).Error("Error fetching task order: ", err)
+		ctx.JSON(http.StatusInternalServerError, ErrorResponse{"Failed to fetch tasks"})
+		return
+	}
+
+	expireCacheAtDue(ctx, page.Tasks)
+	ctx.JSON(http.StatusOK, models.OrderTasks(page.Tasks))
+}
@@ -0,0 +1,97 @@
+package apiserver
+
+import (
+	"TaskManager/internal/models"
+	"fmt"
+	"net/http"
+	"testing"
+)
+
+func dependencyPath(taskID, dependsOnID int) string {
+	return fmt.Sprintf("/tasks/%d/dependencies/%d", taskID, dependsOnID)
+}
+
+func TestDependencyCycles(t *testing.T) {
+	t.Run("memory", func(t *testing.T) {
+		testDependencyCycles(t, newTestServer(t))
+	})
+	t.Run("sqlite", func(t *testing.T) {
+		testDependencyCycles(t, newTestServer(t, useSQLite(t)))
+	})
+}
+
+func testDependencyCycles(t *testing.T, s *APIServer) {
+	token := register(t, s, "alice")
+	a := createTask(t, s, token, "A")
+	b := createTask(t, s, token, "B")
+	c := createTask(t, s, token, "C")
+
+	// A is blocked by B, which is blocked by C.
+	var blocked models.Task
+	mustDo(t, s, http.MethodPut, dependencyPath(a.ID, b.ID), token, nil, &blocked)
+	if fmt.Sprint(blocked.BlockedBy) != fmt.Sprint([]int{b.ID}) || !blocked.Blocked {
+		t.Errorf("after adding a dependency: blocked_by %v, blocked %v", blocked.BlockedBy, blocked.Blocked)
+	}
+	mustDo(t, s, http.MethodPut, dependencyPath(b.ID, c.ID), token, nil, nil)
+	// Adding it again changes nothing.
+	mustDo(t, s, http.MethodPut, dependencyPath(a.ID, b.ID), token, nil, nil)
+
+	for _, edge := range [][2]int{{a.ID, a.ID}, {b.ID, a.ID}, {c.ID, a.ID}, {c.ID, b.ID}} {
+		if w := do(t, s, http.MethodPut, dependencyPath(edge[0], edge[1]), token, nil); w.Code != http.StatusConflict {
+			t.Errorf("task %d blocked by %d: status %d, want 409", edge[0], edge[1], w.Code)
+		}
+	}
+	if stored := getTask(t, s, token, c.ID); len(stored.BlockedBy) != 0 {
+		t.Errorf("rejected dependencies stored: blocked_by %v", stored.BlockedBy)
+	}
+
+	// With the chain broken, the former cycle is allowed.
+	mustDo(t, s, http.MethodDelete, dependencyPath(b.ID, c.ID), token, nil, nil)
+	mustDo(t, s, http.MethodPut, dependencyPath(c.ID, a.ID), token, nil, nil)
+
+	bob := register(t, s, "bob")
+	foreign := createTask(t, s, bob, "Bob's")
+	if w := do(t, s, http.MethodPut, dependencyPath(a.ID, foreign.ID), token, nil); w.Code != http.StatusNotFound {
+		t.Errorf("dependency on another user's task: status %d, want 404", w.Code)
+	}
+	if w := do(t, s, http.MethodPut, dependencyPath(a.ID, 999), token, nil); w.Code != http.StatusNotFound {
+		t.Errorf("dependency on a missing task: status %d, want 404", w.Code)
+	}
+}
+
+func TestTaskOrder(t *testing.T) {
+	t.Run("memory", func(t *testing.T) {
+		testTaskOrder(t, newTestServer(t))
+	})
+	t.Run("sqlite", func(t *testing.T) {
+		testTaskOrder(t, newTestServer(t, useSQLite(t)))
+	})
+}
+
+func testTaskOrder(t *testing.T, s *APIServer) {
+	token := register(t, s, "alice")
+	create := func(title, priority string) models.Task {
+		var task models.Task
+		mustDo(t, s, http.MethodPost, "/tasks", token, map[string]string{"title": title, "priority": priority}, &task)
+		return task
+	}
+
+	release := create("Release", "P0")
+	tests := create("Tests", "P3")
+	docs := create("Docs", "P1")
+	done := create("Done", "P0")
+	mustDo(t, s, http.MethodPut, dependencyPath(release.ID, tests.ID), token, nil, nil)
+	mustDo(t, s, http.MethodPut, dependencyPath(release.ID, done.ID), token, nil, nil)
+	mustDo(t, s, http.MethodPost, taskPath(done.ID)+"/transition", token, map[string]string{"status": string(models.StatusDone)}, nil)
+
+	// The finished task is left out, and the release waits for the tests.
+	var ordered []models.Task
+	mustDo(t, s, http.MethodGet, "/tasks/order", token, nil, &ordered)
+	got := make([]int, len(ordered))
+	for i := range ordered {
+		got[i] = ordered[i].ID
+	}
+	if want := []int{docs.ID, tests.ID, release.ID}; fmt.Sprint(got) != fmt.Sprint(want) {
+		t.Errorf("task order %v, want %v", got, want)
+	}
+}
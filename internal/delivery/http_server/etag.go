@@ -0,0 +1,56 @@
+package apiserver
+
+import (
+	"TaskManager/internal/models"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// taskETag returns the strong entity tag of a task version. Progress and
+// the blocked flag come from other tasks rather than the task's own
+// version, so they are part of the tag as well.
+func taskETag(task *models.Task) string {
+	etag := fmt.Sprintf("%d-%d", task.ID, task.Version)
+	if task.Progress != nil {
+		etag += fmt.Sprintf("-%d", *task.Progress)
+	}
+	if task.Blocked {
+		etag += "-b"
+	}
+	return `"` + etag + `"`
+}
+
+// etagMatches reports whether an If-Match or If-None-Match header value
+// lists etag. If-None-Match uses the weak comparison, which ignores W/
+// prefixes; If-Match requires a strong match.
+func etagMatches(header, etag string, weak bool) bool {
+	for _, candidate := range strings.Split(header, ",") {
+		candidate = strings.TrimSpace(candidate)
+		if candidate == "*" {
+			return true
+		}
+		if weak {
+			candidate = strings.TrimPrefix(candidate, "W/")
+		}
+		if candidate == etag {
+			return true
+		}
+	}
+	return false
+}
+
+// checkIfMatch writes 412 Precondition Failed and returns false if the
+// request carries an If-Match header that does not match current.
+func checkIfMatch(ctx *gin.Context, current *models.Task) bool {
+	ifMatch := ctx.GetHeader("If-Match")
+	if ifMatch == "" || etagMatches(ifMatch, taskETag(current), false) {
+		return true
+	}
+
+	ctx.Header("ETag", taskETag(current))
+	ctx.JSON(http.StatusPreconditionFailed, ErrorResponse{"Task has been modified"})
+	return false
+}
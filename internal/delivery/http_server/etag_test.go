@@ -0,0 +1,129 @@
+package apiserver
+
+import (
+	"TaskManager/internal/models"
+	"net/http"
+	"testing"
+)
+
+func TestETagMatches(t *testing.T) {
+	etag := `"1-2"`
+
+	tests := []struct {
+		header string
+		weak   bool
+		want   bool
+	}{
+		{`"1-2"`, false, true},
+		{`"1-2"`, true, true},
+		{`"1-1", "1-2"`, false, true},
+		{`"1-1","1-3"`, true, false},
+		{`*`, false, true},
+		{`W/"1-2"`, true, true},
+		{`W/"1-2"`, false, false},
+		{`1-2`, false, false},
+		{``, true, false},
+	}
+	for _, tt := range tests {
+		if got := etagMatches(tt.header, etag, tt.weak); got != tt.want {
+			t.Errorf("etagMatches(%q, weak=%v) = %v, want %v", tt.header, tt.weak, got, tt.want)
+		}
+	}
+}
+
+func TestGetTaskNotModified(t *testing.T) {
+	s := newTestServer(t)
+	token := register(t, s, "alice")
+	task := createTask(t, s, token, "Cached")
+
+	w := do(t, s, http.MethodGet, taskPath(task.ID), token, nil)
+	etag := w.Header().Get("ETag")
+	if w.Code != http.StatusOK || etag == "" {
+		t.Fatalf("get: status %d, ETag %q", w.Code, etag)
+	}
+
+	for _, header := range []string{etag, "W/" + etag, `"0-0", ` + etag, "*"} {
+		w := doWith(t, s, http.MethodGet, taskPath(task.ID), token, nil, http.Header{"If-None-Match": {header}})
+		if w.Code != http.StatusNotModified {
+			t.Errorf("If-None-Match %s: status %d, want 304", header, w.Code)
+		}
+		if w.Body.Len() != 0 || w.Header().Get("ETag") != etag {
+			t.Errorf("If-None-Match %s: body %q, ETag %q", header, w.Body, w.Header().Get("ETag"))
+		}
+	}
+
+	mustDo(t, s, http.MethodPatch, taskPath(task.ID), token, map[string]string{"title": "Changed"}, nil)
+	w = doWith(t, s, http.MethodGet, taskPath(task.ID), token, nil, http.Header{"If-None-Match": {etag}})
+	if w.Code != http.StatusOK || w.Header().Get("ETag") == etag {
+		t.Errorf("stale If-None-Match: status %d, ETag %q, want 200 with a new ETag", w.Code, w.Header().Get("ETag"))
+	}
+}
+
+func TestGetTaskNotModifiedFromCache(t *testing.T) {
+	s, _ := newCachingTestServer(t)
+	token := register(t, s, "alice")
+	task := createTask(t, s, token, "Cached")
+	etag := do(t, s, http.MethodGet, taskPath(task.ID), token, nil).Header().Get("ETag")
+
+	w := doWith(t, s, http.MethodGet, taskPath(task.ID), token, nil, http.Header{"If-None-Match": {etag}})
+	if w.Code != http.StatusNotModified || w.Header().Get("X-Cache") != "HIT" {
+		t.Errorf("cached If-None-Match: status %d, X-Cache %q, want 304 from the cache", w.Code, w.Header().Get("X-Cache"))
+	}
+
+	// A 304 is not cached in place of the task.
+	mustDo(t, s, http.MethodPatch, taskPath(task.ID), token, map[string]string{"title": "Changed"}, nil)
+	newETag := do(t, s, http.MethodGet, taskPath(task.ID), token, nil).Header().Get("ETag")
+	doWith(t, s, http.MethodGet, taskPath(task.ID)+"?fresh", token, nil, http.Header{"If-None-Match": {newETag}})
+	if fetched := do(t, s, http.MethodGet, taskPath(task.ID)+"?fresh", token, nil); fetched.Code != http.StatusOK || fetched.Body.Len() == 0 {
+		t.Errorf("get after a 304: status %d, body %q", fetched.Code, fetched.Body)
+	}
+}
+
+func TestIfMatchPreconditionFailed(t *testing.T) {
+	s := newTestServer(t)
+	token := register(t, s, "alice")
+
+	tests := []struct {
+		method string
+		path   string
+		body   interface{}
+	}{
+		{http.MethodPut, "", map[string]string{"title": "Put"}},
+		{http.MethodPatch, "", map[string]string{"title": "Patched"}},
+		{http.MethodPost, "/transition", map[string]string{"status": string(models.StatusInProgress)}},
+		{http.MethodDelete, "", nil},
+	}
+	for _, tt := range tests {
+		task := createTask(t, s, token, "Conditional")
+		stale := taskETag(&task)
+		path := taskPath(task.ID) + tt.path
+		mustDo(t, s, http.MethodPatch, taskPath(task.ID), token, map[string]string{"description": "moved on"}, nil)
+		current := getTask(t, s, token, task.ID)
+
+		for _, header := range []string{stale, "W/" + taskETag(&current)} {
+			w := doWith(t, s, tt.method, path, token, tt.body, http.Header{"If-Match": {header}})
+			if w.Code != http.StatusPreconditionFailed {
+				t.Errorf("%s %s with If-Match %s: status %d, want 412", tt.method, path, header, w.Code)
+			}
+			if got := w.Header().Get("ETag"); got != taskETag(&current) {
+				t.Errorf("%s %s with If-Match %s: ETag %q, want the current %q", tt.method, path, header, got, taskETag(&current))
+			}
+		}
+		if stored := getTask(t, s, token, task.ID); stored.Version != current.Version {
+			t.Errorf("%s %s: task changed by a failed precondition", tt.method, path)
+		}
+
+		w := doWith(t, s, tt.method, path, token, tt.body, http.Header{"If-Match": {taskETag(&current)}})
+		if w.Code != http.StatusOK {
+			t.Errorf("%s %s with the current ETag: status %d, want 200: %s", tt.method, path, w.Code, w.Body)
+		}
+	}
+
+	task := createTask(t, s, token, "Any")
+	if w := doWith(t, s, http.MethodPatch, taskPath(task.ID), token, map[string]string{"title": "x"}, http.Header{"If-Match": {"*"}}); w.Code != http.StatusOK {
+		t.Errorf("If-Match *: status %d, want 200", w.Code)
+	}
+	if w := doWith(t, s, http.MethodPatch, taskPath(999), token, map[string]string{"title": "x"}, http.Header{"If-Match": {"*"}}); w.Code != http.StatusNotFound {
+		t.Errorf("If-Match * on a missing task: status %d, want 404", w.Code)
+	}
+}
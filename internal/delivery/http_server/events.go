@@ -0,0 +1,98 @@
+package apiserver
+
+import (
+	"TaskManager/internal/models"
+	"context"
+	"encoding/json"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// publish adds an event to the outbox of the user's webhooks and sends it
+// to the user's streams. The change is already made, so the event is
+// published even if the client has gone away. It returns an error if the
+// event could not be added to the outbox; failing to stream it is only
+// logged, as streams are not replayed anyway.
+func (s *APIServer) publish(ctx context.Context, userID int, event *models.Event) error {
+	ctx = context.WithoutCancel(ctx)
+	event.OccurredAt = time.Now().UTC()
+	payload, err := json.Marshal(event)
+	if err != nil {
+		return err
+	}
+
+	if s.webhooks != nil {
+		if err := s.storage.EnqueueEvent(ctx, userID, event.Type, payload); err != nil {
+			return err
+		}
+	}
+	if err := s.broker.Publish(userID, payload); err != nil {
+		s.log(ctx).Warn("Error streaming event: ", err)
+	}
+	return nil
+}
+
+// publishTask publishes an event about a task.
+func (s *APIServer) publishTask(ctx context.Context, userID int, eventType models.EventType, task *models.Task) error {
+	return s.publish(ctx, userID, &models.Event{Type: eventType, Task: task})
+}
+
+// publishUpdate publishes task.updated for a changed task, and also
+// task.completed if the change moved it to done from another status.
+func (s *APIServer) publishUpdate(ctx context.Context, userID int, previous models.TaskStatus, task *models.Task) error {
+	if err := s.publishTask(ctx, userID, models.EventTaskUpdated, task); err != nil {
+		return err
+	}
+	if task.Status == models.StatusDone && previous != models.StatusDone {
+		return s.publishTask(ctx, userID, models.EventTaskCompleted, task)
+	}
+	return nil
+}
+
+// publishStoredUpdate is publishUpdate for a task read back from the
+// storage, for handlers that do not have the whole task at hand.
+func (s *APIServer) publishStoredUpdate(ctx context.Context, userID, taskID int, previous models.TaskStatus) error {
+	ctx = context.WithoutCancel(ctx)
+	task, err := s.storage.GetTaskByID(ctx, userID, taskID)
+	if err != nil {
+		return err
+	}
+	return s.publishUpdate(ctx, userID, previous, task)
+}
+
+// publishStoredTask publishes task.updated for a task read back from the
+// storage, after a change to its tags, dependencies or checklist, which
+// leaves its status as it is.
+func (s *APIServer) publishStoredTask(ctx context.Context, userID, taskID int) error {
+	ctx = context.WithoutCancel(ctx)
+	task, err := s.storage.GetTaskByID(ctx, userID, taskID)
+	if err != nil {
+		return err
+	}
+	return s.publishTask(ctx, userID, models.EventTaskUpdated, task)
+}
+
+// publishReminder publishes a reminder fired by the scheduler.
+func (s *APIServer) publishReminder(ctx context.Context, reminder *models.Reminder) {
+	if err := s.publish(ctx, reminder.UserID, &models.Event{Type: models.EventTaskReminder, Reminder: reminder}); err != nil {
+		s.log(ctx).Error("Error publishing event: ", err)
+	}
+}
+
+// publishOccurrence publishes an occurrence of a recurring task created
+// outside of a request.
+func (s *APIServer) publishOccurrence(ctx context.Context, task *models.Task) {
+	if err := s.publishTask(ctx, task.UserID, models.EventTaskCreated, task); err != nil {
+		s.log(ctx).Error("Error publishing event: ", err)
+	}
+}
+
+// respondEventError fails a request whose change was made but whose event
+// could not be published to the webhooks. The response says so, so that
+// clients reload rather than repeat the change.
+func (s *APIServer) respondEventError(ctx *gin.Context, err error) {
+	s.log(ctx).Error("Error publishing event: ", err)
+	ctx.JSON(http.StatusInternalServerError, ErrorResponse{"Change saved, but its event could not be published"})
+}
@@ -0,0 +1,179 @@
+package apiserver
+
+import (
+	"TaskManager/internal/models"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"testing"
+)
+
+// subscribe returns a function returning the events published for the
+// user since the previous call.
+func subscribe(t *testing.T, s *APIServer, userID int) func() []models.Event {
+	t.Helper()
+
+	events, unsubscribe := s.broker.Subscribe(userID)
+	t.Cleanup(unsubscribe)
+
+	return func() []models.Event {
+		var received []models.Event
+		for {
+			select {
+			case payload := <-events:
+				var event models.Event
+				if err := json.Unmarshal(payload, &event); err != nil {
+					t.Fatal(err)
+				}
+				received = append(received, event)
+			default:
+				return received
+			}
+		}
+	}
+}
+
+// expectUpdated checks that the only events received are task.updated for
+// the given tasks, in order.
+func expectUpdated(t *testing.T, what string, events []models.Event, taskIDs ...int) {
+	t.Helper()
+
+	if len(events) != len(taskIDs) {
+		t.Errorf("%s: %d events, want %d", what, len(events), len(taskIDs))
+		return
+	}
+	for i, event := range events {
+		if event.Type != models.EventTaskUpdated || event.Task == nil || event.Task.ID != taskIDs[i] {
+			t.Errorf("%s: event %d = %s for %+v, want task.updated for task %d", what, i, event.Type, event.Task, taskIDs[i])
+		}
+	}
+}
+
+// mustDo sends a request that must succeed and decodes its response into
+// out, if given.
+func mustDo(t *testing.T, s *APIServer, method, path, token string, body, out interface{}) {
+	t.Helper()
+
+	w := do(t, s, method, path, token, body)
+	if w.Code != http.StatusOK && w.Code != http.StatusCreated {
+		t.Fatalf("%s %s: status %d: %s", method, path, w.Code, w.Body)
+	}
+	if out != nil {
+		if err := json.Unmarshal(w.Body.Bytes(), out); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func TestTagChangesPublishUpdates(t *testing.T) {
+	s := newTestServer(t)
+	token := register(t, s, "alice")
+	first := createTask(t, s, token, "First")
+	second := createTask(t, s, token, "Second")
+	received := subscribe(t, s, 1)
+
+	var tag models.Tag
+	mustDo(t, s, http.MethodPost, "/tags", token, map[string]string{"name": "home"}, &tag)
+	expectUpdated(t, "create tag", received())
+
+	for _, task := range []models.Task{first, second} {
+		mustDo(t, s, http.MethodPut, fmt.Sprintf("/tasks/%d/tags/%d", task.ID, tag.ID), token, nil, nil)
+		expectUpdated(t, "attach tag", received(), task.ID)
+	}
+
+	mustDo(t, s, http.MethodPut, fmt.Sprintf("/tags/%d", tag.ID), token, map[string]string{"name": "house"}, nil)
+	events := received()
+	expectUpdated(t, "rename tag", events, first.ID, second.ID)
+	for _, event := range events {
+		if len(event.Task.Tags) != 1 || event.Task.Tags[0].Name != "house" {
+			t.Errorf("rename tag: event carries tags %+v", event.Task.Tags)
+		}
+	}
+
+	mustDo(t, s, http.MethodDelete, fmt.Sprintf("/tasks/%d/tags/%d", second.ID, tag.ID), token, nil, nil)
+	expectUpdated(t, "detach tag", received(), second.ID)
+
+	mustDo(t, s, http.MethodDelete, fmt.Sprintf("/tags/%d", tag.ID), token, nil, nil)
+	events = received()
+	expectUpdated(t, "delete tag", events, first.ID)
+	if len(events) == 1 && len(events[0].Task.Tags) != 0 {
+		t.Errorf("delete tag: event carries tags %+v", events[0].Task.Tags)
+	}
+}
+
+func TestDependencyChangesPublishUpdates(t *testing.T) {
+	s := newTestServer(t)
+	token := register(t, s, "alice")
+	blocked := createTask(t, s, token, "Blocked")
+	blocker := createTask(t, s, token, "Blocker")
+	received := subscribe(t, s, 1)
+
+	path := fmt.Sprintf("/tasks/%d/dependencies/%d", blocked.ID, blocker.ID)
+	mustDo(t, s, http.MethodPut, path, token, nil, nil)
+	events := received()
+	expectUpdated(t, "add dependency", events, blocked.ID)
+	if len(events) == 1 && !events[0].Task.Blocked {
+		t.Error("add dependency: event task is not blocked")
+	}
+
+	mustDo(t, s, http.MethodDelete, path, token, nil, nil)
+	expectUpdated(t, "remove dependency", received(), blocked.ID)
+}
+
+func TestChecklistChangesPublishUpdates(t *testing.T) {
+	s := newTestServer(t)
+	token := register(t, s, "alice")
+	task := createTask(t, s, token, "Checklist")
+	received := subscribe(t, s, 1)
+	checklist := fmt.Sprintf("/tasks/%d/checklist", task.ID)
+
+	var first, second models.ChecklistItem
+	mustDo(t, s, http.MethodPost, checklist, token, map[string]string{"text": "one"}, &first)
+	mustDo(t, s, http.MethodPost, checklist, token, map[string]string{"text": "two"}, &second)
+	expectUpdated(t, "add items", received(), task.ID, task.ID)
+
+	mustDo(t, s, http.MethodPatch, fmt.Sprintf("%s/%d", checklist, first.ID), token, map[string]bool{"done": true}, nil)
+	events := received()
+	expectUpdated(t, "check item", events, task.ID)
+	if len(events) == 1 && (len(events[0].Task.Checklist) != 2 || !events[0].Task.Checklist[0].Done) {
+		t.Errorf("check item: event checklist %+v, want the first item done", events[0].Task.Checklist)
+	}
+
+	mustDo(t, s, http.MethodPut, checklist+"/order", token, map[string][]int{"item_ids": {second.ID, first.ID}}, nil)
+	expectUpdated(t, "reorder", received(), task.ID)
+
+	mustDo(t, s, http.MethodDelete, fmt.Sprintf("%s/%d", checklist, second.ID), token, nil, nil)
+	expectUpdated(t, "delete item", received(), task.ID)
+}
+
+func TestDeletePublishesSubtasks(t *testing.T) {
+	s := newTestServer(t)
+	token := register(t, s, "alice")
+	parent := createTask(t, s, token, "Parent")
+	other := createTask(t, s, token, "Other")
+
+	var child, grandchild models.Task
+	mustDo(t, s, http.MethodPost, "/tasks", token, map[string]interface{}{"title": "Child", "parent_id": parent.ID}, &child)
+	mustDo(t, s, http.MethodPost, "/tasks", token, map[string]interface{}{"title": "Grandchild", "parent_id": child.ID}, &grandchild)
+	received := subscribe(t, s, 1)
+
+	mustDo(t, s, http.MethodDelete, taskPath(parent.ID), token, nil, nil)
+
+	events := received()
+	want := []int{parent.ID, child.ID, grandchild.ID}
+	if len(events) != len(want) {
+		t.Fatalf("%d events, want %d", len(events), len(want))
+	}
+	for i, event := range events {
+		if event.Type != models.EventTaskDeleted || event.Task.ID != want[i] {
+			t.Errorf("event %d = %s for task %d, want task.deleted for %d", i, event.Type, event.Task.ID, want[i])
+		}
+	}
+
+	if w := do(t, s, http.MethodGet, taskPath(grandchild.ID), token, nil); w.Code != http.StatusNotFound {
+		t.Errorf("subtask after delete: status %d, want 404", w.Code)
+	}
+	if w := do(t, s, http.MethodGet, taskPath(other.ID), token, nil); w.Code != http.StatusOK {
+		t.Errorf("unrelated task after delete: status %d, want 200", w.Code)
+	}
+}
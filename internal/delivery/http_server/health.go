@@ -0,0 +1,81 @@
+package apiserver
+
+import (
+	"context"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// @Summary Handling the liveness probe
+// @Description Handling the request to check that the process is alive. It does not check the dependencies, so that a database outage does not get the server restarted.
+// @Produce json
+// @Success 200 {object} HealthResponse "The server is alive"
+// @Router /healthz [get]
+func (s *APIServer) handleHealth(ctx *gin.Context) {
+	ctx.JSON(http.StatusOK, HealthResponse{Status: "ok"})
+}
+
+// @Summary Handling the readiness probe
+// @Description Handling the request to check that the server can serve requests: the storage and, if enabled, the cache are pinged concurrently, each bounded by the readiness timeout. The server is not ready once it is shutting down.
+// @Produce json
+// @Success 200 {object} HealthResponse "The server is ready"
+// @Failure 503 {object} HealthResponse "A dependency is down or the server is shutting down"
+// @Router /readyz [get]
+func (s *APIServer) handleReady(ctx *gin.Context) {
+	probes := map[string]func(context.Context) error{
+		"storage": s.storage.Ping,
+	}
+	if s.cache != nil {
+		probes["cache"] = s.cache.Ping
+	}
+
+	res := HealthResponse{Status: "ok", Checks: make(map[string]DependencyStatus, len(probes))}
+
+	var (
+		mu sync.Mutex
+		wg sync.WaitGroup
+	)
+	for name, ping := range probes {
+		wg.Add(1)
+		go func(name string, ping func(context.Context) error) {
+			defer wg.Done()
+			status := s.probe(ctx.Request.Context(), ping)
+
+			mu.Lock()
+			defer mu.Unlock()
+			res.Checks[name] = status
+			if status.Error != "" {
+				res.Status = "unavailable"
+			}
+		}(name, ping)
+	}
+	wg.Wait()
+
+	if s.draining.Load() {
+		res.Status = "unavailable"
+	}
+
+	if res.Status != "ok" {
+		ctx.JSON(http.StatusServiceUnavailable, res)
+		return
+	}
+	ctx.JSON(http.StatusOK, res)
+}
+
+// probe pings a dependency within the readiness timeout.
+func (s *APIServer) probe(ctx context.Context, ping func(context.Context) error) DependencyStatus {
+	ctx, cancel := context.WithTimeout(ctx, s.config.ReadinessTimeout)
+	defer cancel()
+
+	start := time.Now()
+	err := ping(ctx)
+	latency := float64(time.Since(start).Microseconds()) / 1000
+
+	if err != nil {
+		return DependencyStatus{Status: "down", LatencyMS: latency, Error: err.Error()}
+	}
+	return DependencyStatus{Status: "up", LatencyMS: latency}
+}
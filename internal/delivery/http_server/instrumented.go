@@ -0,0 +1,364 @@
+package apiserver
+
+import (
+	"TaskManager/internal/cache/lru"
+	"TaskManager/internal/metrics"
+	"TaskManager/internal/models"
+	"TaskManager/internal/tracing"
+	"context"
+	"errors"
+	"time"
+
+	"github.com/go-redis/redis/v8"
+	"github.com/sirupsen/logrus"
+	semconv "go.opentelemetry.io/otel/semconv/v1.26.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// instrumentedStorage traces and logs every storage call and records its
+// latency and errors.
+type instrumentedStorage struct {
+	Storage
+	// logger logs the calls made outside of requests.
+	logger logrus.FieldLogger
+}
+
+// instrumentedCache traces every cache call and records its latency and
+// errors, and whether lookups hit.
+type instrumentedCache struct {
+	Cache
+}
+
+// observe starts the span of a storage call. The returned function is
+// deferred with the named error of the call; it ends the span, records the
+// metrics and logs the call at debug level to the logger of the request.
+func (s *instrumentedStorage) observe(ctx context.Context, method string) (context.Context, func(*error)) {
+	start := time.Now()
+	logger := loggerFrom(ctx, s.logger)
+	ctx, span := tracing.Tracer().Start(ctx, "storage."+method,
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(semconv.DBOperationName(method)),
+	)
+
+	return ctx, func(err *error) {
+		tracing.End(span, *err)
+		metrics.ObserveStorage(method, start, *err)
+
+		entry := logger.WithFields(logrus.Fields{
+			"storage_method": method,
+			"latency_ms":     float64(time.Since(start).Microseconds()) / 1000,
+		})
+		if *err != nil {
+			entry = entry.WithError(*err)
+		}
+		entry.Debug("Storage call")
+	}
+}
+
+// observeCache is observe for cache calls, which are not logged.
+func observeCache(ctx context.Context, method string) (context.Context, func(*error)) {
+	start := time.Now()
+	ctx, span := tracing.Tracer().Start(ctx, "cache."+method, trace.WithSpanKind(trace.SpanKindClient))
+
+	return ctx, func(err *error) {
+		tracing.End(span, *err)
+		metrics.ObserveCache(method, start, *err)
+	}
+}
+
+// isCacheMiss tells a missing key, as reported by the cache backends,
+// from a failed lookup.
+func isCacheMiss(err error) bool {
+	return errors.Is(err, lru.ErrNotFound) || errors.Is(err, redis.Nil)
+}
+
+func (c *instrumentedCache) Get(ctx context.Context, key string) (string, error) {
+	ctx, done := observeCache(ctx, "Get")
+	value, err := c.Cache.Get(ctx, key)
+
+	// A miss is not a failure.
+	failure := err
+	if isCacheMiss(err) {
+		failure = nil
+	}
+	done(&failure)
+	metrics.ObserveLookup(err == nil)
+
+	return value, err
+}
+
+func (c *instrumentedCache) Set(ctx context.Context, key string, value string, expiration time.Duration) (err error) {
+	ctx, done := observeCache(ctx, "Set")
+	defer done(&err)
+	return c.Cache.Set(ctx, key, value, expiration)
+}
+
+func (c *instrumentedCache) Del(ctx context.Context, key string) (err error) {
+	ctx, done := observeCache(ctx, "Del")
+	defer done(&err)
+	return c.Cache.Del(ctx, key)
+}
+
+func (c *instrumentedCache) Ping(ctx context.Context) (err error) {
+	ctx, done := observeCache(ctx, "Ping")
+	defer done(&err)
+	return c.Cache.Ping(ctx)
+}
+
+func (s *instrumentedStorage) Ping(ctx context.Context) (err error) {
+	ctx, done := s.observe(ctx, "Ping")
+	defer done(&err)
+	return s.Storage.Ping(ctx)
+}
+
+func (s *instrumentedStorage) CreateUser(ctx context.Context, username, passwordHash string) (_ int, err error) {
+	ctx, done := s.observe(ctx, "CreateUser")
+	defer done(&err)
+	return s.Storage.CreateUser(ctx, username, passwordHash)
+}
+
+func (s *instrumentedStorage) GetUserByUsername(ctx context.Context, username string) (_ *models.User, err error) {
+	ctx, done := s.observe(ctx, "GetUserByUsername")
+	defer done(&err)
+	return s.Storage.GetUserByUsername(ctx, username)
+}
+
+func (s *instrumentedStorage) UpdateUserPassword(ctx context.Context, userID int, passwordHash string) (err error) {
+	ctx, done := s.observe(ctx, "UpdateUserPassword")
+	defer done(&err)
+	return s.Storage.UpdateUserPassword(ctx, userID, passwordHash)
+}
+
+func (s *instrumentedStorage) CreateRefreshToken(ctx context.Context, token *models.RefreshToken) (err error) {
+	ctx, done := s.observe(ctx, "CreateRefreshToken")
+	defer done(&err)
+	return s.Storage.CreateRefreshToken(ctx, token)
+}
+
+func (s *instrumentedStorage) RotateRefreshToken(ctx context.Context, oldHash string, next *models.RefreshToken) (err error) {
+	ctx, done := s.observe(ctx, "RotateRefreshToken")
+	defer done(&err)
+	return s.Storage.RotateRefreshToken(ctx, oldHash, next)
+}
+
+func (s *instrumentedStorage) RevokeRefreshToken(ctx context.Context, userID int, tokenHash string) (err error) {
+	ctx, done := s.observe(ctx, "RevokeRefreshToken")
+	defer done(&err)
+	return s.Storage.RevokeRefreshToken(ctx, userID, tokenHash)
+}
+
+func (s *instrumentedStorage) RevokeAllTokens(ctx context.Context, userID int) (err error) {
+	ctx, done := s.observe(ctx, "RevokeAllTokens")
+	defer done(&err)
+	return s.Storage.RevokeAllTokens(ctx, userID)
+}
+
+func (s *instrumentedStorage) RevokeAccessToken(ctx context.Context, jti string, expiresAt time.Time) (err error) {
+	ctx, done := s.observe(ctx, "RevokeAccessToken")
+	defer done(&err)
+	return s.Storage.RevokeAccessToken(ctx, jti, expiresAt)
+}
+
+func (s *instrumentedStorage) IsAccessTokenRevoked(ctx context.Context, userID int, jti string, issuedAt time.Time) (_ bool, err error) {
+	ctx, done := s.observe(ctx, "IsAccessTokenRevoked")
+	defer done(&err)
+	return s.Storage.IsAccessTokenRevoked(ctx, userID, jti, issuedAt)
+}
+
+func (s *instrumentedStorage) GetTasks(ctx context.Context, userID int, query *models.TaskQuery) (_ *models.TaskPage, err error) {
+	ctx, done := s.observe(ctx, "GetTasks")
+	defer done(&err)
+	return s.Storage.GetTasks(ctx, userID, query)
+}
+
+func (s *instrumentedStorage) CreateTask(ctx context.Context, userID int, task *models.Task) (err error) {
+	ctx, done := s.observe(ctx, "CreateTask")
+	defer done(&err)
+	return s.Storage.CreateTask(ctx, userID, task)
+}
+
+func (s *instrumentedStorage) GetTaskByID(ctx context.Context, userID, taskID int) (_ *models.Task, err error) {
+	ctx, done := s.observe(ctx, "GetTaskByID")
+	defer done(&err)
+	return s.Storage.GetTaskByID(ctx, userID, taskID)
+}
+
+func (s *instrumentedStorage) UpdateTask(ctx context.Context, userID int, task *models.Task) (err error) {
+	ctx, done := s.observe(ctx, "UpdateTask")
+	defer done(&err)
+	return s.Storage.UpdateTask(ctx, userID, task)
+}
+
+func (s *instrumentedStorage) PatchTask(ctx context.Context, userID, taskID int, patch *models.TaskPatch, version int) (_ *models.Task, err error) {
+	ctx, done := s.observe(ctx, "PatchTask")
+	defer done(&err)
+	return s.Storage.PatchTask(ctx, userID, taskID, patch, version)
+}
+
+func (s *instrumentedStorage) UpdateTaskStatus(ctx context.Context, userID, taskID int, status models.TaskStatus, version int) (err error) {
+	ctx, done := s.observe(ctx, "UpdateTaskStatus")
+	defer done(&err)
+	return s.Storage.UpdateTaskStatus(ctx, userID, taskID, status, version)
+}
+
+func (s *instrumentedStorage) DeleteTask(ctx context.Context, userID, taskID, version int) (_ []models.Task, err error) {
+	ctx, done := s.observe(ctx, "DeleteTask")
+	defer done(&err)
+	return s.Storage.DeleteTask(ctx, userID, taskID, version)
+}
+
+func (s *instrumentedStorage) GetSubtasks(ctx context.Context, userID, taskID int) (_ []models.Task, err error) {
+	ctx, done := s.observe(ctx, "GetSubtasks")
+	defer done(&err)
+	return s.Storage.GetSubtasks(ctx, userID, taskID)
+}
+
+func (s *instrumentedStorage) AddChecklistItem(ctx context.Context, userID, taskID int, item *models.ChecklistItem) (err error) {
+	ctx, done := s.observe(ctx, "AddChecklistItem")
+	defer done(&err)
+	return s.Storage.AddChecklistItem(ctx, userID, taskID, item)
+}
+
+func (s *instrumentedStorage) UpdateChecklistItem(ctx context.Context, userID, taskID, itemID int, patch *models.ChecklistItemPatch) (_ *models.ChecklistItem, err error) {
+	ctx, done := s.observe(ctx, "UpdateChecklistItem")
+	defer done(&err)
+	return s.Storage.UpdateChecklistItem(ctx, userID, taskID, itemID, patch)
+}
+
+func (s *instrumentedStorage) DeleteChecklistItem(ctx context.Context, userID, taskID, itemID int) (err error) {
+	ctx, done := s.observe(ctx, "DeleteChecklistItem")
+	defer done(&err)
+	return s.Storage.DeleteChecklistItem(ctx, userID, taskID, itemID)
+}
+
+func (s *instrumentedStorage) ReorderChecklist(ctx context.Context, userID, taskID int, itemIDs []int) (err error) {
+	ctx, done := s.observe(ctx, "ReorderChecklist")
+	defer done(&err)
+	return s.Storage.ReorderChecklist(ctx, userID, taskID, itemIDs)
+}
+
+func (s *instrumentedStorage) AddDependency(ctx context.Context, userID, taskID, dependsOnID int) (err error) {
+	ctx, done := s.observe(ctx, "AddDependency")
+	defer done(&err)
+	return s.Storage.AddDependency(ctx, userID, taskID, dependsOnID)
+}
+
+func (s *instrumentedStorage) RemoveDependency(ctx context.Context, userID, taskID, dependsOnID int) (err error) {
+	ctx, done := s.observe(ctx, "RemoveDependency")
+	defer done(&err)
+	return s.Storage.RemoveDependency(ctx, userID, taskID, dependsOnID)
+}
+
+func (s *instrumentedStorage) MaterializeOccurrences(ctx context.Context, userID, seriesID int, until time.Time) (_ []models.Task, err error) {
+	ctx, done := s.observe(ctx, "MaterializeOccurrences")
+	defer done(&err)
+	return s.Storage.MaterializeOccurrences(ctx, userID, seriesID, until)
+}
+
+func (s *instrumentedStorage) MaterializeDueOccurrences(ctx context.Context, until time.Time) (_ []models.Task, err error) {
+	ctx, done := s.observe(ctx, "MaterializeDueOccurrences")
+	defer done(&err)
+	return s.Storage.MaterializeDueOccurrences(ctx, until)
+}
+
+func (s *instrumentedStorage) UpdateFollowingOccurrences(ctx context.Context, userID, taskID int, patch *models.SeriesPatch) (_ *models.Task, err error) {
+	ctx, done := s.observe(ctx, "UpdateFollowingOccurrences")
+	defer done(&err)
+	return s.Storage.UpdateFollowingOccurrences(ctx, userID, taskID, patch)
+}
+
+func (s *instrumentedStorage) FireDueReminders(ctx context.Context, offsets []time.Duration, from, to time.Time) (_ []models.Reminder, err error) {
+	ctx, done := s.observe(ctx, "FireDueReminders")
+	defer done(&err)
+	return s.Storage.FireDueReminders(ctx, offsets, from, to)
+}
+
+func (s *instrumentedStorage) GetWebhooks(ctx context.Context, userID int) (_ []models.Webhook, err error) {
+	ctx, done := s.observe(ctx, "GetWebhooks")
+	defer done(&err)
+	return s.Storage.GetWebhooks(ctx, userID)
+}
+
+func (s *instrumentedStorage) CreateWebhook(ctx context.Context, userID int, webhook *models.Webhook) (err error) {
+	ctx, done := s.observe(ctx, "CreateWebhook")
+	defer done(&err)
+	return s.Storage.CreateWebhook(ctx, userID, webhook)
+}
+
+func (s *instrumentedStorage) UpdateWebhook(ctx context.Context, userID int, webhook *models.Webhook) (err error) {
+	ctx, done := s.observe(ctx, "UpdateWebhook")
+	defer done(&err)
+	return s.Storage.UpdateWebhook(ctx, userID, webhook)
+}
+
+func (s *instrumentedStorage) DeleteWebhook(ctx context.Context, userID, webhookID int) (err error) {
+	ctx, done := s.observe(ctx, "DeleteWebhook")
+	defer done(&err)
+	return s.Storage.DeleteWebhook(ctx, userID, webhookID)
+}
+
+func (s *instrumentedStorage) EnqueueEvent(ctx context.Context, userID int, event models.EventType, payload []byte) (err error) {
+	ctx, done := s.observe(ctx, "EnqueueEvent")
+	defer done(&err)
+	return s.Storage.EnqueueEvent(ctx, userID, event, payload)
+}
+
+func (s *instrumentedStorage) ClaimDeliveries(ctx context.Context, now, leaseUntil time.Time, limit int) (_ []models.WebhookDelivery, err error) {
+	ctx, done := s.observe(ctx, "ClaimDeliveries")
+	defer done(&err)
+	return s.Storage.ClaimDeliveries(ctx, now, leaseUntil, limit)
+}
+
+func (s *instrumentedStorage) CompleteDelivery(ctx context.Context, deliveryID int, at time.Time) (err error) {
+	ctx, done := s.observe(ctx, "CompleteDelivery")
+	defer done(&err)
+	return s.Storage.CompleteDelivery(ctx, deliveryID, at)
+}
+
+func (s *instrumentedStorage) RetryDelivery(ctx context.Context, deliveryID int, next *time.Time, lastError string) (err error) {
+	ctx, done := s.observe(ctx, "RetryDelivery")
+	defer done(&err)
+	return s.Storage.RetryDelivery(ctx, deliveryID, next, lastError)
+}
+
+func (s *instrumentedStorage) DeleteDeliveries(ctx context.Context, before time.Time) (err error) {
+	ctx, done := s.observe(ctx, "DeleteDeliveries")
+	defer done(&err)
+	return s.Storage.DeleteDeliveries(ctx, before)
+}
+
+func (s *instrumentedStorage) GetTags(ctx context.Context, userID int) (_ []models.Tag, err error) {
+	ctx, done := s.observe(ctx, "GetTags")
+	defer done(&err)
+	return s.Storage.GetTags(ctx, userID)
+}
+
+func (s *instrumentedStorage) CreateTag(ctx context.Context, userID int, tag *models.Tag) (err error) {
+	ctx, done := s.observe(ctx, "CreateTag")
+	defer done(&err)
+	return s.Storage.CreateTag(ctx, userID, tag)
+}
+
+func (s *instrumentedStorage) RenameTag(ctx context.Context, userID, tagID int, name string) (err error) {
+	ctx, done := s.observe(ctx, "RenameTag")
+	defer done(&err)
+	return s.Storage.RenameTag(ctx, userID, tagID, name)
+}
+
+func (s *instrumentedStorage) DeleteTag(ctx context.Context, userID, tagID int) (err error) {
+	ctx, done := s.observe(ctx, "DeleteTag")
+	defer done(&err)
+	return s.Storage.DeleteTag(ctx, userID, tagID)
+}
+
+func (s *instrumentedStorage) AttachTag(ctx context.Context, userID, taskID, tagID int) (err error) {
+	ctx, done := s.observe(ctx, "AttachTag")
+	defer done(&err)
+	return s.Storage.AttachTag(ctx, userID, taskID, tagID)
+}
+
+func (s *instrumentedStorage) DetachTag(ctx context.Context, userID, taskID, tagID int) (err error) {
+	ctx, done := s.observe(ctx, "DetachTag")
+	defer done(&err)
+	return s.Storage.DetachTag(ctx, userID, taskID, tagID)
+}
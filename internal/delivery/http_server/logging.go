@@ -0,0 +1,124 @@
+package apiserver
+
+import (
+	"context"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/sirupsen/logrus"
+)
+
+// HeaderRequestID carries the ID of a request. An ID sent by a client or
+// proxy is kept, so that its logs can be matched with theirs; otherwise
+// one is generated. Either way it is returned with the response.
+const HeaderRequestID = "X-Request-ID"
+
+const (
+	// requestIDBytes is the length of generated request IDs.
+	requestIDBytes = 16
+	// maxRequestIDLength bounds the request IDs accepted from clients.
+	maxRequestIDLength = 128
+)
+
+// loggerKey is the request context key of the request logger.
+type loggerKey struct{}
+
+// RequestIDMiddleware assigns every request its ID.
+func (s *APIServer) RequestIDMiddleware() gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		requestID := ctx.GetHeader(HeaderRequestID)
+		if !validRequestID(requestID) {
+			generated, err := randomString(requestIDBytes)
+			if err != nil {
+				s.logger.Error("Error generating request ID: ", err)
+			}
+			requestID = generated
+		}
+
+		ctx.Set("requestID", requestID)
+		ctx.Header(HeaderRequestID, requestID)
+		ctx.Next()
+	}
+}
+
+// validRequestID tells whether a request ID from a client may be logged
+// as it is: it must be short and printable, without spaces.
+func validRequestID(id string) bool {
+	if id == "" || len(id) > maxRequestIDLength {
+		return false
+	}
+	for i := 0; i < len(id); i++ {
+		if id[i] <= ' ' || id[i] > '~' {
+			return false
+		}
+	}
+	return true
+}
+
+// LoggerMiddleware gives every request a logger carrying its ID and route,
+// which the handlers and the storage log through, and logs the request
+// once it is handled. AuthMiddleware adds the user to it. It must run
+// after RequestIDMiddleware.
+func (s *APIServer) LoggerMiddleware() gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		start := time.Now()
+
+		route := ctx.FullPath()
+		if route == "" {
+			route = "unmatched"
+		}
+		setRequestLogger(ctx, s.logger.WithFields(logrus.Fields{
+			"request_id": ctx.GetString("requestID"),
+			"method":     ctx.Request.Method,
+			"route":      route,
+		}))
+
+		ctx.Next()
+
+		status := ctx.Writer.Status()
+		entry := s.log(ctx).WithFields(logrus.Fields{
+			"path":       ctx.Request.URL.Path,
+			"status":     status,
+			"latency_ms": float64(time.Since(start).Microseconds()) / 1000,
+			"client_ip":  ctx.ClientIP(),
+		})
+		switch {
+		case status >= http.StatusInternalServerError:
+			entry.Error("Request handled")
+		case status >= http.StatusBadRequest:
+			entry.Warn("Request handled")
+		default:
+			entry.Info("Request handled")
+		}
+	}
+}
+
+// setRequestLogger stores the logger of a request in both the gin context
+// and the request context, which is what reaches the storage.
+func setRequestLogger(ctx *gin.Context, logger logrus.FieldLogger) {
+	ctx.Set("logger", logger)
+	ctx.Request = ctx.Request.WithContext(context.WithValue(ctx.Request.Context(), loggerKey{}, logger))
+}
+
+// log returns the logger of the request ctx belongs to, either its gin
+// context or a context derived from the request context. Outside of
+// requests it returns the server logger.
+func (s *APIServer) log(ctx context.Context) logrus.FieldLogger {
+	return loggerFrom(ctx, s.logger)
+}
+
+// loggerFrom is log with the given fallback.
+func loggerFrom(ctx context.Context, fallback logrus.FieldLogger) logrus.FieldLogger {
+	var logger interface{}
+	if c, ok := ctx.(*gin.Context); ok {
+		logger, _ = c.Get("logger")
+	} else {
+		logger = ctx.Value(loggerKey{})
+	}
+
+	if logger, ok := logger.(logrus.FieldLogger); ok {
+		return logger
+	}
+	return fallback
+}
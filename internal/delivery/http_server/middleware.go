@@ -1,8 +1,15 @@
 package apiserver
 
 import (
+	"TaskManager/internal/models"
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
 	"net/http"
+	"strconv"
 	"strings"
+	"time"
 
 	"github.com/dgrijalva/jwt-go"
 	"github.com/gin-gonic/gin"
@@ -17,8 +24,17 @@ func (s *APIServer) AuthMiddleware() gin.HandlerFunc {
 			return
 		}
 
-		tokenString := strings.Split(authorizationHeader, " ")[1]
+		tokenString, found := strings.CutPrefix(authorizationHeader, "Bearer ")
+		if !found {
+			ctx.JSON(http.StatusUnauthorized, gin.H{"error": "Authorization header must be a bearer token"})
+			ctx.Abort()
+			return
+		}
+
 		token, err := jwt.Parse(tokenString, func(token *jwt.Token) (interface{}, error) {
+			if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
+				return nil, fmt.Errorf("unexpected signing method %v", token.Header["alg"])
+			}
 			return []byte(s.config.JWTSecret), nil
 		})
 
@@ -35,28 +51,210 @@ func (s *APIServer) AuthMiddleware() gin.HandlerFunc {
 			return
 		}
 
+		sub, _ := claims["sub"].(float64)
+		jti, _ := claims["jti"].(string)
+		iat, _ := claims["iat"].(float64)
+		exp, _ := claims["exp"].(float64)
+		if jti == "" {
+			ctx.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid token claims"})
+			ctx.Abort()
+			return
+		}
+
+		revoked, err := s.storage.IsAccessTokenRevoked(ctx.Request.Context(), int(sub), jti, time.Unix(int64(iat), 0))
+		if err != nil {
+			s.log(ctx).Error("Error checking token revocation: ", err)
+			ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to verify token"})
+			ctx.Abort()
+			return
+		}
+		if revoked {
+			ctx.JSON(http.StatusUnauthorized, gin.H{"error": "Token has been revoked"})
+			ctx.Abort()
+			return
+		}
+
 		ctx.Set("userID", claims["sub"])
+		ctx.Set("tokenID", jti)
+		ctx.Set("tokenExpiresAt", time.Unix(int64(exp), 0))
+		setRequestLogger(ctx, s.log(ctx).WithField("user_id", int(sub)))
 		ctx.Next()
 	}
 }
 
+// StreamAuthMiddleware is AuthMiddleware that also accepts the token in
+// the access_token query parameter, as browsers cannot set headers on
+// EventSource and WebSocket requests.
+func (s *APIServer) StreamAuthMiddleware() gin.HandlerFunc {
+	auth := s.AuthMiddleware()
+	return func(ctx *gin.Context) {
+		if token := ctx.Query("access_token"); token != "" && ctx.GetHeader("Authorization") == "" {
+			ctx.Request.Header.Set("Authorization", "Bearer "+token)
+		}
+		auth(ctx)
+	}
+}
+
+// cachedResponse is a GET response stored by CacheMiddleware.
+type cachedResponse struct {
+	Status int         `json:"status"`
+	Header http.Header `json:"header"`
+	Body   []byte      `json:"body"`
+}
+
+// responseRecorder copies everything written to the client into body.
+type responseRecorder struct {
+	gin.ResponseWriter
+	body bytes.Buffer
+}
+
+func (w *responseRecorder) Write(b []byte) (int, error) {
+	w.body.Write(b)
+	return w.ResponseWriter.Write(b)
+}
+
+func (w *responseRecorder) WriteString(s string) (int, error) {
+	w.body.WriteString(s)
+	return w.ResponseWriter.WriteString(s)
+}
+
+// CacheMiddleware serves repeated GET requests of a user from the cache.
+// It must run after AuthMiddleware. Entries are keyed by user and request
+// URI and are dropped by invalidateTasks whenever the user's tasks change.
 func (s *APIServer) CacheMiddleware() gin.HandlerFunc {
 	return func(c *gin.Context) {
-		key := c.Request.URL.String()
-
-		if value, err := s.cache.Get(key); err == nil {
-			c.String(http.StatusOK, value)
+		userID, ok := userIDFromContext(c)
+		if c.Request.Method != http.MethodGet || !ok {
+			c.Next()
 			return
 		}
 
-		c.Next()
+		key := s.taskCacheKey(c.Request.Context(), userID, c.Request.URL.RequestURI())
 
-		if c.Writer.Status() == http.StatusOK {
-			if body, err := c.GetRawData(); err != nil {
-				if err := s.cache.Set(key, string(body), 0); err != nil {
-					s.logger.Warn(err)
+		if value, err := s.cache.Get(c.Request.Context(), key); err == nil {
+			var cached cachedResponse
+			if err := json.Unmarshal([]byte(value), &cached); err == nil {
+				etag := cached.Header.Get("ETag")
+				if etag != "" && etagMatches(c.GetHeader("If-None-Match"), etag, true) {
+					c.Header("ETag", etag)
+					c.Header("X-Cache", "HIT")
+					c.AbortWithStatus(http.StatusNotModified)
+					return
 				}
+
+				for name, values := range cached.Header {
+					for _, v := range values {
+						c.Writer.Header().Add(name, v)
+					}
+				}
+				c.Header("X-Cache", "HIT")
+				c.Data(cached.Status, cached.Header.Get("Content-Type"), cached.Body)
+				c.Abort()
+				return
+			}
+		}
+
+		recorder := &responseRecorder{ResponseWriter: c.Writer}
+		c.Writer = recorder
+		c.Header("X-Cache", "MISS")
+
+		c.Next()
+
+		if recorder.Status() != http.StatusOK {
+			return
+		}
+
+		header := recorder.Header().Clone()
+		header.Del("X-Cache")
+
+		value, err := json.Marshal(cachedResponse{
+			Status: recorder.Status(),
+			Header: header,
+			Body:   recorder.body.Bytes(),
+		})
+		if err != nil {
+			s.log(c).Warn(err)
+			return
+		}
+
+		ttl := s.config.CacheTTL
+		if expiresAt, ok := c.Get("cacheExpiresAt"); ok {
+			untilDue := time.Until(expiresAt.(time.Time))
+			if untilDue <= 0 {
+				return
 			}
+			if ttl <= 0 || untilDue < ttl {
+				ttl = untilDue
+			}
+		}
+
+		if err := s.cache.Set(c.Request.Context(), key, string(value), ttl); err != nil {
+			s.log(c).Warn(err)
+		}
+	}
+}
+
+// expireCacheAtDue keeps CacheMiddleware from caching a response with the
+// given tasks past the next due date among them, when the overdue flag
+// embedded in the response changes.
+func expireCacheAtDue(ctx *gin.Context, tasks []models.Task) {
+	now := time.Now()
+	expiresAt, ok := ctx.Value("cacheExpiresAt").(time.Time)
+	for i := range tasks {
+		due := tasks[i].DueAt
+		if due == nil || tasks[i].Status.Finished() || !due.After(now) {
+			continue
+		}
+		if !ok || due.Before(expiresAt) {
+			expiresAt, ok = *due, true
 		}
 	}
+
+	if ok {
+		ctx.Set("cacheExpiresAt", expiresAt)
+	}
+}
+
+// taskCacheKey builds the cache key of a response. It embeds the user's
+// current cache generation, so bumping the generation drops every cached
+// response of that user at once.
+func (s *APIServer) taskCacheKey(ctx context.Context, userID int, uri string) string {
+	generation, err := s.cache.Get(ctx, taskCacheGenerationKey(userID))
+	if err != nil {
+		// The generation was never set or has been evicted. Going back
+		// to a fixed one would serve the responses cached under it
+		// before, so a new one is started.
+		generation = s.newTaskCacheGeneration(ctx, userID)
+	}
+
+	return fmt.Sprintf("cache:tasks:%d:%s:%s", userID, generation, uri)
+}
+
+// invalidateTasks drops the cached task responses of a user. It runs
+// after a change and so is not cut short if the client goes away.
+func (s *APIServer) invalidateTasks(ctx context.Context, userID int) {
+	if s.cache == nil || !s.config.Caching {
+		return
+	}
+	s.newTaskCacheGeneration(context.WithoutCancel(ctx), userID)
+}
+
+// newTaskCacheGeneration starts a new cache generation for a user and
+// returns it.
+func (s *APIServer) newTaskCacheGeneration(ctx context.Context, userID int) string {
+	generation := strconv.FormatInt(time.Now().UnixNano(), 10)
+	if err := s.cache.Set(ctx, taskCacheGenerationKey(userID), generation, 0); err != nil {
+		s.log(ctx).Warn("Error starting cache generation: ", err)
+	}
+	return generation
+}
+
+func taskCacheGenerationKey(userID int) string {
+	return fmt.Sprintf("cache:tasks:%d:generation", userID)
+}
+
+// isTaskCacheGenerationKey tells whether key is the cache generation of
+// a user.
+func isTaskCacheGenerationKey(key string) bool {
+	return strings.HasPrefix(key, "cache:tasks:") && strings.HasSuffix(key, ":generation")
 }
@@ -0,0 +1,113 @@
+package apiserver
+
+import (
+	"TaskManager/internal/cache/lru"
+	"TaskManager/internal/models"
+	"context"
+	"encoding/json"
+	"net/http"
+	"testing"
+)
+
+// newCachingTestServer returns a test server caching responses in an LRU
+// cache, which it also returns.
+func newCachingTestServer(t *testing.T) (*APIServer, *lru.LRU) {
+	t.Helper()
+
+	cache := lru.New(lru.NewConfig())
+	s := newTestServer(t, func(s *APIServer) {
+		s.config.Caching = true
+		if err := s.UseCache(cache); err != nil {
+			t.Fatal(err)
+		}
+	})
+	return s, cache
+}
+
+// listTasks lists the user's tasks and returns them with the X-Cache
+// header of the response.
+func listTasks(t *testing.T, s *APIServer, token string) ([]models.Task, string) {
+	t.Helper()
+
+	w := do(t, s, http.MethodGet, "/tasks", token, nil)
+	if w.Code != http.StatusOK {
+		t.Fatalf("list: status %d: %s", w.Code, w.Body)
+	}
+	var page models.TaskPage
+	if err := json.Unmarshal(w.Body.Bytes(), &page); err != nil {
+		t.Fatal(err)
+	}
+	return page.Tasks, w.Header().Get("X-Cache")
+}
+
+func TestCacheHitAndMiss(t *testing.T) {
+	s, _ := newCachingTestServer(t)
+	alice := register(t, s, "alice")
+	bob := register(t, s, "bob")
+	createTask(t, s, alice, "Cached")
+
+	if _, status := listTasks(t, s, alice); status != "MISS" {
+		t.Errorf("first request: X-Cache %q, want MISS", status)
+	}
+	tasks, status := listTasks(t, s, alice)
+	if status != "HIT" {
+		t.Errorf("repeated request: X-Cache %q, want HIT", status)
+	}
+	if len(tasks) != 1 || tasks[0].Title != "Cached" {
+		t.Errorf("cached tasks = %+v", tasks)
+	}
+
+	// Responses are cached per user.
+	tasks, status = listTasks(t, s, bob)
+	if status != "MISS" || len(tasks) != 0 {
+		t.Errorf("other user: X-Cache %q with %d tasks, want MISS with none", status, len(tasks))
+	}
+}
+
+func TestCacheInvalidation(t *testing.T) {
+	s, _ := newCachingTestServer(t)
+	token := register(t, s, "alice")
+
+	listTasks(t, s, token)
+	if _, status := listTasks(t, s, token); status != "HIT" {
+		t.Fatalf("X-Cache %q, want HIT", status)
+	}
+
+	task := createTask(t, s, token, "New")
+	tasks, status := listTasks(t, s, token)
+	if status != "MISS" || len(tasks) != 1 {
+		t.Errorf("after create: X-Cache %q with %d tasks, want MISS with 1", status, len(tasks))
+	}
+
+	if w := do(t, s, http.MethodDelete, taskPath(task.ID), token, nil); w.Code != http.StatusOK {
+		t.Fatalf("delete: status %d", w.Code)
+	}
+	tasks, status = listTasks(t, s, token)
+	if status != "MISS" || len(tasks) != 0 {
+		t.Errorf("after delete: X-Cache %q with %d tasks, want MISS with none", status, len(tasks))
+	}
+}
+
+func TestCacheGenerationEvicted(t *testing.T) {
+	s, cache := newCachingTestServer(t)
+	token := register(t, s, "alice")
+	userID := 1
+
+	// Cached before the first invalidation, without the task.
+	listTasks(t, s, token)
+	createTask(t, s, token, "New")
+	listTasks(t, s, token)
+
+	// Losing the generation must not bring back the responses cached
+	// under an earlier one.
+	if err := cache.Del(context.Background(), taskCacheGenerationKey(userID)); err != nil {
+		t.Fatal(err)
+	}
+	tasks, status := listTasks(t, s, token)
+	if status != "MISS" || len(tasks) != 1 {
+		t.Errorf("after eviction: X-Cache %q with %d tasks, want MISS with 1", status, len(tasks))
+	}
+	if _, status := listTasks(t, s, token); status != "HIT" {
+		t.Errorf("after eviction, repeated: X-Cache %q, want HIT", status)
+	}
+}
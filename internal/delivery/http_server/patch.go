@@ -0,0 +1,193 @@
+package apiserver
+
+import (
+	"TaskManager/internal/models"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+const (
+	mergePatchContentType = "application/merge-patch+json"
+	jsonPatchContentType  = "application/json-patch+json"
+)
+
+// patchableTaskFields are the task fields a PATCH request may change.
+var patchableTaskFields = map[string]bool{
+	"title":         true,
+	"description":   true,
+	"scheduled_for": true,
+	"due_at":        true,
+	"priority":      true,
+	"parent_id":     true,
+	"status":        true,
+}
+
+// applyMergePatch applies an RFC 7396 merge patch to the top-level members
+// of doc. Tasks have no nested objects, so members are replaced whole.
+func applyMergePatch(doc map[string]json.RawMessage, patch []byte) error {
+	var members map[string]json.RawMessage
+	if err := json.Unmarshal(patch, &members); err != nil {
+		return errors.New("merge patch must be a JSON object")
+	}
+
+	for name, value := range members {
+		if isJSONNull(value) {
+			delete(doc, name)
+		} else {
+			doc[name] = value
+		}
+	}
+
+	return nil
+}
+
+type jsonPatchOp struct {
+	Op    string          `json:"op"`
+	Path  string          `json:"path"`
+	Value json.RawMessage `json:"value"`
+}
+
+// applyJSONPatch applies an RFC 6902 JSON patch to doc. Only the add,
+// replace, remove and test operations on top-level members are supported.
+func applyJSONPatch(doc map[string]json.RawMessage, patch []byte) error {
+	var ops []jsonPatchOp
+	if err := json.Unmarshal(patch, &ops); err != nil {
+		return errors.New("JSON patch must be an array of operations")
+	}
+
+	for i, op := range ops {
+		name, ok := strings.CutPrefix(op.Path, "/")
+		if !ok || name == "" || strings.Contains(name, "/") {
+			return fmt.Errorf("operation %d: unsupported path %q", i, op.Path)
+		}
+
+		switch op.Op {
+		case "add", "replace":
+			if op.Value == nil {
+				return fmt.Errorf("operation %d: missing value", i)
+			}
+			if _, exists := doc[name]; op.Op == "replace" && !exists {
+				return fmt.Errorf("operation %d: path %q does not exist", i, op.Path)
+			}
+			doc[name] = op.Value
+		case "remove":
+			if _, exists := doc[name]; !exists {
+				return fmt.Errorf("operation %d: path %q does not exist", i, op.Path)
+			}
+			delete(doc, name)
+		case "test":
+			if !jsonEqual(doc[name], op.Value) {
+				return fmt.Errorf("operation %d: test of %q failed", i, op.Path)
+			}
+		default:
+			return fmt.Errorf("operation %d: unsupported op %q", i, op.Op)
+		}
+	}
+
+	return nil
+}
+
+// taskPatch compares the patched document with the current task and
+// returns the changes. Changing a read-only field is an error.
+func taskPatch(current *models.Task, doc map[string]json.RawMessage) (*models.TaskPatch, error) {
+	currentDoc, err := taskDocument(current)
+	if err != nil {
+		return nil, err
+	}
+
+	for name := range currentDoc {
+		if !patchableTaskFields[name] && !jsonEqual(currentDoc[name], doc[name]) {
+			return nil, fmt.Errorf("%s is read-only", name)
+		}
+	}
+	for name := range doc {
+		// Optional fields such as due_at are missing from the document
+		// while unset, yet may be added.
+		if _, known := currentDoc[name]; !known && !patchableTaskFields[name] {
+			return nil, fmt.Errorf("unknown field %s", name)
+		}
+	}
+
+	if _, ok := doc["priority"]; !ok {
+		return nil, errors.New("priority cannot be removed")
+	}
+
+	raw, err := json.Marshal(doc)
+	if err != nil {
+		return nil, err
+	}
+
+	var next models.Task
+	if err := json.Unmarshal(raw, &next); err != nil {
+		return nil, errors.New("invalid task data")
+	}
+
+	patch := &models.TaskPatch{}
+	if next.Title != current.Title {
+		if next.Title == "" {
+			return nil, errors.New("title must not be empty")
+		}
+		patch.Title = &next.Title
+	}
+	if next.Description != current.Description {
+		patch.Description = &next.Description
+	}
+	if !next.ScheduledFor.Equal(current.ScheduledFor) {
+		patch.ScheduledFor = &next.ScheduledFor
+	}
+	switch {
+	case next.DueAt == nil && current.DueAt != nil:
+		patch.ClearDueAt = true
+	case next.DueAt != nil && (current.DueAt == nil || !next.DueAt.Equal(*current.DueAt)):
+		patch.DueAt = next.DueAt
+	}
+	if next.Priority != current.Priority {
+		patch.Priority = &next.Priority
+	}
+	switch {
+	case next.ParentID == nil && current.ParentID != nil:
+		patch.ClearParent = true
+	case next.ParentID != nil && (current.ParentID == nil || *next.ParentID != *current.ParentID):
+		patch.ParentID = next.ParentID
+	}
+	if next.Status != current.Status {
+		patch.Status = &next.Status
+	}
+
+	return patch, nil
+}
+
+// taskDocument returns the JSON members of a task.
+func taskDocument(task *models.Task) (map[string]json.RawMessage, error) {
+	raw, err := json.Marshal(task)
+	if err != nil {
+		return nil, err
+	}
+
+	var doc map[string]json.RawMessage
+	err = json.Unmarshal(raw, &doc)
+	return doc, err
+}
+
+func isJSONNull(value json.RawMessage) bool {
+	return bytes.Equal(bytes.TrimSpace(value), []byte("null"))
+}
+
+// jsonEqual compares two JSON values semantically.
+func jsonEqual(a, b json.RawMessage) bool {
+	if a == nil || b == nil {
+		return a == nil && b == nil
+	}
+
+	var va, vb interface{}
+	if json.Unmarshal(a, &va) != nil || json.Unmarshal(b, &vb) != nil {
+		return false
+	}
+
+	ca, _ := json.Marshal(va)
+	cb, _ := json.Marshal(vb)
+	return bytes.Equal(ca, cb)
+}
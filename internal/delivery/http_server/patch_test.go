@@ -0,0 +1,230 @@
+package apiserver
+
+import (
+	"TaskManager/internal/models"
+	"encoding/json"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+)
+
+// document parses a JSON object into the form the patch functions work on.
+func document(t *testing.T, s string) map[string]json.RawMessage {
+	t.Helper()
+
+	var doc map[string]json.RawMessage
+	if err := json.Unmarshal([]byte(s), &doc); err != nil {
+		t.Fatal(err)
+	}
+	return doc
+}
+
+func encodeDocument(t *testing.T, doc map[string]json.RawMessage) string {
+	t.Helper()
+
+	raw, err := json.Marshal(doc)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(raw)
+}
+
+func TestApplyMergePatch(t *testing.T) {
+	tests := []struct {
+		patch string
+		want  string
+	}{
+		{`{}`, `{"a":1,"b":"x","c":[1]}`},
+		{`{"a":2}`, `{"a":2,"b":"x","c":[1]}`},
+		{`{"b":null}`, `{"a":1,"c":[1]}`},
+		{`{"d":{"e":1}}`, `{"a":1,"b":"x","c":[1],"d":{"e":1}}`},
+		{`{"c":[2,3], "missing":null}`, `{"a":1,"b":"x","c":[2,3]}`},
+	}
+	for _, tt := range tests {
+		doc := document(t, `{"a":1,"b":"x","c":[1]}`)
+		if err := applyMergePatch(doc, []byte(tt.patch)); err != nil {
+			t.Errorf("merge %s: %v", tt.patch, err)
+			continue
+		}
+		if got := encodeDocument(t, doc); got != tt.want {
+			t.Errorf("merge %s = %s, want %s", tt.patch, got, tt.want)
+		}
+	}
+
+	for _, patch := range []string{`[]`, `"a"`, `1`, `{`} {
+		if err := applyMergePatch(document(t, `{}`), []byte(patch)); err == nil {
+			t.Errorf("merge %s succeeded", patch)
+		}
+	}
+}
+
+func TestApplyJSONPatch(t *testing.T) {
+	tests := []struct {
+		patch string
+		want  string
+	}{
+		{`[]`, `{"a":1,"b":"x"}`},
+		{`[{"op":"replace","path":"/a","value":2}]`, `{"a":2,"b":"x"}`},
+		{`[{"op":"add","path":"/c","value":null}]`, `{"a":1,"b":"x","c":null}`},
+		{`[{"op":"add","path":"/a","value":3}]`, `{"a":3,"b":"x"}`},
+		{`[{"op":"remove","path":"/b"}]`, `{"a":1}`},
+		{`[{"op":"test","path":"/a","value":1.0},{"op":"replace","path":"/b","value":"y"}]`, `{"a":1,"b":"y"}`},
+	}
+	for _, tt := range tests {
+		doc := document(t, `{"a":1,"b":"x"}`)
+		if err := applyJSONPatch(doc, []byte(tt.patch)); err != nil {
+			t.Errorf("patch %s: %v", tt.patch, err)
+			continue
+		}
+		if got := encodeDocument(t, doc); got != tt.want {
+			t.Errorf("patch %s = %s, want %s", tt.patch, got, tt.want)
+		}
+	}
+
+	for _, patch := range []string{
+		`{"op":"replace","path":"/a","value":2}`,
+		`[{"op":"replace","path":"/missing","value":2}]`,
+		`[{"op":"remove","path":"/missing"}]`,
+		`[{"op":"replace","path":"/a"}]`,
+		`[{"op":"test","path":"/a","value":2}]`,
+		`[{"op":"move","from":"/a","path":"/c"}]`,
+		`[{"op":"add","path":"a","value":2}]`,
+		`[{"op":"add","path":"/","value":2}]`,
+		`[{"op":"add","path":"/a/b","value":2}]`,
+	} {
+		doc := document(t, `{"a":1,"b":"x"}`)
+		if err := applyJSONPatch(doc, []byte(patch)); err == nil {
+			t.Errorf("patch %s succeeded", patch)
+		}
+	}
+}
+
+func TestTaskPatch(t *testing.T) {
+	scheduled := time.Date(2030, 1, 1, 9, 0, 0, 0, time.UTC)
+	due := scheduled.Add(24 * time.Hour)
+	parent := 3
+	current := &models.Task{
+		ID: 7, Title: "Old", Description: "old", ScheduledFor: scheduled, DueAt: &due,
+		Priority: models.PriorityP2, Status: models.StatusTodo, ParentID: &parent, Version: 4, UserID: 1,
+	}
+
+	patched := func(merge string) (*models.TaskPatch, error) {
+		doc, err := taskDocument(current)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := applyMergePatch(doc, []byte(merge)); err != nil {
+			t.Fatal(err)
+		}
+		return taskPatch(current, doc)
+	}
+
+	patch, err := patched(`{}`)
+	if err != nil || !patch.Empty() {
+		t.Errorf("unchanged document: patch %+v, %v, want an empty patch", patch, err)
+	}
+
+	later := scheduled.Add(time.Hour)
+	patch, err = patched(`{"title":"New","priority":"P0","scheduled_for":"` + later.Format(time.RFC3339) + `","due_at":null,"parent_id":null,"status":"done"}`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if patch.Title == nil || *patch.Title != "New" ||
+		patch.Priority == nil || *patch.Priority != models.PriorityP0 ||
+		patch.ScheduledFor == nil || !patch.ScheduledFor.Equal(later) ||
+		!patch.ClearDueAt || patch.DueAt != nil ||
+		!patch.ClearParent || patch.ParentID != nil ||
+		patch.Status == nil || *patch.Status != models.StatusDone ||
+		patch.Description != nil {
+		t.Errorf("patch = %+v", patch)
+	}
+
+	// The same instant in another zone is no change.
+	patch, err = patched(`{"due_at":"` + due.In(time.FixedZone("", 3600)).Format(time.RFC3339) + `","parent_id":4}`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if patch.DueAt != nil || patch.ClearDueAt || patch.ParentID == nil || *patch.ParentID != 4 {
+		t.Errorf("patch = %+v, want only the parent changed", patch)
+	}
+
+	for _, merge := range []string{
+		`{"id":8}`,
+		`{"version":1}`,
+		`{"user_id":2}`,
+		`{"created_at":"2020-01-01T00:00:00Z"}`,
+		`{"tags":[{"id":1,"name":"home"}]}`,
+		`{"colour":"red"}`,
+		`{"priority":null}`,
+		`{"priority":"P9"}`,
+		`{"title":""}`,
+		`{"title":5}`,
+	} {
+		if _, err := patched(merge); err == nil {
+			t.Errorf("merge %s: taskPatch succeeded", merge)
+		}
+	}
+
+	// Read-only fields may be repeated unchanged, as a client sending the
+	// whole task back would.
+	if _, err := patched(`{"id":7,"version":4,"tags":[],"title":"Renamed"}`); err != nil {
+		t.Errorf("unchanged read-only fields: %v", err)
+	}
+}
+
+func TestPatchTaskContentTypes(t *testing.T) {
+	s := newTestServer(t)
+	token := register(t, s, "alice")
+	task := createTask(t, s, token, "Patchable")
+
+	patch := func(contentType string, body interface{}) (int, models.Task) {
+		t.Helper()
+
+		w := doWith(t, s, http.MethodPatch, taskPath(task.ID), token, body, http.Header{"Content-Type": {contentType}})
+		var patched models.Task
+		if w.Code == http.StatusOK {
+			if err := json.Unmarshal(w.Body.Bytes(), &patched); err != nil {
+				t.Fatal(err)
+			}
+		}
+		return w.Code, patched
+	}
+
+	code, patched := patch(mergePatchContentType, map[string]interface{}{"description": "merged", "due_at": "2030-01-02T09:00:00Z"})
+	if code != http.StatusOK || patched.Description != "merged" || patched.DueAt == nil || patched.Title != "Patchable" {
+		t.Errorf("merge patch: status %d, task %+v", code, patched)
+	}
+
+	code, patched = patch(jsonPatchContentType, []map[string]interface{}{
+		{"op": "test", "path": "/description", "value": "merged"},
+		{"op": "replace", "path": "/title", "value": "Patched"},
+		{"op": "remove", "path": "/due_at"},
+	})
+	if code != http.StatusOK || patched.Title != "Patched" || patched.DueAt != nil || patched.Description != "merged" {
+		t.Errorf("JSON patch: status %d, task %+v", code, patched)
+	}
+
+	code, _ = patch(jsonPatchContentType, []map[string]interface{}{
+		{"op": "replace", "path": "/title", "value": "Lost"},
+		{"op": "test", "path": "/description", "value": "other"},
+	})
+	if code != http.StatusBadRequest {
+		t.Errorf("JSON patch with a failing test: status %d, want 400", code)
+	}
+	if stored := getTask(t, s, token, task.ID); stored.Title != "Patched" {
+		t.Errorf("failed JSON patch changed the title to %q", stored.Title)
+	}
+
+	if code, _ = patch("text/plain", map[string]string{"title": "x"}); code != http.StatusUnsupportedMediaType {
+		t.Errorf("text/plain patch: status %d, want 415", code)
+	}
+	if code, _ = patch(mergePatchContentType, map[string]int{"version": 99}); code != http.StatusBadRequest {
+		t.Errorf("patch of a read-only field: status %d, want 400", code)
+	}
+
+	w := doWith(t, s, http.MethodPatch, taskPath(task.ID), token, nil, http.Header{"Content-Type": {mergePatchContentType}})
+	if w.Code != http.StatusBadRequest || !strings.Contains(w.Body.String(), "JSON object") {
+		t.Errorf("empty merge patch: status %d: %s", w.Code, w.Body)
+	}
+}
@@ -0,0 +1,159 @@
+package apiserver
+
+import (
+	"TaskManager/internal/models"
+	"context"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// @Summary Handling updating an occurrence
+// @Description Handling the request to change a single occurrence of a recurring task. The series and its other occurrences are left as they are; a rescheduled occurrence keeps its due date offset.
+// @Accept json
+// @Produce json
+// @Param id path int true "Task ID"
+// @Param input body OccurrencePatchRequest true "Fields to change"
+// @Success 200 {object} models.Task "Updated occurrence"
+// @Failure 400,401,404,500 {object} ErrorResponse "Error response with details"
+// @Router /tasks/{id}/occurrence [patch]
+func (s *APIServer) handlePatchOccurrence(ctx *gin.Context) {
+	userID, taskID, ok := taskParams(ctx)
+	if !ok {
+		return
+	}
+
+	req, ok := bindOccurrencePatch(ctx)
+	if !ok {
+		return
+	}
+	if req.RRule != nil {
+		ctx.JSON(http.StatusBadRequest, ErrorResponse{"The rule can only be changed for this and following occurrences"})
+		return
+	}
+
+	current, err := s.storage.GetTaskByID(ctx.Request.Context(), userID, taskID)
+	if err != nil {
+		s.respondTaskError(ctx, "Error updating occurrence: ", err, "Failed to update occurrence")
+		return
+	}
+	if current.SeriesID == nil {
+		s.respondTaskError(ctx, "Error updating occurrence: ", models.ErrNotRecurring, "Failed to update occurrence")
+		return
+	}
+
+	patch := &models.TaskPatch{
+		Title:        req.Title,
+		Description:  req.Description,
+		Priority:     req.Priority,
+		ScheduledFor: req.ScheduledFor,
+	}
+	if req.ScheduledFor != nil && current.DueAt != nil {
+		due := current.DueAt.Add(req.ScheduledFor.Sub(current.ScheduledFor))
+		patch.DueAt = &due
+	}
+
+	task := current
+	if !patch.Empty() {
+		if task, err = s.storage.PatchTask(ctx.Request.Context(), userID, taskID, patch, 0); err != nil {
+			s.respondTaskError(ctx, "Error updating occurrence: ", err, "Failed to update occurrence")
+			return
+		}
+		s.invalidateTasks(ctx.Request.Context(), userID)
+		if err := s.publishUpdate(ctx.Request.Context(), userID, current.Status, task); err != nil {
+			s.respondEventError(ctx, err)
+			return
+		}
+	}
+
+	ctx.Header("ETag", taskETag(task))
+	ctx.JSON(http.StatusOK, task)
+}
+
+// @Summary Handling updating following occurrences
+// @Description Handling the request to change an occurrence of a recurring task together with the unfinished occurrences after it, which become a series of their own. Changing the rule or the time recreates the later occurrences; an empty rule stops the series, and a rule on a task that does not recur yet makes it recurring.
+// @Accept json
+// @Produce json
+// @Param id path int true "Task ID"
+// @Param input body OccurrencePatchRequest true "Fields to change"
+// @Success 200 {object} models.Task "Updated occurrence"
+// @Failure 400,401,404,500 {object} ErrorResponse "Error response with details"
+// @Router /tasks/{id}/following [patch]
+func (s *APIServer) handlePatchFollowing(ctx *gin.Context) {
+	userID, taskID, ok := taskParams(ctx)
+	if !ok {
+		return
+	}
+
+	req, ok := bindOccurrencePatch(ctx)
+	if !ok {
+		return
+	}
+
+	task, err := s.storage.UpdateFollowingOccurrences(ctx.Request.Context(), userID, taskID, &models.SeriesPatch{
+		Title:        req.Title,
+		Description:  req.Description,
+		Priority:     req.Priority,
+		ScheduledFor: req.ScheduledFor,
+		RRule:        req.RRule,
+	})
+	if err != nil {
+		s.respondTaskError(ctx, "Error updating occurrences: ", err, "Failed to update occurrences")
+		return
+	}
+	s.extendSeries(ctx.Request.Context(), userID, task)
+	s.invalidateTasks(ctx.Request.Context(), userID)
+	if err := s.publishUpdate(ctx.Request.Context(), userID, task.Status, task); err != nil {
+		s.respondEventError(ctx, err)
+		return
+	}
+
+	ctx.Header("ETag", taskETag(task))
+	ctx.JSON(http.StatusOK, task)
+}
+
+// bindOccurrencePatch reads an OccurrencePatchRequest and normalises its
+// rule, writing a 400 response if the request is invalid.
+func bindOccurrencePatch(ctx *gin.Context) (*OccurrencePatchRequest, bool) {
+	var req OccurrencePatchRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, ErrorResponse{"Invalid occurrence data"})
+		return nil, false
+	}
+
+	if req.Title != nil && *req.Title == "" {
+		ctx.JSON(http.StatusBadRequest, ErrorResponse{"Title must not be empty"})
+		return nil, false
+	}
+
+	if req.RRule != nil && *req.RRule != "" {
+		rule, err := models.NormalizeRRule(*req.RRule)
+		if err != nil {
+			ctx.JSON(http.StatusBadRequest, ErrorResponse{err.Error()})
+			return nil, false
+		}
+		req.RRule = &rule
+	}
+
+	return &req, true
+}
+
+// extendSeries creates the upcoming occurrences of the series task belongs
+// to and publishes them. A failure is logged and does not undo the change
+// that triggered it; the scheduler creates the occurrences later.
+func (s *APIServer) extendSeries(ctx context.Context, userID int, task *models.Task) {
+	if task.SeriesID == nil {
+		return
+	}
+
+	until := time.Now().Add(s.config.RecurrenceHorizon)
+	created, err := s.storage.MaterializeOccurrences(ctx, userID, *task.SeriesID, until)
+	if err != nil {
+		s.log(ctx).Error("Error creating occurrences: ", err)
+		return
+	}
+	for i := range created {
+		s.publishOccurrence(ctx, &created[i])
+	}
+}
@@ -1,5 +1,10 @@
 package apiserver
 
+import (
+	"TaskManager/internal/models"
+	"time"
+)
+
 // StatusResponse represents a successful API response with a message.
 // @Summary Successful API response with a message
 // @Description Successful API response with a message.
@@ -18,12 +23,122 @@ type ErrorResponse struct {
 	Error string `json:"error"`
 }
 
-// TokenResponse represents an API response with an access token.
+// TokenResponse represents an API response with an access token and the
+// refresh token used to obtain the next one.
 // @Summary API response with an access token
-// @Description API response with an access token.
+// @Description API response with an access token, a refresh token and the access token lifetime in seconds.
 // @Produce json
 // @Param token body TokenResponse true "Access token"
 // @Success 200 {object} TokenResponse "Access token"
 type TokenResponse struct {
-	Token string `token:"token"`
+	Token        string `json:"token"`
+	RefreshToken string `json:"refresh_token"`
+	ExpiresIn    int    `json:"expires_in"`
+}
+
+// TransitionRequest represents a request to move a task to another status.
+// @Summary Task status transition request
+// @Description Target status of a task lifecycle transition.
+// @Accept json
+// @Param status body string true "Target status"
+type TransitionRequest struct {
+	Status models.TaskStatus `json:"status" binding:"required"`
+}
+
+// RefreshRequest represents a request carrying a refresh token.
+// @Summary Refresh token request
+// @Description Refresh token to rotate or revoke.
+// @Accept json
+// @Param refresh_token body string true "Refresh token"
+type RefreshRequest struct {
+	RefreshToken string `json:"refresh_token"`
+}
+
+// TagRequest represents a request to create or rename a tag.
+// @Summary Tag request
+// @Description Name of a tag to create or rename to.
+// @Accept json
+// @Param name body string true "Tag name, 1 to 64 characters"
+type TagRequest struct {
+	Name string `json:"name" binding:"required"`
+}
+
+// ChecklistItemRequest represents a request to add a checklist item.
+// @Summary Checklist item request
+// @Description Text of a new checklist item.
+// @Accept json
+// @Param text body string true "Item text, 1 to 255 characters"
+type ChecklistItemRequest struct {
+	Text string `json:"text" binding:"required"`
+}
+
+// ChecklistItemPatchRequest represents a request to change a checklist item.
+// @Summary Checklist item update request
+// @Description Checklist item fields to change; omitted fields are kept.
+// @Accept json
+// @Param text body string false "New item text"
+// @Param done body bool false "Whether the item is done"
+type ChecklistItemPatchRequest struct {
+	Text *string `json:"text"`
+	Done *bool   `json:"done"`
+}
+
+// ChecklistOrderRequest represents a request to reorder a checklist.
+// @Summary Checklist order request
+// @Description IDs of every checklist item of the task in the new order.
+// @Accept json
+// @Param item_ids body []int true "Checklist item IDs"
+type ChecklistOrderRequest struct {
+	ItemIDs []int `json:"item_ids" binding:"required"`
+}
+
+// OccurrencePatchRequest represents a request to change occurrences of a
+// recurring task.
+// @Summary Occurrence update request
+// @Description Fields to change on an occurrence, or on it and the occurrences following it; omitted fields are kept.
+// @Accept json
+// @Param title body string false "New title"
+// @Param description body string false "New description"
+// @Param priority body string false "New priority, P0 to P4"
+// @Param scheduled_for body string false "New time in RFC3339 format"
+// @Param rrule body string false "New RFC 5545 RRULE, or an empty string to stop repeating (following occurrences only)"
+type OccurrencePatchRequest struct {
+	Title        *string              `json:"title"`
+	Description  *string              `json:"description"`
+	Priority     *models.TaskPriority `json:"priority" swaggertype:"string" enums:"P0,P1,P2,P3,P4"`
+	ScheduledFor *time.Time           `json:"scheduled_for"`
+	RRule        *string              `json:"rrule"`
+}
+
+// WebhookRequest represents a request to create or change a webhook.
+// @Summary Webhook request
+// @Description Webhook URL, secret and the events it receives.
+// @Accept json
+// @Param url body string true "Absolute http or https URL events are posted to"
+// @Param secret body string false "Key the payloads are signed with; generated on create if empty, kept on update if empty"
+// @Param events body []string false "Events to deliver: task.created, task.updated, task.deleted, task.completed or task.reminder; empty for all"
+type WebhookRequest struct {
+	URL    string             `json:"url" binding:"required"`
+	Secret string             `json:"secret"`
+	Events []models.EventType `json:"events" swaggertype:"array,string"`
+}
+
+// HealthResponse represents the result of a health or readiness probe.
+// @Summary Probe response
+// @Description Overall status, "ok" or "unavailable", and the status of each dependency checked.
+// @Produce json
+// @Success 200 {object} HealthResponse "Probe result"
+type HealthResponse struct {
+	Status string                      `json:"status"`
+	Checks map[string]DependencyStatus `json:"checks,omitempty"`
+}
+
+// DependencyStatus represents the check of a single dependency.
+// @Summary Dependency check
+// @Description Whether a dependency answered, "up" or "down", how long the check took in milliseconds, and the error if it failed.
+// @Produce json
+type DependencyStatus struct {
+	Status    string  `json:"status"`
+	LatencyMS float64 `json:"latency_ms"`
+	Error     string  `json:"error,omitempty"`
 }
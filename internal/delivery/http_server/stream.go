@@ -0,0 +1,153 @@
+package apiserver
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gorilla/websocket"
+)
+
+const (
+	// streamKeepAlive is how often an idle stream is sent a keep-alive,
+	// so that proxies do not close it.
+	streamKeepAlive = 30 * time.Second
+	// streamWriteTimeout bounds a single write to a WebSocket.
+	streamWriteTimeout = 10 * time.Second
+)
+
+var upgrader = websocket.Upgrader{
+	ReadBufferSize:  1024,
+	WriteBufferSize: 1024,
+}
+
+// @Summary Handling the task event stream
+// @Description Handling the request to receive the task events of the authenticated user as Server-Sent Events. Every event is a JSON object as delivered to webhooks. Events are not replayed, so clients reload the tasks when they (re)connect. The stream ends when the access token expires or the server shuts down; as EventSource cannot set headers, the token may be passed in the access_token query parameter.
+// @Produce text/event-stream
+// @Param access_token query string false "Access token, instead of the Authorization header"
+// @Success 200 {object} models.Event "Stream of task events"
+// @Failure 400,401,500 {object} ErrorResponse "Error response with details"
+// @Router /tasks/stream [get]
+func (s *APIServer) handleTaskStream(ctx *gin.Context) {
+	userID, ok := userIDFromContext(ctx)
+	if !ok {
+		ctx.JSON(http.StatusBadRequest, ErrorResponse{"Wrong user id"})
+		return
+	}
+
+	events, cancel := s.broker.Subscribe(userID)
+	defer cancel()
+
+	expired := time.NewTimer(time.Until(tokenExpiresAt(ctx)))
+	defer expired.Stop()
+	keepAlive := time.NewTicker(streamKeepAlive)
+	defer keepAlive.Stop()
+
+	ctx.Header("Content-Type", "text/event-stream")
+	ctx.Header("Cache-Control", "no-cache")
+	ctx.Header("X-Accel-Buffering", "no")
+	ctx.Writer.WriteHeaderNow()
+	ctx.Writer.Flush()
+
+	ctx.Stream(func(w io.Writer) bool {
+		select {
+		case payload, ok := <-events:
+			if !ok {
+				return false
+			}
+			ctx.SSEvent("message", string(payload))
+			return true
+		case <-keepAlive.C:
+			fmt.Fprint(w, ": keep-alive\n\n")
+			return true
+		case <-expired.C:
+			return false
+		case <-s.closing:
+			return false
+		case <-ctx.Request.Context().Done():
+			return false
+		}
+	})
+}
+
+// @Summary Handling the task event WebSocket
+// @Description Handling the request to receive the task events of the authenticated user over a WebSocket. Every event is a text message with a JSON object as delivered to webhooks; messages from the client are ignored. Events are not replayed, so clients reload the tasks when they (re)connect. The socket is closed when the access token expires or the server shuts down; as browsers cannot set headers on WebSockets, the token may be passed in the access_token query parameter.
+// @Param access_token query string false "Access token, instead of the Authorization header"
+// @Success 101 "Switching to the WebSocket protocol"
+// @Failure 400,401,500 {object} ErrorResponse "Error response with details"
+// @Router /tasks/ws [get]
+func (s *APIServer) handleTaskWebSocket(ctx *gin.Context) {
+	userID, ok := userIDFromContext(ctx)
+	if !ok {
+		ctx.JSON(http.StatusBadRequest, ErrorResponse{"Wrong user id"})
+		return
+	}
+
+	// Upgrade responds to the client itself if it fails.
+	conn, err := upgrader.Upgrade(ctx.Writer, ctx.Request, nil)
+	if err != nil {
+		s.log(ctx).Debug("Error upgrading to WebSocket: ", err)
+		return
+	}
+	defer conn.Close()
+
+	events, cancel := s.broker.Subscribe(userID)
+	defer cancel()
+
+	// Reading handles pings and close frames and tells when the client
+	// has gone away.
+	gone := make(chan struct{})
+	go func() {
+		defer close(gone)
+		for {
+			if _, _, err := conn.NextReader(); err != nil {
+				return
+			}
+		}
+	}()
+
+	expired := time.NewTimer(time.Until(tokenExpiresAt(ctx)))
+	defer expired.Stop()
+	keepAlive := time.NewTicker(streamKeepAlive)
+	defer keepAlive.Stop()
+
+	closeWith := func(code int, text string) {
+		conn.WriteControl(websocket.CloseMessage, websocket.FormatCloseMessage(code, text), time.Now().Add(streamWriteTimeout))
+	}
+
+	for {
+		select {
+		case payload, ok := <-events:
+			if !ok {
+				closeWith(websocket.CloseTryAgainLater, "too many pending events")
+				return
+			}
+			conn.SetWriteDeadline(time.Now().Add(streamWriteTimeout))
+			if err := conn.WriteMessage(websocket.TextMessage, payload); err != nil {
+				return
+			}
+		case <-keepAlive.C:
+			if err := conn.WriteControl(websocket.PingMessage, nil, time.Now().Add(streamWriteTimeout)); err != nil {
+				return
+			}
+		case <-expired.C:
+			closeWith(websocket.ClosePolicyViolation, "token expired")
+			return
+		case <-s.closing:
+			closeWith(websocket.CloseGoingAway, "server shutting down")
+			return
+		case <-gone:
+			return
+		}
+	}
+}
+
+// tokenExpiresAt returns the expiry of the access token the request was
+// authenticated with.
+func tokenExpiresAt(ctx *gin.Context) time.Time {
+	expiresAt, _ := ctx.Get("tokenExpiresAt")
+	t, _ := expiresAt.(time.Time)
+	return t
+}
@@ -0,0 +1,204 @@
+package apiserver
+
+import (
+	"TaskManager/internal/models"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+// @Summary Handling fetching subtasks
+// @Description Handling the request to list the direct subtasks of a task, oldest first
+// @Produce json
+// @Param id path int true "Task ID"
+// @Success 200 {array} models.Task "Subtasks"
+// @Failure 400,401,404,500 {object} ErrorResponse "Error response with details"
+// @Router /tasks/{id}/children [get]
+func (s *APIServer) handleGetSubtasks(ctx *gin.Context) {
+	userID, taskID, ok := taskParams(ctx)
+	if !ok {
+		return
+	}
+
+	subtasks, err := s.storage.GetSubtasks(ctx.Request.Context(), userID, taskID)
+	if err != nil {
+		s.respondTaskError(ctx, "Error fetching subtasks: ", err, "Failed to fetch subtasks")
+		return
+	}
+
+	expireCacheAtDue(ctx, subtasks)
+	ctx.JSON(http.StatusOK, subtasks)
+}
+
+// @Summary Handling adding a checklist item
+// @Description Handling the request to append an item to the checklist of a task
+// @Accept json
+// @Produce json
+// @Param id path int true "Task ID"
+// @Param input body ChecklistItemRequest true "Item text"
+// @Success 201 {object} models.ChecklistItem "Added item"
+// @Failure 400,401,404,500 {object} ErrorResponse "Error response with details"
+// @Router /tasks/{id}/checklist [post]
+func (s *APIServer) handleAddChecklistItem(ctx *gin.Context) {
+	userID, taskID, ok := taskParams(ctx)
+	if !ok {
+		return
+	}
+
+	var req ChecklistItemRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, ErrorResponse{"Invalid checklist item data"})
+		return
+	}
+
+	text, err := models.NormalizeChecklistItemText(req.Text)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, ErrorResponse{err.Error()})
+		return
+	}
+
+	item := models.ChecklistItem{Text: text}
+	if err := s.storage.AddChecklistItem(ctx.Request.Context(), userID, taskID, &item); err != nil {
+		s.respondTaskError(ctx, "Error adding checklist item: ", err, "Failed to add checklist item")
+		return
+	}
+	s.invalidateTasks(ctx.Request.Context(), userID)
+	if err := s.publishStoredTask(ctx.Request.Context(), userID, taskID); err != nil {
+		s.respondEventError(ctx, err)
+		return
+	}
+
+	ctx.JSON(http.StatusCreated, item)
+}
+
+// @Summary Handling updating a checklist item
+// @Description Handling the request to change the text of a checklist item or mark it done
+// @Accept json
+// @Produce json
+// @Param id path int true "Task ID"
+// @Param itemID path int true "Checklist item ID"
+// @Param input body ChecklistItemPatchRequest true "Fields to change"
+// @Success 200 {object} models.ChecklistItem "Updated item"
+// @Failure 400,401,404,500 {object} ErrorResponse "Error response with details"
+// @Router /tasks/{id}/checklist/{itemID} [patch]
+func (s *APIServer) handleUpdateChecklistItem(ctx *gin.Context) {
+	userID, taskID, itemID, ok := checklistItemParams(ctx)
+	if !ok {
+		return
+	}
+
+	var req ChecklistItemPatchRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil || (req.Text == nil && req.Done == nil) {
+		ctx.JSON(http.StatusBadRequest, ErrorResponse{"Invalid checklist item data"})
+		return
+	}
+
+	patch := &models.ChecklistItemPatch{Done: req.Done}
+	if req.Text != nil {
+		text, err := models.NormalizeChecklistItemText(*req.Text)
+		if err != nil {
+			ctx.JSON(http.StatusBadRequest, ErrorResponse{err.Error()})
+			return
+		}
+		patch.Text = &text
+	}
+
+	item, err := s.storage.UpdateChecklistItem(ctx.Request.Context(), userID, taskID, itemID, patch)
+	if err != nil {
+		s.respondTaskError(ctx, "Error updating checklist item: ", err, "Failed to update checklist item")
+		return
+	}
+	s.invalidateTasks(ctx.Request.Context(), userID)
+	if err := s.publishStoredTask(ctx.Request.Context(), userID, taskID); err != nil {
+		s.respondEventError(ctx, err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, item)
+}
+
+// @Summary Handling deleting a checklist item
+// @Description Handling the request to remove an item from the checklist of a task
+// @Produce json
+// @Param id path int true "Task ID"
+// @Param itemID path int true "Checklist item ID"
+// @Success 200 {object} StatusResponse "Checklist item deleted successfully"
+// @Failure 400,401,404,500 {object} ErrorResponse "Error response with details"
+// @Router /tasks/{id}/checklist/{itemID} [delete]
+func (s *APIServer) handleDeleteChecklistItem(ctx *gin.Context) {
+	userID, taskID, itemID, ok := checklistItemParams(ctx)
+	if !ok {
+		return
+	}
+
+	if err := s.storage.DeleteChecklistItem(ctx.Request.Context(), userID, taskID, itemID); err != nil {
+		s.respondTaskError(ctx, "Error deleting checklist item: ", err, "Failed to delete checklist item")
+		return
+	}
+	s.invalidateTasks(ctx.Request.Context(), userID)
+	if err := s.publishStoredTask(ctx.Request.Context(), userID, taskID); err != nil {
+		s.respondEventError(ctx, err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, StatusResponse{"Checklist item deleted successfully"})
+}
+
+// @Summary Handling reordering a checklist
+// @Description Handling the request to reorder the checklist of a task. The body must list every item of the checklist exactly once.
+// @Accept json
+// @Produce json
+// @Param id path int true "Task ID"
+// @Param input body ChecklistOrderRequest true "Item IDs in the new order"
+// @Success 200 {object} models.Task "Task with the reordered checklist"
+// @Failure 400,401,404,500 {object} ErrorResponse "Error response with details"
+// @Router /tasks/{id}/checklist/order [put]
+func (s *APIServer) handleReorderChecklist(ctx *gin.Context) {
+	userID, taskID, ok := taskParams(ctx)
+	if !ok {
+		return
+	}
+
+	var req ChecklistOrderRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, ErrorResponse{"Invalid checklist order"})
+		return
+	}
+
+	if err := s.storage.ReorderChecklist(ctx.Request.Context(), userID, taskID, req.ItemIDs); err != nil {
+		s.respondTaskError(ctx, "Error reordering checklist: ", err, "Failed to reorder checklist")
+		return
+	}
+	s.invalidateTasks(ctx.Request.Context(), userID)
+
+	task, err := s.storage.GetTaskByID(ctx.Request.Context(), userID, taskID)
+	if err != nil {
+		s.respondTaskError(ctx, "Error reordering checklist: ", err, "Failed to reorder checklist")
+		return
+	}
+	if err := s.publishTask(ctx.Request.Context(), userID, models.EventTaskUpdated, task); err != nil {
+		s.respondEventError(ctx, err)
+		return
+	}
+
+	ctx.Header("ETag", taskETag(task))
+	ctx.JSON(http.StatusOK, task)
+}
+
+// checklistItemParams returns the authenticated user, the task and the
+// checklist item of the request.
+func checklistItemParams(ctx *gin.Context) (int, int, int, bool) {
+	userID, taskID, ok := taskParams(ctx)
+	if !ok {
+		return 0, 0, 0, false
+	}
+
+	itemID, err := strconv.Atoi(ctx.Param("itemID"))
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, ErrorResponse{"Invalid checklist item ID"})
+		return 0, 0, 0, false
+	}
+
+	return userID, taskID, itemID, true
+}
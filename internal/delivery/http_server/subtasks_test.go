@@ -0,0 +1,150 @@
+package apiserver
+
+import (
+	"TaskManager/internal/models"
+	"encoding/json"
+	"net/http"
+	"testing"
+)
+
+// createSubtask creates a task under parentID and returns the response
+// status and, on success, the task.
+func createSubtask(t *testing.T, s *APIServer, token, title string, parentID int) (int, models.Task) {
+	t.Helper()
+
+	var task models.Task
+	w := do(t, s, http.MethodPost, "/tasks", token, map[string]interface{}{"title": title, "parent_id": parentID})
+	if w.Code == http.StatusOK {
+		if err := json.Unmarshal(w.Body.Bytes(), &task); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return w.Code, task
+}
+
+func TestSubtaskHierarchy(t *testing.T) {
+	t.Run("memory", func(t *testing.T) {
+		testSubtaskHierarchy(t, newTestServer(t))
+	})
+	t.Run("sqlite", func(t *testing.T) {
+		testSubtaskHierarchy(t, newTestServer(t, useSQLite(t)))
+	})
+}
+
+func testSubtaskHierarchy(t *testing.T, s *APIServer) {
+	token := register(t, s, "alice")
+
+	// A chain of MaxTaskDepth levels.
+	chain := []models.Task{createTask(t, s, token, "Level 1")}
+	for len(chain) < models.MaxTaskDepth {
+		code, task := createSubtask(t, s, token, "Deeper", chain[len(chain)-1].ID)
+		if code != http.StatusOK {
+			t.Fatalf("level %d: status %d, want 200", len(chain)+1, code)
+		}
+		chain = append(chain, task)
+	}
+	if code, _ := createSubtask(t, s, token, "Too deep", chain[len(chain)-1].ID); code != http.StatusConflict {
+		t.Errorf("level %d: status %d, want 409", models.MaxTaskDepth+1, code)
+	}
+
+	// A two-level subtree fits under level 3 but not under level 4.
+	subtree := createTask(t, s, token, "Subtree")
+	if code, _ := createSubtask(t, s, token, "Subtree leaf", subtree.ID); code != http.StatusOK {
+		t.Fatalf("subtree leaf: status %d", code)
+	}
+	move := func(taskID, parentID int) int {
+		return do(t, s, http.MethodPatch, taskPath(taskID), token, map[string]int{"parent_id": parentID}).Code
+	}
+	if code := move(subtree.ID, chain[3].ID); code != http.StatusConflict {
+		t.Errorf("subtree under level 4: status %d, want 409", code)
+	}
+	if code := move(subtree.ID, chain[2].ID); code != http.StatusOK {
+		t.Errorf("subtree under level 3: status %d, want 200", code)
+	}
+
+	// Cycles are rejected by PATCH and PUT alike.
+	for _, parent := range []models.Task{chain[0], chain[2]} {
+		if code := move(chain[0].ID, parent.ID); code != http.StatusConflict {
+			t.Errorf("level 1 under task %d: status %d, want 409", parent.ID, code)
+		}
+		w := do(t, s, http.MethodPut, taskPath(chain[0].ID), token, map[string]interface{}{"title": "Level 1", "parent_id": parent.ID})
+		if w.Code != http.StatusConflict {
+			t.Errorf("PUT level 1 under task %d: status %d, want 409", parent.ID, w.Code)
+		}
+	}
+
+	bob := register(t, s, "bob")
+	if code, _ := createSubtask(t, s, bob, "Foreign parent", chain[0].ID); code != http.StatusBadRequest {
+		t.Errorf("subtask of another user's task: status %d, want 400", code)
+	}
+	if code := move(chain[1].ID, 999); code != http.StatusBadRequest {
+		t.Errorf("move under a missing task: status %d, want 400", code)
+	}
+
+	// Clearing the parent makes the task top-level again.
+	w := do(t, s, http.MethodPatch, taskPath(chain[4].ID), token, map[string]interface{}{"parent_id": nil})
+	if stored := getTask(t, s, token, chain[4].ID); w.Code != http.StatusOK || stored.ParentID != nil {
+		t.Errorf("clear parent: status %d, parent %v", w.Code, stored.ParentID)
+	}
+}
+
+func TestSubtaskProgress(t *testing.T) {
+	t.Run("memory", func(t *testing.T) {
+		testSubtaskProgress(t, newTestServer(t))
+	})
+	t.Run("sqlite", func(t *testing.T) {
+		testSubtaskProgress(t, newTestServer(t, useSQLite(t)))
+	})
+}
+
+func testSubtaskProgress(t *testing.T, s *APIServer) {
+	token := register(t, s, "alice")
+	parent := createTask(t, s, token, "Parent")
+
+	if parent.Progress != nil {
+		t.Errorf("progress without subtasks = %d, want none", *parent.Progress)
+	}
+
+	var children []models.Task
+	for _, title := range []string{"One", "Two", "Three", "Four"} {
+		_, child := createSubtask(t, s, token, title, parent.ID)
+		children = append(children, child)
+	}
+	progress := func() *int {
+		return getTask(t, s, token, parent.ID).Progress
+	}
+	setStatus := func(task models.Task, status models.TaskStatus) {
+		mustDo(t, s, http.MethodPost, taskPath(task.ID)+"/transition", token, map[string]string{"status": string(status)}, nil)
+	}
+
+	if p := progress(); p == nil || *p != 0 {
+		t.Errorf("progress with open subtasks = %v, want 0", p)
+	}
+
+	setStatus(children[0], models.StatusDone)
+	setStatus(children[1], models.StatusCancelled)
+	// One of three subtasks done, the cancelled one left out.
+	if p := progress(); p == nil || *p != 33 {
+		t.Errorf("progress = %v, want 33", p)
+	}
+
+	setStatus(children[2], models.StatusDone)
+	setStatus(children[3], models.StatusCancelled)
+	if p := progress(); p == nil || *p != 100 {
+		t.Errorf("progress = %v, want 100", p)
+	}
+
+	var subtasks []models.Task
+	mustDo(t, s, http.MethodGet, taskPath(parent.ID)+"/children", token, nil, &subtasks)
+	if len(subtasks) != len(children) {
+		t.Errorf("%d subtasks listed, want %d", len(subtasks), len(children))
+	}
+
+	// Progress goes into the ETag, as it changes without the parent.
+	before := getTask(t, s, token, parent.ID)
+	setStatus(children[2], models.StatusTodo)
+	after := getTask(t, s, token, parent.ID)
+	if after.Version != before.Version || taskETag(&after) == taskETag(&before) {
+		t.Errorf("ETag %s after a subtask reopened, was %s", taskETag(&after), taskETag(&before))
+	}
+}
@@ -0,0 +1,272 @@
+package apiserver
+
+import (
+	"TaskManager/internal/models"
+	"context"
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+// @Summary Handling fetching tags
+// @Description Handling the request to list the tags of the authenticated user, ordered by name
+// @Produce json
+// @Success 200 {array} models.Tag "User tags"
+// @Failure 400,401,500 {object} ErrorResponse "Error response with details"
+// @Router /tags [get]
+func (s *APIServer) handleGetTags(ctx *gin.Context) {
+	userID, ok := userIDFromContext(ctx)
+	if !ok {
+		ctx.JSON(http.StatusBadRequest, ErrorResponse{"Wrong user id"})
+		return
+	}
+
+	tags, err := s.storage.GetTags(ctx.Request.Context(), userID)
+	if err != nil {
+		s.log(ctx).Error("Error fetching tags: ", err)
+		ctx.JSON(http.StatusInternalServerError, ErrorResponse{"Failed to fetch tags"})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, tags)
+}
+
+// @Summary Handling tag creation
+// @Description Handling the request to create a tag for the authenticated user. Names are unique per user.
+// @Accept json
+// @Produce json
+// @Param input body TagRequest true "Tag name"
+// @Success 201 {object} models.Tag "Created tag"
+// @Failure 400,401,409,500 {object} ErrorResponse "Error response with details"
+// @Router /tags [post]
+func (s *APIServer) handleCreateTag(ctx *gin.Context) {
+	userID, ok := userIDFromContext(ctx)
+	if !ok {
+		ctx.JSON(http.StatusBadRequest, ErrorResponse{"Wrong user id"})
+		return
+	}
+
+	name, ok := bindTagName(ctx)
+	if !ok {
+		return
+	}
+
+	tag := models.Tag{Name: name}
+	if err := s.storage.CreateTag(ctx.Request.Context(), userID, &tag); err != nil {
+		s.respondTagError(ctx, "Error creating tag: ", err, "Failed to create tag")
+		return
+	}
+
+	ctx.JSON(http.StatusCreated, tag)
+}
+
+// @Summary Handling tag rename
+// @Description Handling the request to rename a tag of the authenticated user
+// @Accept json
+// @Produce json
+// @Param id path int true "Tag ID"
+// @Param input body TagRequest true "New tag name"
+// @Success 200 {object} models.Tag "Renamed tag"
+// @Failure 400,401,404,409,500 {object} ErrorResponse "Error response with details"
+// @Router /tags/{id} [put]
+func (s *APIServer) handleRenameTag(ctx *gin.Context) {
+	userID, tagID, ok := tagParams(ctx, "id")
+	if !ok {
+		return
+	}
+
+	name, ok := bindTagName(ctx)
+	if !ok {
+		return
+	}
+
+	if err := s.storage.RenameTag(ctx.Request.Context(), userID, tagID, name); err != nil {
+		s.respondTagError(ctx, "Error renaming tag: ", err, "Failed to rename tag")
+		return
+	}
+	s.invalidateTasks(ctx.Request.Context(), userID)
+
+	page, err := s.storage.GetTasks(ctx.Request.Context(), userID, &models.TaskQuery{Tags: []string{name}})
+	if err != nil {
+		s.respondEventError(ctx, err)
+		return
+	}
+	for i := range page.Tasks {
+		if err := s.publishTask(ctx.Request.Context(), userID, models.EventTaskUpdated, &page.Tasks[i]); err != nil {
+			s.respondEventError(ctx, err)
+			return
+		}
+	}
+
+	ctx.JSON(http.StatusOK, models.Tag{ID: tagID, Name: name, UserID: userID})
+}
+
+// @Summary Handling deleting a tag
+// @Description Handling the request to delete a tag of the authenticated user. The tag is detached from every task.
+// @Produce json
+// @Param id path int true "Tag ID"
+// @Success 200 {object} StatusResponse "Tag deleted successfully"
+// @Failure 400,401,404,500 {object} ErrorResponse "Error response with details"
+// @Router /tags/{id} [delete]
+func (s *APIServer) handleDeleteTag(ctx *gin.Context) {
+	userID, tagID, ok := tagParams(ctx, "id")
+	if !ok {
+		return
+	}
+
+	// The tasks losing the tag are looked up first, to publish their
+	// change afterwards.
+	tagged, err := s.taggedTaskIDs(ctx.Request.Context(), userID, tagID)
+	if err != nil {
+		s.respondTagError(ctx, "Error deleting tag: ", err, "Failed to delete tag")
+		return
+	}
+
+	if err := s.storage.DeleteTag(ctx.Request.Context(), userID, tagID); err != nil {
+		s.respondTagError(ctx, "Error deleting tag: ", err, "Failed to delete tag")
+		return
+	}
+	s.invalidateTasks(ctx.Request.Context(), userID)
+	for _, taskID := range tagged {
+		if err := s.publishStoredTask(ctx.Request.Context(), userID, taskID); err != nil {
+			s.respondEventError(ctx, err)
+			return
+		}
+	}
+
+	ctx.JSON(http.StatusOK, StatusResponse{"Tag deleted successfully"})
+}
+
+// @Summary Handling attaching a tag
+// @Description Handling the request to attach a tag to a task. Attaching a tag the task already carries has no effect.
+// @Produce json
+// @Param id path int true "Task ID"
+// @Param tagID path int true "Tag ID"
+// @Success 200 {object} models.Task "Tagged task"
+// @Failure 400,401,404,500 {object} ErrorResponse "Error response with details"
+// @Router /tasks/{id}/tags/{tagID} [put]
+func (s *APIServer) handleAttachTag(ctx *gin.Context) {
+	s.changeTaskTag(ctx, s.storage.AttachTag, "Error attaching tag: ", "Failed to attach tag")
+}
+
+// @Summary Handling detaching a tag
+// @Description Handling the request to detach a tag from a task. Detaching a tag the task does not carry has no effect.
+// @Produce json
+// @Param id path int true "Task ID"
+// @Param tagID path int true "Tag ID"
+// @Success 200 {object} models.Task "Untagged task"
+// @Failure 400,401,404,500 {object} ErrorResponse "Error response with details"
+// @Router /tasks/{id}/tags/{tagID} [delete]
+func (s *APIServer) handleDetachTag(ctx *gin.Context) {
+	s.changeTaskTag(ctx, s.storage.DetachTag, "Error detaching tag: ", "Failed to detach tag")
+}
+
+// changeTaskTag runs change for the task and tag of the request and
+// responds with the updated task.
+func (s *APIServer) changeTaskTag(ctx *gin.Context, change func(ctx context.Context, userID, taskID, tagID int) error, logMsg, failMsg string) {
+	userID, taskID, ok := taskParams(ctx)
+	if !ok {
+		return
+	}
+	_, tagID, ok := tagParams(ctx, "tagID")
+	if !ok {
+		return
+	}
+
+	if err := change(ctx.Request.Context(), userID, taskID, tagID); err != nil {
+		s.respondTagError(ctx, logMsg, err, failMsg)
+		return
+	}
+	s.invalidateTasks(ctx.Request.Context(), userID)
+
+	task, err := s.storage.GetTaskByID(ctx.Request.Context(), userID, taskID)
+	if err != nil {
+		s.respondTaskError(ctx, logMsg, err, failMsg)
+		return
+	}
+	if err := s.publishTask(ctx.Request.Context(), userID, models.EventTaskUpdated, task); err != nil {
+		s.respondEventError(ctx, err)
+		return
+	}
+
+	ctx.Header("ETag", taskETag(task))
+	ctx.JSON(http.StatusOK, task)
+}
+
+// taggedTaskIDs returns the IDs of the tasks of a user carrying a tag.
+func (s *APIServer) taggedTaskIDs(ctx context.Context, userID, tagID int) ([]int, error) {
+	tags, err := s.storage.GetTags(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, tag := range tags {
+		if tag.ID != tagID {
+			continue
+		}
+
+		page, err := s.storage.GetTasks(ctx, userID, &models.TaskQuery{Tags: []string{tag.Name}})
+		if err != nil {
+			return nil, err
+		}
+		ids := make([]int, len(page.Tasks))
+		for i := range page.Tasks {
+			ids[i] = page.Tasks[i].ID
+		}
+		return ids, nil
+	}
+
+	return nil, models.ErrTagNotFound
+}
+
+// respondTagError maps tag errors to 404 or 409 responses and everything
+// else as respondTaskError does.
+func (s *APIServer) respondTagError(ctx *gin.Context, logMsg string, err error, failMsg string) {
+	if errors.Is(err, models.ErrTagNotFound) {
+		ctx.JSON(http.StatusNotFound, ErrorResponse{"Tag not found"})
+		return
+	}
+	if errors.Is(err, models.ErrTagExists) {
+		ctx.JSON(http.StatusConflict, ErrorResponse{"Tag already exists"})
+		return
+	}
+
+	s.respondTaskError(ctx, logMsg, err, failMsg)
+}
+
+// bindTagName reads and validates the name of a TagRequest.
+func bindTagName(ctx *gin.Context) (string, bool) {
+	var req TagRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, ErrorResponse{"Invalid tag data"})
+		return "", false
+	}
+
+	name, err := models.NormalizeTagName(req.Name)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, ErrorResponse{err.Error()})
+		return "", false
+	}
+
+	return name, true
+}
+
+// tagParams returns the authenticated user and the tag ID in the path
+// parameter param.
+func tagParams(ctx *gin.Context, param string) (int, int, bool) {
+	userID, ok := userIDFromContext(ctx)
+	if !ok {
+		ctx.JSON(http.StatusBadRequest, ErrorResponse{"Invalid user ID"})
+		return 0, 0, false
+	}
+
+	tagID, err := strconv.Atoi(ctx.Param(param))
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, ErrorResponse{"Invalid tag ID"})
+		return 0, 0, false
+	}
+
+	return userID, tagID, true
+}
@@ -0,0 +1,103 @@
+package apiserver
+
+import (
+	"TaskManager/internal/models"
+	"fmt"
+	"net/http"
+	"net/url"
+	"testing"
+)
+
+// tagTask attaches the named tags to a task, creating those that do not
+// exist yet.
+func tagTask(t *testing.T, s *APIServer, token string, tags map[string]models.Tag, taskID int, names ...string) {
+	t.Helper()
+
+	for _, name := range names {
+		tag, ok := tags[name]
+		if !ok {
+			mustDo(t, s, http.MethodPost, "/tags", token, map[string]string{"name": name}, &tag)
+			tags[name] = tag
+		}
+		mustDo(t, s, http.MethodPut, fmt.Sprintf("/tasks/%d/tags/%d", taskID, tag.ID), token, nil, nil)
+	}
+}
+
+func TestTagMode(t *testing.T) {
+	t.Run("memory", func(t *testing.T) {
+		testTagMode(t, newTestServer(t))
+	})
+	t.Run("sqlite", func(t *testing.T) {
+		testTagMode(t, newTestServer(t, useSQLite(t)))
+	})
+}
+
+func testTagMode(t *testing.T, s *APIServer) {
+	token := register(t, s, "alice")
+	tags := make(map[string]models.Tag)
+
+	both := createTask(t, s, token, "Both")
+	home := createTask(t, s, token, "Home")
+	work := createTask(t, s, token, "Work")
+	untagged := createTask(t, s, token, "Untagged")
+	tagTask(t, s, token, tags, both.ID, "home", "work")
+	tagTask(t, s, token, tags, home.ID, "home")
+	tagTask(t, s, token, tags, work.ID, "work", "later")
+
+	// Another user's tags of the same name do not count.
+	bob := register(t, s, "bob")
+	tagTask(t, s, bob, make(map[string]models.Tag), createTask(t, s, bob, "Bob's").ID, "home", "work")
+
+	tests := []struct {
+		tags []string
+		mode string
+		want []int
+	}{
+		{[]string{"home", "work"}, "", []int{both.ID}},
+		{[]string{"home", "work"}, "all", []int{both.ID}},
+		{[]string{"home", "work"}, "any", []int{both.ID, home.ID, work.ID}},
+		{[]string{"home"}, "all", []int{both.ID, home.ID}},
+		{[]string{"home"}, "any", []int{both.ID, home.ID}},
+		{[]string{"home", "missing"}, "all", nil},
+		{[]string{"home", "missing"}, "any", []int{both.ID, home.ID}},
+		{[]string{"home", "home"}, "all", []int{both.ID, home.ID}},
+		{[]string{"later", "home", "work"}, "any", []int{both.ID, home.ID, work.ID}},
+		{nil, "any", []int{both.ID, home.ID, work.ID, untagged.ID}},
+	}
+	for _, tt := range tests {
+		query := url.Values{"tag": tt.tags}
+		if tt.mode != "" {
+			query.Set("tag_mode", tt.mode)
+		}
+
+		page := listPage(t, s, token, query)
+		var got []int
+		for _, task := range page.Tasks {
+			got = append(got, task.ID)
+		}
+		if fmt.Sprint(got) != fmt.Sprint(tt.want) || page.Total != len(tt.want) {
+			t.Errorf("tags %v, mode %q: tasks %v (total %d), want %v", tt.tags, tt.mode, got, page.Total, tt.want)
+		}
+	}
+
+	// A task carrying several of the tags is listed once on every page.
+	query := url.Values{"tag": {"home", "work"}, "tag_mode": {"any"}, "limit": {"1"}}
+	var got []int
+	for {
+		page := listPage(t, s, token, query)
+		for _, task := range page.Tasks {
+			got = append(got, task.ID)
+		}
+		if page.NextCursor == "" || len(got) > 3 {
+			break
+		}
+		query.Set("cursor", page.NextCursor)
+	}
+	if want := []int{both.ID, home.ID, work.ID}; fmt.Sprint(got) != fmt.Sprint(want) {
+		t.Errorf("paged tag_mode=any: tasks %v, want %v", got, want)
+	}
+
+	if w := do(t, s, http.MethodGet, "/tasks?tag=home&tag_mode=some", token, nil); w.Code != http.StatusBadRequest {
+		t.Errorf("unknown tag_mode: status %d, want 400", w.Code)
+	}
+}
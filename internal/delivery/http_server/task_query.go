@@ -0,0 +1,98 @@
+package apiserver
+
+import (
+	"TaskManager/internal/models"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	defaultTaskLimit = 50
+	maxTaskLimit     = 200
+)
+
+// parseTaskQuery reads the pagination, filter and sort parameters of
+// GET /tasks.
+func parseTaskQuery(ctx *gin.Context) (*models.TaskQuery, error) {
+	query := &models.TaskQuery{
+		Limit:  defaultTaskLimit,
+		Cursor: ctx.Query("cursor"),
+		Title:  ctx.Query("title"),
+		SortBy: models.TaskSortField(ctx.DefaultQuery("sort", string(models.SortByID))),
+	}
+
+	if limit := ctx.Query("limit"); limit != "" {
+		n, err := strconv.Atoi(limit)
+		if err != nil || n < 1 || n > maxTaskLimit {
+			return nil, fmt.Errorf("limit must be between 1 and %d", maxTaskLimit)
+		}
+		query.Limit = n
+	}
+
+	if !query.SortBy.Valid() {
+		return nil, fmt.Errorf("unknown sort field %q", query.SortBy)
+	}
+
+	switch order := ctx.DefaultQuery("order", "asc"); order {
+	case "asc":
+	case "desc":
+		query.Descending = true
+	default:
+		return nil, fmt.Errorf("order must be asc or desc")
+	}
+
+	for _, p := range []struct {
+		name string
+		dst  **time.Time
+	}{
+		{"scheduled_from", &query.ScheduledFrom},
+		{"scheduled_to", &query.ScheduledTo},
+		{"created_from", &query.CreatedFrom},
+		{"created_to", &query.CreatedTo},
+	} {
+		value := ctx.Query(p.name)
+		if value == "" {
+			continue
+		}
+
+		t, err := time.Parse(time.RFC3339, value)
+		if err != nil {
+			return nil, fmt.Errorf("%s must be an RFC 3339 timestamp", p.name)
+		}
+		*p.dst = &t
+	}
+
+	for _, value := range ctx.QueryArray("status") {
+		for _, status := range strings.Split(value, ",") {
+			status := models.TaskStatus(status)
+			if !status.Valid() {
+				return nil, fmt.Errorf("unknown status %q", status)
+			}
+			query.Statuses = append(query.Statuses, status)
+		}
+	}
+
+	// Tag names may contain commas, so only repeated parameters are split.
+	seen := make(map[string]bool)
+	for _, value := range ctx.QueryArray("tag") {
+		name, err := models.NormalizeTagName(value)
+		if err != nil {
+			return nil, err
+		}
+		if !seen[name] {
+			seen[name] = true
+			query.Tags = append(query.Tags, name)
+		}
+	}
+
+	query.TagMode = models.TagMode(ctx.DefaultQuery("tag_mode", string(models.TagModeAll)))
+	if !query.TagMode.Valid() {
+		return nil, fmt.Errorf("tag_mode must be all or any")
+	}
+
+	return query, nil
+}
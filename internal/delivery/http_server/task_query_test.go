@@ -0,0 +1,227 @@
+package apiserver
+
+import (
+	"TaskManager/internal/models"
+	"TaskManager/internal/storage/sqlite"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"path/filepath"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+)
+
+// useSQLite backs the server with a fresh SQLite database, for the queries
+// the in-memory storage does not exercise.
+func useSQLite(t *testing.T) func(s *APIServer) {
+	return func(s *APIServer) {
+		config := sqlite.NewConfig()
+		config.DataBaseURL = "sqlite://" + filepath.Join(t.TempDir(), "tasks.db")
+
+		db := sqlite.New(config)
+		if err := db.Open(); err != nil {
+			t.Fatal(err)
+		}
+		t.Cleanup(func() { db.Close() })
+
+		if err := s.UseDB(db); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+// listPage fetches one page of GET /tasks with the given query.
+func listPage(t *testing.T, s *APIServer, token string, query url.Values) models.TaskPage {
+	t.Helper()
+
+	w := do(t, s, http.MethodGet, "/tasks?"+query.Encode(), token, nil)
+	if w.Code != http.StatusOK {
+		t.Fatalf("list %s: status %d: %s", query.Encode(), w.Code, w.Body)
+	}
+	var page models.TaskPage
+	if err := json.Unmarshal(w.Body.Bytes(), &page); err != nil {
+		t.Fatal(err)
+	}
+	return page
+}
+
+// createSortableTasks creates tasks with ties in every sort key, and
+// undated ones among them.
+func createSortableTasks(t *testing.T, s *APIServer, token string) []models.Task {
+	t.Helper()
+
+	base := time.Date(2030, 1, 1, 9, 0, 0, 0, time.UTC)
+	specs := []struct {
+		title    string
+		priority string
+		hours    int
+		dueDays  int
+	}{
+		{"b", "P1", 0, 1},
+		{"a", "P0", 1, 0},
+		{"c", "P1", 2, 2},
+		{"a", "P2", 0, 1},
+		{"b", "P1", 1, 0},
+		{"d", "P0", 2, 1},
+		{"a", "P2", 0, 0},
+		{"c", "P1", 1, 1},
+	}
+
+	var tasks []models.Task
+	for _, spec := range specs {
+		body := map[string]interface{}{
+			"title":         spec.title,
+			"priority":      spec.priority,
+			"scheduled_for": base.Add(time.Duration(spec.hours) * time.Hour),
+		}
+		if spec.dueDays > 0 {
+			body["due_at"] = base.Add(time.Duration(spec.dueDays) * 24 * time.Hour)
+		}
+
+		var task models.Task
+		mustDo(t, s, http.MethodPost, "/tasks", token, body, &task)
+		tasks = append(tasks, task)
+	}
+	return tasks
+}
+
+// expectedOrder sorts tasks as GET /tasks documents it: by the sort key,
+// ties broken by ID, all reversed for descending order, except that
+// undated tasks come last by priority either way.
+func expectedOrder(tasks []models.Task, sortBy models.TaskSortField, descending bool) []int {
+	sorted := append([]models.Task(nil), tasks...)
+	sort.Slice(sorted, func(i, j int) bool {
+		a, b := sorted[i], sorted[j]
+		c := 0
+		switch sortBy {
+		case models.SortByCreatedAt:
+			c = a.CreatedAt.Compare(b.CreatedAt)
+		case models.SortByScheduledFor:
+			c = a.ScheduledFor.Compare(b.ScheduledFor)
+		case models.SortByTitle:
+			c = strings.Compare(a.Title, b.Title)
+		case models.SortByPriority:
+			c = int(a.Priority - b.Priority)
+			if c == 0 {
+				c = compareDue(a.DueAt, b.DueAt, descending)
+			}
+		}
+		if c == 0 {
+			c = a.ID - b.ID
+		}
+		if descending {
+			return c > 0
+		}
+		return c < 0
+	})
+
+	ids := make([]int, len(sorted))
+	for i, task := range sorted {
+		ids[i] = task.ID
+	}
+	return ids
+}
+
+// compareDue compares due dates so that, once the result is reversed for
+// descending order, undated tasks still come last.
+func compareDue(a, b *time.Time, descending bool) int {
+	undated := 1
+	if descending {
+		undated = -1
+	}
+	switch {
+	case a == nil && b == nil:
+		return 0
+	case a == nil:
+		return undated
+	case b == nil:
+		return -undated
+	}
+	return a.Compare(*b)
+}
+
+func TestTaskPagingAcrossSorts(t *testing.T) {
+	t.Run("memory", func(t *testing.T) {
+		testTaskPaging(t, newTestServer(t))
+	})
+	t.Run("sqlite", func(t *testing.T) {
+		testTaskPaging(t, newTestServer(t, useSQLite(t)))
+	})
+}
+
+func testTaskPaging(t *testing.T, s *APIServer) {
+	token := register(t, s, "alice")
+	tasks := createSortableTasks(t, s, token)
+
+	for _, sortBy := range []models.TaskSortField{models.SortByID, models.SortByCreatedAt, models.SortByScheduledFor, models.SortByTitle, models.SortByPriority} {
+		for _, order := range []string{"asc", "desc"} {
+			name := fmt.Sprintf("sort=%s order=%s", sortBy, order)
+			want := expectedOrder(tasks, sortBy, order == "desc")
+
+			query := url.Values{"sort": {string(sortBy)}, "order": {order}, "limit": {"3"}}
+			var got []int
+			for pages := 0; ; pages++ {
+				if pages > len(tasks) {
+					t.Fatalf("%s: paging does not end", name)
+				}
+
+				page := listPage(t, s, token, query)
+				if page.Total != len(tasks) {
+					t.Errorf("%s: total %d, want %d", name, page.Total, len(tasks))
+				}
+				for _, task := range page.Tasks {
+					got = append(got, task.ID)
+				}
+				if page.NextCursor == "" {
+					break
+				}
+				query.Set("cursor", page.NextCursor)
+			}
+
+			if fmt.Sprint(got) != fmt.Sprint(want) {
+				t.Errorf("%s: paged through %v, want %v", name, got, want)
+			}
+		}
+	}
+}
+
+func TestTaskCursorSurvivesDeletion(t *testing.T) {
+	s := newTestServer(t)
+	token := register(t, s, "alice")
+	tasks := createSortableTasks(t, s, token)
+	want := expectedOrder(tasks, models.SortByTitle, false)
+
+	query := url.Values{"sort": {"title"}, "limit": {"3"}}
+	first := listPage(t, s, token, query)
+
+	// The cursor holds the sort key, so the next page starts in the same
+	// place after the last task of the page is gone.
+	mustDo(t, s, http.MethodDelete, taskPath(first.Tasks[2].ID), token, nil, nil)
+	query.Set("cursor", first.NextCursor)
+	second := listPage(t, s, token, query)
+
+	if len(second.Tasks) == 0 || second.Tasks[0].ID != want[3] {
+		t.Errorf("next page starts with %+v, want task %d", second.Tasks, want[3])
+	}
+}
+
+func TestTaskCursorRejected(t *testing.T) {
+	s := newTestServer(t)
+	token := register(t, s, "alice")
+	createSortableTasks(t, s, token)
+
+	page := listPage(t, s, token, url.Values{"sort": {"title"}, "limit": {"2"}})
+
+	for _, query := range []url.Values{
+		{"sort": {"priority"}, "cursor": {page.NextCursor}},
+		{"sort": {"title"}, "order": {"desc"}, "cursor": {page.NextCursor}},
+		{"sort": {"title"}, "cursor": {"garbage"}},
+	} {
+		if w := do(t, s, http.MethodGet, "/tasks?"+query.Encode(), token, nil); w.Code != http.StatusBadRequest {
+			t.Errorf("list %s: status %d, want 400", query.Encode(), w.Code)
+		}
+	}
+}
@@ -0,0 +1,133 @@
+package apiserver
+
+import (
+	"TaskManager/internal/models"
+	"context"
+	"encoding/json"
+	"net/http"
+	"testing"
+)
+
+func TestTransitionTask(t *testing.T) {
+	s := newTestServer(t)
+	token := register(t, s, "alice")
+	task := createTask(t, s, token, "Lifecycle")
+	path := taskPath(task.ID) + "/transition"
+
+	steps := []struct {
+		status models.TaskStatus
+		code   int
+	}{
+		{models.StatusInProgress, http.StatusOK},
+		{models.StatusBlocked, http.StatusOK},
+		{models.StatusDone, http.StatusConflict},
+		{models.StatusBlocked, http.StatusOK},
+		{models.StatusTodo, http.StatusOK},
+		{models.StatusDone, http.StatusOK},
+		{models.StatusCancelled, http.StatusConflict},
+		{models.StatusInProgress, http.StatusConflict},
+		{"archived", http.StatusBadRequest},
+		{models.StatusTodo, http.StatusOK},
+	}
+	status := models.StatusTodo
+	for _, step := range steps {
+		w := do(t, s, http.MethodPost, path, token, map[string]string{"status": string(step.status)})
+		if w.Code != step.code {
+			t.Fatalf("%s to %s: status %d, want %d: %s", status, step.status, w.Code, step.code, w.Body)
+		}
+		if w.Code != http.StatusOK {
+			continue
+		}
+
+		var moved models.Task
+		if err := json.Unmarshal(w.Body.Bytes(), &moved); err != nil {
+			t.Fatal(err)
+		}
+		if moved.Status != step.status || w.Header().Get("ETag") != taskETag(&moved) {
+			t.Errorf("%s to %s: response %+v with ETag %s", status, step.status, moved, w.Header().Get("ETag"))
+		}
+		status = step.status
+	}
+
+	if stored := getTask(t, s, token, task.ID); stored.Status != status {
+		t.Errorf("stored status = %s, want %s", stored.Status, status)
+	}
+	if w := do(t, s, http.MethodPost, path, token, map[string]string{}); w.Code != http.StatusBadRequest {
+		t.Errorf("transition without status: status %d, want 400", w.Code)
+	}
+}
+
+func TestUpdateChecksTransition(t *testing.T) {
+	s := newTestServer(t)
+	token := register(t, s, "alice")
+	task := createTask(t, s, token, "Lifecycle")
+
+	mustDo(t, s, http.MethodPatch, taskPath(task.ID), token, map[string]string{"status": string(models.StatusBlocked)}, nil)
+
+	for _, method := range []string{http.MethodPut, http.MethodPatch} {
+		w := do(t, s, method, taskPath(task.ID), token, map[string]string{"title": "Lifecycle", "status": string(models.StatusDone)})
+		if w.Code != http.StatusConflict {
+			t.Errorf("%s from blocked to done: status %d, want 409", method, w.Code)
+		}
+	}
+
+	// Keeping the status is not a transition.
+	w := do(t, s, http.MethodPut, taskPath(task.ID), token, map[string]string{"title": "Renamed", "status": string(models.StatusBlocked)})
+	if w.Code != http.StatusOK {
+		t.Errorf("PUT keeping the status: status %d, want 200: %s", w.Code, w.Body)
+	}
+}
+
+// racingStorage changes the task's title right before every status
+// update, as a concurrent request would.
+type racingStorage struct {
+	Storage
+}
+
+func (s racingStorage) UpdateTaskStatus(ctx context.Context, userID, taskID int, status models.TaskStatus, version int) error {
+	title := "Changed meanwhile"
+	if _, err := s.PatchTask(ctx, userID, taskID, &models.TaskPatch{Title: &title}, 0); err != nil {
+		return err
+	}
+	return s.Storage.UpdateTaskStatus(ctx, userID, taskID, status, version)
+}
+
+func TestTransitionRace(t *testing.T) {
+	s := newTestServer(t, func(s *APIServer) {
+		s.storage = racingStorage{s.storage}
+	})
+	token := register(t, s, "alice")
+	task := createTask(t, s, token, "Racy")
+	path := taskPath(task.ID) + "/transition"
+
+	w := do(t, s, http.MethodPost, path, token, map[string]string{"status": string(models.StatusInProgress)})
+	if w.Code != http.StatusConflict {
+		t.Errorf("unconditional transition: status %d, want 409: %s", w.Code, w.Body)
+	}
+
+	current := getTask(t, s, token, task.ID)
+	w = doWith(t, s, http.MethodPost, path, token, map[string]string{"status": string(models.StatusInProgress)},
+		http.Header{"If-Match": {taskETag(&current)}})
+	if w.Code != http.StatusPreconditionFailed {
+		t.Errorf("conditional transition: status %d, want 412: %s", w.Code, w.Body)
+	}
+
+	if current := getTask(t, s, token, task.ID); current.Status != models.StatusTodo {
+		t.Errorf("status after lost races = %s, want %s", current.Status, models.StatusTodo)
+	}
+}
+
+// getTask fetches the task as stored.
+func getTask(t *testing.T, s *APIServer, token string, id int) models.Task {
+	t.Helper()
+
+	w := do(t, s, http.MethodGet, taskPath(id), token, nil)
+	if w.Code != http.StatusOK {
+		t.Fatalf("get task: status %d: %s", w.Code, w.Body)
+	}
+	var task models.Task
+	if err := json.Unmarshal(w.Body.Bytes(), &task); err != nil {
+		t.Fatal(err)
+	}
+	return task
+}
@@ -0,0 +1,196 @@
+package apiserver
+
+import (
+	"TaskManager/internal/models"
+	"TaskManager/internal/webhook"
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+// webhookSecretBytes is the length of generated webhook secrets.
+const webhookSecretBytes = 32
+
+// @Summary Handling fetching webhooks
+// @Description Handling the request to list the webhooks of the authenticated user. Secrets are not included.
+// @Produce json
+// @Success 200 {array} models.Webhook "User webhooks"
+// @Failure 400,401,500 {object} ErrorResponse "Error response with details"
+// @Router /webhooks [get]
+func (s *APIServer) handleGetWebhooks(ctx *gin.Context) {
+	userID, ok := userIDFromContext(ctx)
+	if !ok {
+		ctx.JSON(http.StatusBadRequest, ErrorResponse{"Wrong user id"})
+		return
+	}
+
+	webhooks, err := s.storage.GetWebhooks(ctx.Request.Context(), userID)
+	if err != nil {
+		s.log(ctx).Error("Error fetching webhooks: ", err)
+		ctx.JSON(http.StatusInternalServerError, ErrorResponse{"Failed to fetch webhooks"})
+		return
+	}
+
+	for i := range webhooks {
+		webhooks[i].Secret = ""
+	}
+	ctx.JSON(http.StatusOK, webhooks)
+}
+
+// @Summary Handling webhook creation
+// @Description Handling the request to subscribe a URL to task events. The URL must point to a public address. Deliveries are POST requests signed with HMAC-SHA256: the X-Webhook-Signature header is "sha256=" and the hex HMAC, keyed with the secret, of the X-Webhook-Timestamp header, a dot and the body. The secret is only returned here.
+// @Accept json
+// @Produce json
+// @Param input body WebhookRequest true "Webhook data"
+// @Success 201 {object} models.Webhook "Created webhook"
+// @Failure 400,401,500 {object} ErrorResponse "Error response with details"
+// @Router /webhooks [post]
+func (s *APIServer) handleCreateWebhook(ctx *gin.Context) {
+	userID, ok := userIDFromContext(ctx)
+	if !ok {
+		ctx.JSON(http.StatusBadRequest, ErrorResponse{"Wrong user id"})
+		return
+	}
+
+	webhook, ok := s.bindWebhook(ctx)
+	if !ok {
+		return
+	}
+
+	if webhook.Secret == "" {
+		secret, err := randomString(webhookSecretBytes)
+		if err != nil {
+			s.log(ctx).Error("Error generating webhook secret: ", err)
+			ctx.JSON(http.StatusInternalServerError, ErrorResponse{"Failed to create webhook"})
+			return
+		}
+		webhook.Secret = secret
+	}
+
+	if err := s.storage.CreateWebhook(ctx.Request.Context(), userID, webhook); err != nil {
+		s.respondWebhookError(ctx, "Error creating webhook: ", err, "Failed to create webhook")
+		return
+	}
+
+	ctx.JSON(http.StatusCreated, webhook)
+}
+
+// @Summary Handling updating a webhook
+// @Description Handling the request to change the URL, events or secret of a webhook. An empty secret keeps the current one.
+// @Accept json
+// @Produce json
+// @Param id path int true "Webhook ID"
+// @Param input body WebhookRequest true "Webhook data"
+// @Success 200 {object} models.Webhook "Updated webhook"
+// @Failure 400,401,404,500 {object} ErrorResponse "Error response with details"
+// @Router /webhooks/{id} [put]
+func (s *APIServer) handleUpdateWebhook(ctx *gin.Context) {
+	userID, webhookID, ok := webhookParams(ctx)
+	if !ok {
+		return
+	}
+
+	webhook, ok := s.bindWebhook(ctx)
+	if !ok {
+		return
+	}
+	webhook.ID = webhookID
+
+	if err := s.storage.UpdateWebhook(ctx.Request.Context(), userID, webhook); err != nil {
+		s.respondWebhookError(ctx, "Error updating webhook: ", err, "Failed to update webhook")
+		return
+	}
+
+	webhook.Secret = ""
+	ctx.JSON(http.StatusOK, webhook)
+}
+
+// @Summary Handling deleting a webhook
+// @Description Handling the request to delete a webhook. Its pending deliveries are dropped.
+// @Produce json
+// @Param id path int true "Webhook ID"
+// @Success 200 {object} StatusResponse "Webhook deleted successfully"
+// @Failure 400,401,404,500 {object} ErrorResponse "Error response with details"
+// @Router /webhooks/{id} [delete]
+func (s *APIServer) handleDeleteWebhook(ctx *gin.Context) {
+	userID, webhookID, ok := webhookParams(ctx)
+	if !ok {
+		return
+	}
+
+	if err := s.storage.DeleteWebhook(ctx.Request.Context(), userID, webhookID); err != nil {
+		s.respondWebhookError(ctx, "Error deleting webhook: ", err, "Failed to delete webhook")
+		return
+	}
+
+	ctx.JSON(http.StatusOK, StatusResponse{"Webhook deleted successfully"})
+}
+
+// respondWebhookError maps webhook errors to 400 or 404 responses, and
+// anything unexpected to a logged 500.
+func (s *APIServer) respondWebhookError(ctx *gin.Context, logMsg string, err error, failMsg string) {
+	switch {
+	case errors.Is(err, models.ErrWebhookNotFound):
+		ctx.JSON(http.StatusNotFound, ErrorResponse{"Webhook not found"})
+		return
+	case errors.Is(err, models.ErrInvalidWebhook):
+		ctx.JSON(http.StatusBadRequest, ErrorResponse{err.Error()})
+		return
+	}
+
+	s.log(ctx).Error(logMsg, err)
+	ctx.JSON(http.StatusInternalServerError, ErrorResponse{failMsg})
+}
+
+// bindWebhook reads and validates a WebhookRequest.
+func (s *APIServer) bindWebhook(ctx *gin.Context) (*models.Webhook, bool) {
+	var req WebhookRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, ErrorResponse{"Invalid webhook data"})
+		return nil, false
+	}
+
+	webhook := &models.Webhook{URL: req.URL, Secret: req.Secret, Events: models.EventFilter(req.Events)}
+	if webhook.Events == nil {
+		webhook.Events = models.EventFilter{}
+	}
+	if err := webhook.Validate(); err != nil {
+		ctx.JSON(http.StatusBadRequest, ErrorResponse{err.Error()})
+		return nil, false
+	}
+	if err := s.checkWebhookURL(webhook.URL); err != nil {
+		ctx.JSON(http.StatusBadRequest, ErrorResponse{err.Error()})
+		return nil, false
+	}
+
+	return webhook, true
+}
+
+// checkWebhookURL rejects webhook URLs pointing at non-public addresses,
+// unless the dispatcher is configured to allow them.
+func (s *APIServer) checkWebhookURL(rawURL string) error {
+	if s.webhooks != nil {
+		return s.webhooks.CheckURL(rawURL)
+	}
+	return webhook.CheckURL(rawURL)
+}
+
+// webhookParams returns the authenticated user and the webhook ID in the
+// path.
+func webhookParams(ctx *gin.Context) (int, int, bool) {
+	userID, ok := userIDFromContext(ctx)
+	if !ok {
+		ctx.JSON(http.StatusBadRequest, ErrorResponse{"Invalid user ID"})
+		return 0, 0, false
+	}
+
+	webhookID, err := strconv.Atoi(ctx.Param("id"))
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, ErrorResponse{"Invalid webhook ID"})
+		return 0, 0, false
+	}
+
+	return userID, webhookID, true
+}
@@ -0,0 +1,14 @@
+package metrics
+
+type Config struct {
+	Enabled bool `toml:"enabled"`
+	// Path is where the metrics are served in the Prometheus text format.
+	Path string `toml:"path"`
+}
+
+func NewConfig() *Config {
+	return &Config{
+		Enabled: true,
+		Path:    "/metrics",
+	}
+}
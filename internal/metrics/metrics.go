@@ -0,0 +1,137 @@
+// Package metrics records Prometheus metrics of the HTTP requests, storage
+// calls and cache lookups, and serves them.
+package metrics
+
+import (
+	"database/sql"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+const namespace = "taskmanager"
+
+var (
+	httpRequests = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Subsystem: "http",
+		Name:      "requests_total",
+		Help:      "HTTP requests by method, route and status code.",
+	}, []string{"method", "route", "status"})
+	httpDuration = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Subsystem: "http",
+		Name:      "request_duration_seconds",
+		Help:      "HTTP request latency by method and route.",
+		Buckets:   prometheus.DefBuckets,
+	}, []string{"method", "route"})
+
+	storageDuration = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Subsystem: "storage",
+		Name:      "call_duration_seconds",
+		Help:      "Storage call latency by method.",
+		Buckets:   []float64{.0005, .001, .0025, .005, .01, .025, .05, .1, .25, .5, 1, 2.5},
+	}, []string{"method"})
+	storageErrors = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Subsystem: "storage",
+		Name:      "errors_total",
+		Help:      "Storage calls that returned an error, not found and conflicts included, by method.",
+	}, []string{"method"})
+
+	cacheDuration = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Subsystem: "cache",
+		Name:      "call_duration_seconds",
+		Help:      "Cache call latency by method.",
+		Buckets:   []float64{.0001, .00025, .0005, .001, .0025, .005, .01, .025, .05, .1},
+	}, []string{"method"})
+	cacheErrors = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Subsystem: "cache",
+		Name:      "errors_total",
+		Help:      "Cache calls that failed, misses excluded, by method.",
+	}, []string{"method"})
+	cacheLookups = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Subsystem: "cache",
+		Name:      "lookups_total",
+		Help:      "Cache lookups by result, hit or miss.",
+	}, []string{"result"})
+)
+
+// Handler serves the metrics of the default registry, which include the
+// Go runtime and process metrics.
+func Handler() http.Handler {
+	return promhttp.Handler()
+}
+
+// Middleware records the count and latency of the requests. Requests
+// matching no route are recorded under the route "unmatched", so that
+// scanners cannot create a series per path.
+func Middleware() gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		start := time.Now()
+
+		// The request is recorded in a deferred call, so that a panic on
+		// its way to gin.Recovery is counted as the 500 it turns into.
+		panicked := true
+		defer func() {
+			status := ctx.Writer.Status()
+			if panicked {
+				status = http.StatusInternalServerError
+			}
+
+			route := ctx.FullPath()
+			if route == "" {
+				route = "unmatched"
+			}
+			method := ctx.Request.Method
+
+			httpRequests.WithLabelValues(method, route, strconv.Itoa(status)).Inc()
+			httpDuration.WithLabelValues(method, route).Observe(time.Since(start).Seconds())
+		}()
+
+		ctx.Next()
+		panicked = false
+	}
+}
+
+// ObserveStorage records a storage call that started at start.
+func ObserveStorage(method string, start time.Time, err error) {
+	storageDuration.WithLabelValues(method).Observe(time.Since(start).Seconds())
+	if err != nil {
+		storageErrors.WithLabelValues(method).Inc()
+	}
+}
+
+// ObserveCache records a cache call that started at start. err is nil for
+// a miss.
+func ObserveCache(method string, start time.Time, err error) {
+	cacheDuration.WithLabelValues(method).Observe(time.Since(start).Seconds())
+	if err != nil {
+		cacheErrors.WithLabelValues(method).Inc()
+	}
+}
+
+// ObserveLookup records a cache hit or miss.
+func ObserveLookup(hit bool) {
+	result := "miss"
+	if hit {
+		result = "hit"
+	}
+	cacheLookups.WithLabelValues(result).Inc()
+}
+
+// RegisterDB exports the connection pool statistics of a database, such
+// as open, in-use and idle connections and waits for one.
+func RegisterDB(name string, db *sql.DB) error {
+	return prometheus.Register(collectors.NewDBStatsCollector(db, name))
+}
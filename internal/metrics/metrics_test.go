@@ -0,0 +1,37 @@
+package metrics
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+)
+
+func TestMiddlewareRecordsPanics(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	router := gin.New()
+	router.Use(gin.CustomRecovery(func(ctx *gin.Context, err interface{}) {
+		ctx.AbortWithStatus(http.StatusInternalServerError)
+	}), Middleware())
+	router.GET("/panic", func(ctx *gin.Context) { panic("boom") })
+	router.GET("/ok", func(ctx *gin.Context) { ctx.Status(http.StatusNoContent) })
+
+	panics := httpRequests.WithLabelValues(http.MethodGet, "/panic", "500")
+	ok := httpRequests.WithLabelValues(http.MethodGet, "/ok", "204")
+	before, beforeOK := testutil.ToFloat64(panics), testutil.ToFloat64(ok)
+
+	for _, path := range []string{"/panic", "/ok"} {
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, path, nil))
+	}
+
+	if got := testutil.ToFloat64(panics) - before; got != 1 {
+		t.Errorf("panicking request counted %v times as 500, want 1", got)
+	}
+	if got := testutil.ToFloat64(ok) - beforeOK; got != 1 {
+		t.Errorf("request counted %v times as 204, want 1", got)
+	}
+}
@@ -0,0 +1,85 @@
+package models
+
+import (
+	"errors"
+	"sort"
+)
+
+var (
+	ErrDependencyNotFound = errors.New("dependency task not found")
+	ErrDependencyCycle    = errors.New("dependency would create a cycle")
+)
+
+// Finished reports whether a task in status s no longer blocks the tasks
+// depending on it.
+func (s TaskStatus) Finished() bool {
+	return s == StatusDone || s == StatusCancelled
+}
+
+// OrderTasks sorts tasks so that every task comes after the tasks it is
+// blocked by. Dependencies on tasks outside the slice are ignored. Among
+// tasks whose dependencies are all placed, the most urgent comes first:
+// by priority, then due date, then ID.
+func OrderTasks(tasks []Task) []Task {
+	index := make(map[int]int, len(tasks))
+	for i := range tasks {
+		index[tasks[i].ID] = i
+	}
+
+	pending := make([]int, len(tasks))
+	dependents := make(map[int][]int)
+	for i := range tasks {
+		for _, id := range tasks[i].BlockedBy {
+			if j, ok := index[id]; ok {
+				pending[i]++
+				dependents[j] = append(dependents[j], i)
+			}
+		}
+	}
+
+	urgent := func(a, b *Task) bool {
+		if a.Priority != b.Priority {
+			return a.Priority < b.Priority
+		}
+		if c := a.DueSortKey(false).Compare(b.DueSortKey(false)); c != 0 {
+			return c < 0
+		}
+		return a.ID < b.ID
+	}
+
+	var ready []int
+	for i := range tasks {
+		if pending[i] == 0 {
+			ready = append(ready, i)
+		}
+	}
+
+	ordered := make([]Task, 0, len(tasks))
+	placed := make([]bool, len(tasks))
+	for len(ready) > 0 {
+		sort.Slice(ready, func(a, b int) bool {
+			return urgent(&tasks[ready[a]], &tasks[ready[b]])
+		})
+
+		i := ready[0]
+		ready = ready[1:]
+		ordered = append(ordered, tasks[i])
+		placed[i] = true
+
+		for _, j := range dependents[i] {
+			if pending[j]--; pending[j] == 0 {
+				ready = append(ready, j)
+			}
+		}
+	}
+
+	// Cycles are rejected when dependencies are added; should one slip
+	// through, its tasks still end up in the result.
+	for i := range tasks {
+		if !placed[i] {
+			ordered = append(ordered, tasks[i])
+		}
+	}
+
+	return ordered
+}
@@ -0,0 +1,83 @@
+package models
+
+import (
+	"fmt"
+	"testing"
+	"time"
+)
+
+func orderedIDs(tasks []Task) []int {
+	ids := make([]int, len(tasks))
+	for i := range tasks {
+		ids[i] = tasks[i].ID
+	}
+	return ids
+}
+
+func TestOrderTasks(t *testing.T) {
+	soon := time.Date(2030, 1, 1, 9, 0, 0, 0, time.UTC)
+	later := soon.Add(24 * time.Hour)
+
+	tests := []struct {
+		name  string
+		tasks []Task
+		want  []int
+	}{
+		{"empty", nil, []int{}},
+		{
+			"by priority",
+			[]Task{{ID: 1, Priority: PriorityP3}, {ID: 2, Priority: PriorityP0}, {ID: 3, Priority: PriorityP1}},
+			[]int{2, 3, 1},
+		},
+		{
+			"by due date, undated last",
+			[]Task{{ID: 1}, {ID: 2, DueAt: &later}, {ID: 3, DueAt: &soon}},
+			[]int{3, 2, 1},
+		},
+		{
+			"by ID",
+			[]Task{{ID: 3}, {ID: 1}, {ID: 2}},
+			[]int{1, 2, 3},
+		},
+		{
+			"dependencies first",
+			[]Task{{ID: 1, Priority: PriorityP0, BlockedBy: []int{2}}, {ID: 2, Priority: PriorityP4}},
+			[]int{2, 1},
+		},
+		{
+			"urgent task freed by its dependency",
+			[]Task{
+				{ID: 1, Priority: PriorityP0, BlockedBy: []int{3}},
+				{ID: 2, Priority: PriorityP1},
+				{ID: 3, Priority: PriorityP2},
+			},
+			[]int{2, 3, 1},
+		},
+		{
+			"blocked by several tasks",
+			[]Task{
+				{ID: 1, Priority: PriorityP0, BlockedBy: []int{2, 3}},
+				{ID: 2, Priority: PriorityP4},
+				{ID: 3, Priority: PriorityP3, BlockedBy: []int{2}},
+				{ID: 4, Priority: PriorityP2},
+			},
+			[]int{4, 2, 3, 1},
+		},
+		{
+			"outside dependencies ignored",
+			[]Task{{ID: 1, Priority: PriorityP0, BlockedBy: []int{99}}, {ID: 2, Priority: PriorityP1}},
+			[]int{1, 2},
+		},
+		{
+			"cycle kept",
+			[]Task{{ID: 1, BlockedBy: []int{2}}, {ID: 2, BlockedBy: []int{1}}, {ID: 3}},
+			[]int{3, 1, 2},
+		},
+	}
+	for _, tt := range tests {
+		got := orderedIDs(OrderTasks(tt.tasks))
+		if fmt.Sprint(got) != fmt.Sprint(tt.want) {
+			t.Errorf("%s: OrderTasks = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
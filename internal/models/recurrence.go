@@ -0,0 +1,221 @@
+package models
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/teambition/rrule-go"
+)
+
+var (
+	ErrInvalidRRule = errors.New("invalid recurrence rule")
+	ErrNotRecurring = errors.New("task is not recurring")
+)
+
+// MaxPendingOccurrences bounds how many occurrences of a series are created
+// at once, so that a long horizon cannot flood the task list.
+const MaxPendingOccurrences = 100
+
+// TaskSeries is a recurring task. Its occurrences are ordinary tasks,
+// created from the fields kept here at the times produced by the rule.
+type TaskSeries struct {
+	ID     int `db:"id"`
+	UserID int `db:"user_id"`
+	// RRule is an RFC 5545 RRULE without DTSTART, which is kept apart.
+	RRule   string    `db:"rrule"`
+	DTStart time.Time `db:"dtstart"`
+	// MaterializedUntil is the time of the latest occurrence created.
+	MaterializedUntil time.Time `db:"materialized_until"`
+
+	Title       string       `db:"title"`
+	Description string       `db:"description"`
+	Priority    TaskPriority `db:"priority"`
+	// DueOffset is the time in seconds from the scheduled time of an
+	// occurrence to its due date, nil if occurrences have no due date.
+	DueOffset *int64 `db:"due_offset"`
+}
+
+// SeriesPatch lists the changes to an occurrence and the occurrences
+// following it. Nil fields are left unchanged; an empty RRule stops the
+// series before the occurrence.
+type SeriesPatch struct {
+	Title        *string
+	Description  *string
+	Priority     *TaskPriority
+	ScheduledFor *time.Time
+	RRule        *string
+}
+
+// NormalizeRRule parses an RRULE, with or without the "RRULE:" prefix, and
+// returns it in canonical form. DTSTART comes from the task and may not be
+// part of the rule, and rules repeating more often than hourly are refused.
+func NormalizeRRule(rule string) (string, error) {
+	rule = strings.TrimPrefix(strings.ToUpper(strings.TrimSpace(rule)), "RRULE:")
+	if strings.Contains(rule, "DTSTART") || strings.Contains(rule, "\n") {
+		return "", fmt.Errorf("%w: DTSTART is taken from scheduled_for", ErrInvalidRRule)
+	}
+
+	option, err := rrule.StrToROption(rule)
+	if err != nil {
+		return "", fmt.Errorf("%w: %v", ErrInvalidRRule, err)
+	}
+	if option.Freq == rrule.MINUTELY || option.Freq == rrule.SECONDLY {
+		return "", fmt.Errorf("%w: tasks can repeat at most hourly", ErrInvalidRRule)
+	}
+	if _, err := rrule.NewRRule(*option); err != nil {
+		return "", fmt.Errorf("%w: %v", ErrInvalidRRule, err)
+	}
+
+	return option.RRuleString(), nil
+}
+
+// NewTaskSeries returns a series repeating task by rule from the time the
+// task is scheduled for, which becomes its first occurrence.
+func NewTaskSeries(task *Task, rule string) *TaskSeries {
+	series := &TaskSeries{
+		UserID:      task.UserID,
+		RRule:       rule,
+		DTStart:     task.ScheduledFor.Truncate(time.Second).UTC(),
+		Title:       task.Title,
+		Description: task.Description,
+		Priority:    task.Priority,
+	}
+	series.MaterializedUntil = series.DTStart
+	if task.DueAt != nil {
+		offset := int64(task.DueAt.Sub(task.ScheduledFor) / time.Second)
+		series.DueOffset = &offset
+	}
+	return series
+}
+
+// Apply copies the field changes of patch into the series, so that later
+// occurrences are created with them.
+func (s *TaskSeries) Apply(patch *SeriesPatch) {
+	if patch.Title != nil {
+		s.Title = *patch.Title
+	}
+	if patch.Description != nil {
+		s.Description = *patch.Description
+	}
+	if patch.Priority != nil {
+		s.Priority = *patch.Priority
+	}
+}
+
+// Occurrence returns a new task of the series scheduled at at.
+func (s *TaskSeries) Occurrence(at time.Time) Task {
+	seriesID := s.ID
+	task := Task{
+		Title:        s.Title,
+		Description:  s.Description,
+		ScheduledFor: at,
+		Priority:     s.Priority,
+		Status:       StatusTodo,
+		SeriesID:     &seriesID,
+		OccurrenceAt: &at,
+		RRule:        s.RRule,
+		UserID:       s.UserID,
+	}
+	if s.DueOffset != nil {
+		due := at.Add(time.Duration(*s.DueOffset) * time.Second)
+		task.DueAt = &due
+	}
+	return task
+}
+
+// Pending returns the occurrences after MaterializedUntil up to until.
+// Occurrences that were missed, lying in the past, are skipped. With next
+// set the first remaining one is returned even if it lies beyond until, so
+// that a series whose occurrences are all finished gets its next one. At
+// most MaxPendingOccurrences are returned.
+func (s *TaskSeries) Pending(until time.Time, next bool) ([]time.Time, error) {
+	r, err := s.rule()
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	var pending []time.Time
+	iterate := r.Iterator()
+	for len(pending) < MaxPendingOccurrences {
+		at, ok := iterate()
+		if !ok {
+			break
+		}
+		if !at.After(s.MaterializedUntil) || at.Before(now) {
+			continue
+		}
+		if at.After(until) && !(next && len(pending) == 0) {
+			break
+		}
+		pending = append(pending, at)
+	}
+
+	return pending, nil
+}
+
+// Split divides the rule of the series at the occurrence at. It returns
+// the rule of the occurrences before at, and the rule for a new series
+// starting at at. A COUNT limit is shared between the two.
+func (s *TaskSeries) Split(at time.Time) (before, after string, err error) {
+	r, err := s.rule()
+	if err != nil {
+		return "", "", err
+	}
+
+	head, tail := r.OrigOptions, r.OrigOptions
+	head.Count = 0
+	if head.Until.IsZero() || head.Until.After(at.Add(-time.Second)) {
+		head.Until = at.Add(-time.Second)
+	}
+	if r.OrigOptions.Count > 0 {
+		n := len(r.Between(s.DTStart.Add(-time.Second), at, false))
+		if n > 0 {
+			head.Count, head.Until = n, r.OrigOptions.Until
+		}
+		if tail.Count -= n; tail.Count < 1 {
+			tail.Count = 1
+		}
+	}
+
+	return head.RRuleString(), tail.RRuleString(), nil
+}
+
+// rule parses the RRULE of the series, anchored at its DTStart.
+func (s *TaskSeries) rule() (*rrule.RRule, error) {
+	option, err := rrule.StrToROption(s.RRule)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidRRule, err)
+	}
+	option.Dtstart = s.DTStart.UTC()
+	return rrule.NewRRule(*option)
+}
+
+// Reschedules reports whether patch moves the occurrences of task, so that
+// the ones following it have to be created anew.
+func (p *SeriesPatch) Reschedules(task *Task) bool {
+	return p.RRule != nil || p.ScheduledFor != nil && !p.ScheduledFor.Equal(task.ScheduledFor)
+}
+
+// ApplyTo writes the field changes of patch to task. Rescheduling the task
+// moves its due date along.
+func (p *SeriesPatch) ApplyTo(task *Task) {
+	if p.Title != nil {
+		task.Title = *p.Title
+	}
+	if p.Description != nil {
+		task.Description = *p.Description
+	}
+	if p.Priority != nil {
+		task.Priority = *p.Priority
+	}
+	if p.ScheduledFor != nil {
+		if task.DueAt != nil {
+			due := task.DueAt.Add(p.ScheduledFor.Sub(task.ScheduledFor))
+			task.DueAt = &due
+		}
+		task.ScheduledFor = *p.ScheduledFor
+	}
+}
@@ -0,0 +1,191 @@
+package models
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestNormalizeRRule(t *testing.T) {
+	tests := []struct {
+		rule string
+		want string
+	}{
+		{"FREQ=DAILY", "FREQ=DAILY"},
+		{"rrule:freq=weekly;byday=mo,we", "FREQ=WEEKLY;BYDAY=MO,WE"},
+		{"  RRULE:FREQ=MONTHLY;COUNT=3 ", "FREQ=MONTHLY;COUNT=3"},
+		{"FREQ=HOURLY;INTERVAL=2", "FREQ=HOURLY;INTERVAL=2"},
+	}
+	for _, tt := range tests {
+		got, err := NormalizeRRule(tt.rule)
+		if err != nil {
+			t.Errorf("NormalizeRRule(%q) failed: %v", tt.rule, err)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("NormalizeRRule(%q) = %q, want %q", tt.rule, got, tt.want)
+		}
+	}
+
+	for _, rule := range []string{
+		"",
+		"FREQ=SOMETIMES",
+		"FREQ=MINUTELY",
+		"FREQ=SECONDLY",
+		"DTSTART:20240101T000000Z\nRRULE:FREQ=DAILY",
+		"FREQ=DAILY;DTSTART=20240101T000000Z",
+		"FREQ=DAILY;BYDAY=XX",
+	} {
+		if _, err := NormalizeRRule(rule); !errors.Is(err, ErrInvalidRRule) {
+			t.Errorf("NormalizeRRule(%q) = %v, want ErrInvalidRRule", rule, err)
+		}
+	}
+}
+
+// upcoming returns a time on the hour at least an hour from now, so that
+// occurrences after it are not skipped as missed.
+func upcoming() time.Time {
+	return time.Now().UTC().Truncate(time.Hour).Add(2 * time.Hour)
+}
+
+func newDailySeries(rule string, start time.Time) *TaskSeries {
+	return NewTaskSeries(&Task{Title: "Daily", ScheduledFor: start}, rule)
+}
+
+func TestPending(t *testing.T) {
+	start := upcoming()
+	day := 24 * time.Hour
+
+	tests := []struct {
+		name     string
+		rule     string
+		until    time.Time
+		next     bool
+		want     int
+		wantLast time.Time
+	}{
+		{"horizon", "FREQ=DAILY", start.Add(3 * day), false, 3, start.Add(3 * day)},
+		{"nothing due", "FREQ=DAILY", start.Add(day / 2), false, 0, time.Time{}},
+		{"next beyond horizon", "FREQ=DAILY", start.Add(day / 2), true, 1, start.Add(day)},
+		{"count", "FREQ=DAILY;COUNT=3", start.Add(30 * day), false, 2, start.Add(2 * day)},
+		{"bounded", "FREQ=DAILY", start.Add(1000 * day), false, MaxPendingOccurrences, start.Add(MaxPendingOccurrences * day)},
+	}
+	for _, tt := range tests {
+		series := newDailySeries(tt.rule, start)
+		pending, err := series.Pending(tt.until, tt.next)
+		if err != nil {
+			t.Fatalf("%s: %v", tt.name, err)
+		}
+		if len(pending) != tt.want {
+			t.Errorf("%s: %d occurrences, want %d", tt.name, len(pending), tt.want)
+			continue
+		}
+		if tt.want > 0 && !pending[len(pending)-1].Equal(tt.wantLast) {
+			t.Errorf("%s: last occurrence %s, want %s", tt.name, pending[len(pending)-1], tt.wantLast)
+		}
+	}
+}
+
+func TestPendingSkipsMaterializedAndMissed(t *testing.T) {
+	day := 24 * time.Hour
+
+	series := newDailySeries("FREQ=DAILY", upcoming())
+	series.MaterializedUntil = series.DTStart.Add(2 * day)
+	pending, err := series.Pending(series.DTStart.Add(4*day), false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(pending) != 2 || !pending[0].Equal(series.DTStart.Add(3*day)) {
+		t.Errorf("pending = %v, want the days after MaterializedUntil", pending)
+	}
+
+	past := upcoming().Add(-10 * day)
+	series = newDailySeries("FREQ=DAILY", past)
+	pending, err = series.Pending(past.Add(12*day), false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, at := range pending {
+		if at.Before(time.Now()) {
+			t.Errorf("missed occurrence %s returned", at)
+		}
+	}
+	// The days from the upcoming hour on: 10, 11 and 12.
+	if len(pending) != 3 {
+		t.Errorf("%d occurrences, want 3", len(pending))
+	}
+}
+
+func TestSplit(t *testing.T) {
+	start := time.Date(2030, 1, 1, 9, 0, 0, 0, time.UTC)
+	at := start.Add(3 * 24 * time.Hour)
+
+	tests := []struct {
+		rule   string
+		before string
+		after  string
+	}{
+		{"FREQ=DAILY", "FREQ=DAILY;UNTIL=20300104T085959Z", "FREQ=DAILY"},
+		{"FREQ=DAILY;COUNT=10", "FREQ=DAILY;COUNT=3", "FREQ=DAILY;COUNT=7"},
+		{"FREQ=DAILY;COUNT=2", "FREQ=DAILY;COUNT=2", "FREQ=DAILY;COUNT=1"},
+		{"FREQ=DAILY;UNTIL=20300102T000000Z", "FREQ=DAILY;UNTIL=20300102T000000Z", "FREQ=DAILY;UNTIL=20300102T000000Z"},
+	}
+	for _, tt := range tests {
+		series := newDailySeries(tt.rule, start)
+		before, after, err := series.Split(at)
+		if err != nil {
+			t.Fatalf("Split(%q): %v", tt.rule, err)
+		}
+		if before != tt.before || after != tt.after {
+			t.Errorf("Split(%q) = %q, %q, want %q, %q", tt.rule, before, after, tt.before, tt.after)
+		}
+	}
+}
+
+func TestSeriesPatchApplyTo(t *testing.T) {
+	scheduled := time.Date(2030, 1, 1, 9, 0, 0, 0, time.UTC)
+	due := scheduled.Add(2 * time.Hour)
+	task := Task{Title: "Old", Description: "old", Priority: PriorityP2, ScheduledFor: scheduled, DueAt: &due}
+
+	title, priority := "New", PriorityP0
+	moved := scheduled.Add(24 * time.Hour)
+	patch := &SeriesPatch{Title: &title, Priority: &priority, ScheduledFor: &moved}
+	patch.ApplyTo(&task)
+
+	if task.Title != "New" || task.Description != "old" || task.Priority != PriorityP0 {
+		t.Errorf("fields after ApplyTo = %q, %q, %v", task.Title, task.Description, task.Priority)
+	}
+	if !task.ScheduledFor.Equal(moved) {
+		t.Errorf("scheduled_for = %s, want %s", task.ScheduledFor, moved)
+	}
+	if want := moved.Add(2 * time.Hour); task.DueAt == nil || !task.DueAt.Equal(want) {
+		t.Errorf("due_at = %v, want %s", task.DueAt, want)
+	}
+
+	undated := Task{ScheduledFor: scheduled}
+	patch.ApplyTo(&undated)
+	if undated.DueAt != nil {
+		t.Errorf("due_at = %v, want none", undated.DueAt)
+	}
+}
+
+func TestSeriesPatchReschedules(t *testing.T) {
+	scheduled := time.Date(2030, 1, 1, 9, 0, 0, 0, time.UTC)
+	task := &Task{ScheduledFor: scheduled}
+	same, later, rule := scheduled, scheduled.Add(time.Hour), "FREQ=WEEKLY"
+
+	tests := []struct {
+		patch SeriesPatch
+		want  bool
+	}{
+		{SeriesPatch{}, false},
+		{SeriesPatch{ScheduledFor: &same}, false},
+		{SeriesPatch{ScheduledFor: &later}, true},
+		{SeriesPatch{RRule: &rule}, true},
+	}
+	for i, tt := range tests {
+		if got := tt.patch.Reschedules(task); got != tt.want {
+			t.Errorf("case %d: Reschedules() = %v, want %v", i, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,30 @@
+package models
+
+import "time"
+
+// Reminder is fired for a task that is not finished when its scheduled
+// time, less the offset, arrives.
+type Reminder struct {
+	ID     int    `db:"id" json:"id"`
+	TaskID int    `db:"task_id" json:"task_id"`
+	UserID int    `db:"user_id" json:"-"`
+	Title  string `db:"title" json:"title"`
+	// Offset is the time in seconds between the reminder and the
+	// scheduled time of the task.
+	Offset   int64     `db:"remind_offset" json:"offset"`
+	RemindAt time.Time `db:"remind_at" json:"remind_at"`
+	FiredAt  time.Time `db:"fired_at" json:"fired_at"`
+}
+
+// ReminderKey identifies a reminder of a task. A task is reminded once per
+// key, so changing its scheduled time arms its reminders again.
+type ReminderKey struct {
+	TaskID   int
+	Offset   int64
+	RemindAt time.Time
+}
+
+// Key returns the key the reminder is fired once for.
+func (r *Reminder) Key() ReminderKey {
+	return ReminderKey{TaskID: r.TaskID, Offset: r.Offset, RemindAt: r.RemindAt}
+}
@@ -0,0 +1,97 @@
+package models
+
+import (
+	"errors"
+	"strings"
+	"unicode/utf8"
+)
+
+var (
+	ErrParentNotFound           = errors.New("parent task not found")
+	ErrTaskCycle                = errors.New("a task cannot be nested under itself or its subtasks")
+	ErrTaskTooDeep              = errors.New("subtasks are nested too deeply")
+	ErrChecklistItemNotFound    = errors.New("checklist item not found")
+	ErrInvalidChecklistOrder    = errors.New("checklist order must list every item of the task exactly once")
+	ErrInvalidChecklistItemText = errors.New("checklist item text must be 1 to 255 characters")
+)
+
+// MaxTaskDepth is the number of levels a task hierarchy may have, counting
+// the top-level task.
+const MaxTaskDepth = 5
+
+// MaxChecklistItemLength is the longest checklist item text, in characters.
+const MaxChecklistItemLength = 255
+
+// CheckParent validates moving task taskID, whose subtree is height levels
+// deep (1 for a task without subtasks), under a parent. ancestors lists the
+// parent and its own ancestors, nearest first.
+func CheckParent(taskID int, ancestors []int, height int) error {
+	for _, id := range ancestors {
+		if id == taskID {
+			return ErrTaskCycle
+		}
+	}
+	if len(ancestors)+height > MaxTaskDepth {
+		return ErrTaskTooDeep
+	}
+	return nil
+}
+
+// Progress returns the share of finished subtasks as a percentage.
+// Cancelled subtasks do not count. It returns nil for a task without
+// subtasks.
+func Progress(subtasks, done int) *int {
+	if subtasks == 0 {
+		return nil
+	}
+	p := done * 100 / subtasks
+	return &p
+}
+
+// ChecklistItem is a lightweight step of a task that, unlike a subtask,
+// has no status or dates of its own.
+// @Summary Checklist item
+// @Description Checklist item of a task with its text, completion and position.
+type ChecklistItem struct {
+	ID       int    `db:"id" json:"id"`
+	TaskID   int    `db:"task_id" json:"-"`
+	Text     string `db:"text" json:"text"`
+	Done     bool   `db:"done" json:"done"`
+	Position int    `db:"position" json:"position"`
+}
+
+// NormalizeChecklistItemText trims a checklist item text and checks its
+// length.
+func NormalizeChecklistItemText(text string) (string, error) {
+	text = strings.TrimSpace(text)
+	if text == "" || utf8.RuneCountInString(text) > MaxChecklistItemLength {
+		return "", ErrInvalidChecklistItemText
+	}
+	return text, nil
+}
+
+// ChecklistItemPatch lists the fields of a checklist item update. Nil
+// fields are left unchanged.
+type ChecklistItemPatch struct {
+	Text *string
+	Done *bool
+}
+
+// CheckChecklistOrder reports whether order holds each of ids exactly once.
+func CheckChecklistOrder(ids, order []int) error {
+	if len(ids) != len(order) {
+		return ErrInvalidChecklistOrder
+	}
+
+	seen := make(map[int]bool, len(ids))
+	for _, id := range ids {
+		seen[id] = false
+	}
+	for _, id := range order {
+		if done, ok := seen[id]; !ok || done {
+			return ErrInvalidChecklistOrder
+		}
+		seen[id] = true
+	}
+	return nil
+}
@@ -0,0 +1,65 @@
+package models
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestCheckParent(t *testing.T) {
+	tests := []struct {
+		name      string
+		taskID    int
+		ancestors []int
+		height    int
+		want      error
+	}{
+		{"new task under a top-level task", 0, []int{1}, 1, nil},
+		{"leaf under a top-level task", 5, []int{1}, 1, nil},
+		{"under itself", 5, []int{5}, 1, ErrTaskCycle},
+		{"under its grandchild", 5, []int{7, 6, 5}, 3, ErrTaskCycle},
+		{"deepest level", 0, []int{4, 3, 2, 1}, 1, nil},
+		{"below the deepest level", 0, []int{5, 4, 3, 2, 1}, 1, ErrTaskTooDeep},
+		{"subtree reaching the deepest level", 9, []int{2, 1}, 3, nil},
+		{"subtree going past the deepest level", 9, []int{3, 2, 1}, 3, ErrTaskTooDeep},
+	}
+	for _, tt := range tests {
+		if err := CheckParent(tt.taskID, tt.ancestors, tt.height); !errors.Is(err, tt.want) {
+			t.Errorf("%s: CheckParent = %v, want %v", tt.name, err, tt.want)
+		}
+	}
+
+	// A hierarchy has at most MaxTaskDepth levels.
+	ancestors := make([]int, MaxTaskDepth-1)
+	for i := range ancestors {
+		ancestors[i] = i + 1
+	}
+	if err := CheckParent(0, ancestors, 1); err != nil {
+		t.Errorf("level %d: %v", MaxTaskDepth, err)
+	}
+	if err := CheckParent(0, append(ancestors, MaxTaskDepth), 1); !errors.Is(err, ErrTaskTooDeep) {
+		t.Errorf("level %d: %v, want ErrTaskTooDeep", MaxTaskDepth+1, err)
+	}
+}
+
+func TestProgress(t *testing.T) {
+	if p := Progress(0, 0); p != nil {
+		t.Errorf("Progress(0, 0) = %d, want nil", *p)
+	}
+
+	tests := []struct {
+		subtasks, done, want int
+	}{
+		{1, 0, 0},
+		{1, 1, 100},
+		{2, 1, 50},
+		{3, 1, 33},
+		{3, 2, 66},
+		{3, 3, 100},
+	}
+	for _, tt := range tests {
+		p := Progress(tt.subtasks, tt.done)
+		if p == nil || *p != tt.want {
+			t.Errorf("Progress(%d, %d) = %v, want %d", tt.subtasks, tt.done, p, tt.want)
+		}
+	}
+}
@@ -0,0 +1,49 @@
+package models
+
+import (
+	"errors"
+	"strings"
+	"unicode/utf8"
+)
+
+var (
+	ErrTagNotFound    = errors.New("tag not found")
+	ErrTagExists      = errors.New("tag already exists")
+	ErrInvalidTagName = errors.New("tag name must be 1 to 64 characters")
+)
+
+// MaxTagNameLength is the longest tag name, in characters.
+const MaxTagNameLength = 64
+
+// Tag is a user-defined label that can be attached to any of the user's
+// tasks.
+// @Summary Tag details
+// @Description Tag with its ID and name.
+type Tag struct {
+	ID     int    `db:"id" json:"id"`
+	Name   string `db:"name" json:"name"`
+	UserID int    `db:"user_id" json:"-"`
+}
+
+// NormalizeTagName trims a tag name and checks its length.
+func NormalizeTagName(name string) (string, error) {
+	name = strings.TrimSpace(name)
+	if name == "" || utf8.RuneCountInString(name) > MaxTagNameLength {
+		return "", ErrInvalidTagName
+	}
+	return name, nil
+}
+
+// TagMode tells whether a tag filter matches tasks carrying all of the
+// given tags or any of them.
+type TagMode string
+
+const (
+	TagModeAll TagMode = "all"
+	TagModeAny TagMode = "any"
+)
+
+// Valid reports whether m is a known tag mode.
+func (m TagMode) Valid() bool {
+	return m == TagModeAll || m == TagModeAny
+}
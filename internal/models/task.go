@@ -1,10 +1,137 @@
 package models
 
-import "time"
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var (
+	ErrTaskNotFound    = errors.New("task not found")
+	ErrVersionConflict = errors.New("task was modified concurrently")
+)
+
+// TaskStatus is a stage of the task lifecycle.
+type TaskStatus string
+
+const (
+	StatusTodo       TaskStatus = "todo"
+	StatusInProgress TaskStatus = "in_progress"
+	StatusBlocked    TaskStatus = "blocked"
+	StatusDone       TaskStatus = "done"
+	StatusCancelled  TaskStatus = "cancelled"
+)
+
+// taskTransitions lists the statuses each status is allowed to move to.
+var taskTransitions = map[TaskStatus][]TaskStatus{
+	StatusTodo:       {StatusInProgress, StatusBlocked, StatusDone, StatusCancelled},
+	StatusInProgress: {StatusTodo, StatusBlocked, StatusDone, StatusCancelled},
+	StatusBlocked:    {StatusTodo, StatusInProgress, StatusCancelled},
+	StatusDone:       {StatusTodo},
+	StatusCancelled:  {StatusTodo},
+}
+
+// Valid reports whether s is a known status.
+func (s TaskStatus) Valid() bool {
+	_, ok := taskTransitions[s]
+	return ok
+}
+
+// CanTransitionTo reports whether a task in status s may be moved to next.
+func (s TaskStatus) CanTransitionTo(next TaskStatus) bool {
+	for _, allowed := range taskTransitions[s] {
+		if allowed == next {
+			return true
+		}
+	}
+
+	return false
+}
+
+// TaskPriority is the urgency of a task, from P0 (most urgent) to P4. It is
+// stored as a number and written as "P0".."P4" in JSON.
+type TaskPriority int
+
+const (
+	PriorityP0 TaskPriority = iota
+	PriorityP1
+	PriorityP2
+	PriorityP3
+	PriorityP4
+)
+
+// DefaultPriority is given to tasks created without a priority.
+const DefaultPriority = PriorityP2
+
+// Valid reports whether p is a known priority.
+func (p TaskPriority) Valid() bool {
+	return p >= PriorityP0 && p <= PriorityP4
+}
+
+func (p TaskPriority) String() string {
+	return "P" + strconv.Itoa(int(p))
+}
+
+// ParseTaskPriority parses "P0".."P4" (in either case) or a bare number.
+func ParseTaskPriority(s string) (TaskPriority, error) {
+	n, err := strconv.Atoi(strings.TrimPrefix(strings.ToUpper(s), "P"))
+	if err != nil || !TaskPriority(n).Valid() {
+		return 0, fmt.Errorf("unknown priority %q", s)
+	}
+	return TaskPriority(n), nil
+}
+
+func (p TaskPriority) MarshalJSON() ([]byte, error) {
+	return json.Marshal(p.String())
+}
+
+// UnmarshalJSON accepts both the "P1" form and a plain number. Null leaves
+// p unchanged.
+func (p *TaskPriority) UnmarshalJSON(data []byte) error {
+	if string(data) == "null" {
+		return nil
+	}
+
+	var s string
+	if err := json.Unmarshal(data, &s); err != nil {
+		var n int
+		if json.Unmarshal(data, &n) != nil {
+			return fmt.Errorf("invalid priority %s", data)
+		}
+		s = strconv.Itoa(n)
+	}
+
+	priority, err := ParseTaskPriority(s)
+	if err != nil {
+		return err
+	}
+	*p = priority
+	return nil
+}
+
+// NoDueDate stands in for a missing due date when ordering tasks, so that
+// tasks without one come after every dated task.
+var NoDueDate = time.Date(9999, time.December, 31, 0, 0, 0, 0, time.UTC)
+
+// NoDueDateDescending is NoDueDate for descending order. It precedes every
+// due date, so that undated tasks still come last.
+var NoDueDateDescending = time.Date(1, time.January, 1, 0, 0, 0, 0, time.UTC)
+
+// NoDueDateFor returns the stand-in for a missing due date in ascending or
+// descending order.
+func NoDueDateFor(descending bool) time.Time {
+	if descending {
+		return NoDueDateDescending
+	}
+	return NoDueDate
+}
 
 // Task represents a task in the system.
 // @Summary Task details
-// @Description Task details with ID, title, description, creation time, management time, and associated user ID.
+// @Description Task details with ID, title, description, creation time, management time, due date, priority, status, tags, parent task, checklist, dependencies, recurrence, and associated user ID. Overdue, progress and blocked are computed by the server.
 // @ID Task
 // @Produce json
 // @Param id path int true "Task ID"
@@ -12,23 +139,109 @@ import "time"
 // @Param description body string true "Task description"
 // @Param created_at body string true "Task creation time in RFC3339 format"
 // @Param scheduled_for body string true "Task management time in RFC3339 format"
+// @Param due_at body string false "Task due date in RFC3339 format"
+// @Param priority body string false "Task priority: P0 (most urgent) to P4, P2 by default"
+// @Param overdue body bool false "Whether the due date has passed on an unfinished task (read-only)"
+// @Param status body string false "Task status: todo, in_progress, blocked, done or cancelled"
+// @Param tags body []Tag false "Tags attached to the task (read-only, see /tasks/{id}/tags)"
+// @Param parent_id body int false "ID of the parent task"
+// @Param progress body int false "Percentage of finished subtasks (read-only)"
+// @Param checklist body []ChecklistItem false "Checklist items in order (read-only, see /tasks/{id}/checklist)"
+// @Param blocked_by body []int false "IDs of the tasks this task depends on (read-only, see /tasks/{id}/dependencies)"
+// @Param blocked body bool false "Whether any of those tasks is unfinished (read-only)"
+// @Param rrule body string false "RFC 5545 RRULE repeating the task from scheduled_for, set on creation (see /tasks/{id}/following)"
+// @Param series_id body int false "ID of the series of a recurring task (read-only)"
+// @Param occurrence_at body string false "Time the recurrence rule produced this occurrence for (read-only)"
+// @Param version body int false "Version incremented on every update"
 // @Param user_id body int true "User ID associated with the task"
 type Task struct {
-	ID           int       `db:"id" json:"id"`
-	Title        string    `db:"title" json:"title"`
-	Description  string    `db:"description" json:"description"`
-	CreatedAt    time.Time `db:"created_at" json:"created_at"`
-	ScheduledFor time.Time `db:"scheduled_for" json:"scheduled_for"`
-	UserID       int       `db:"user_id" json:"user_id"`
+	ID           int             `db:"id" json:"id"`
+	Title        string          `db:"title" json:"title"`
+	Description  string          `db:"description" json:"description"`
+	CreatedAt    time.Time       `db:"created_at" json:"created_at"`
+	ScheduledFor time.Time       `db:"scheduled_for" json:"scheduled_for"`
+	DueAt        *time.Time      `db:"due_at" json:"due_at,omitempty"`
+	Priority     TaskPriority    `db:"priority" json:"priority" swaggertype:"string" enums:"P0,P1,P2,P3,P4"`
+	Overdue      bool            `db:"-" json:"overdue"`
+	Status       TaskStatus      `db:"status" json:"status"`
+	Tags         []Tag           `db:"-" json:"tags"`
+	ParentID     *int            `db:"parent_id" json:"parent_id,omitempty"`
+	Progress     *int            `db:"-" json:"progress,omitempty"`
+	Checklist    []ChecklistItem `db:"-" json:"checklist"`
+	BlockedBy    []int           `db:"-" json:"blocked_by"`
+	Blocked      bool            `db:"-" json:"blocked"`
+	RRule        string          `db:"-" json:"rrule,omitempty"`
+	SeriesID     *int            `db:"series_id" json:"series_id,omitempty"`
+	OccurrenceAt *time.Time      `db:"occurrence_at" json:"occurrence_at,omitempty"`
+	Version      int             `db:"version" json:"version"`
+	UserID       int             `db:"user_id" json:"user_id"`
+}
+
+// IsOverdue reports whether the task is past its due date at now without
+// having been finished.
+func (t *Task) IsOverdue(now time.Time) bool {
+	if t.DueAt == nil || t.Status.Finished() {
+		return false
+	}
+	return t.DueAt.Before(now)
 }
 
-func NewTask(id int, title, description string, createdAt, scheduledFor time.Time, userId int, done bool) Task {
+// DueSortKey returns the due date tasks are ordered by in ascending or
+// descending order, NoDueDateFor that order if unset.
+func (t *Task) DueSortKey(descending bool) time.Time {
+	if t.DueAt == nil {
+		return NoDueDateFor(descending)
+	}
+	return *t.DueAt
+}
+
+// MarshalJSON fills in Overdue at the time the task is written out, so it is
+// never stale in a response.
+func (t Task) MarshalJSON() ([]byte, error) {
+	type task Task
+	v := task(t)
+	v.Overdue = t.IsOverdue(time.Now())
+	if v.Tags == nil {
+		v.Tags = []Tag{}
+	}
+	if v.Checklist == nil {
+		v.Checklist = []ChecklistItem{}
+	}
+	if v.BlockedBy == nil {
+		v.BlockedBy = []int{}
+	}
+	return json.Marshal(v)
+}
+
+func NewTask(id int, title, description string, createdAt, scheduledFor time.Time, userId int, status TaskStatus) Task {
 	return Task{
 		ID:           id,
 		Title:        title,
 		Description:  description,
 		CreatedAt:    createdAt,
 		ScheduledFor: scheduledFor,
+		Status:       status,
 		UserID:       userId,
 	}
 }
+
+// TaskPatch lists the fields of a partial task update. Nil fields are left
+// unchanged.
+type TaskPatch struct {
+	Title        *string
+	Description  *string
+	ScheduledFor *time.Time
+	DueAt        *time.Time
+	ClearDueAt   bool
+	Priority     *TaskPriority
+	ParentID     *int
+	ClearParent  bool
+	Status       *TaskStatus
+}
+
+// Empty reports whether the patch changes nothing.
+func (p *TaskPatch) Empty() bool {
+	return p.Title == nil && p.Description == nil && p.ScheduledFor == nil &&
+		p.DueAt == nil && !p.ClearDueAt && p.Priority == nil &&
+		p.ParentID == nil && !p.ClearParent && p.Status == nil
+}
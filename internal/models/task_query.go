@@ -0,0 +1,144 @@
+package models
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"strconv"
+	"time"
+)
+
+var ErrInvalidCursor = errors.New("invalid cursor")
+
+// TaskSortField is a task attribute GET /tasks can be ordered by.
+type TaskSortField string
+
+const (
+	SortByID           TaskSortField = "id"
+	SortByCreatedAt    TaskSortField = "created_at"
+	SortByScheduledFor TaskSortField = "scheduled_for"
+	SortByTitle        TaskSortField = "title"
+	// SortByPriority orders by priority, then due date with undated tasks
+	// last in either direction.
+	SortByPriority TaskSortField = "priority"
+)
+
+// Valid reports whether f is a known sort field.
+func (f TaskSortField) Valid() bool {
+	switch f {
+	case SortByID, SortByCreatedAt, SortByScheduledFor, SortByTitle, SortByPriority:
+		return true
+	}
+	return false
+}
+
+// TaskQuery selects, orders and paginates a user's tasks. Zero values mean
+// "no filter"; a zero Limit returns every matching task. Tags holds distinct
+// names, matched according to TagMode, all of them by default.
+type TaskQuery struct {
+	Limit  int
+	Cursor string
+
+	ScheduledFrom *time.Time
+	ScheduledTo   *time.Time
+	CreatedFrom   *time.Time
+	CreatedTo     *time.Time
+	Title         string
+	Statuses      []TaskStatus
+	Tags          []string
+	TagMode       TagMode
+
+	SortBy     TaskSortField
+	Descending bool
+}
+
+// TaskPage is one page of a task listing.
+// @Summary Page of tasks
+// @Description Page of tasks with the cursor of the next page and the number of tasks matching the filters.
+type TaskPage struct {
+	Tasks      []Task `json:"tasks"`
+	NextCursor string `json:"next_cursor,omitempty"`
+	Total      int    `json:"total"`
+}
+
+// TaskCursor is the decoded form of the opaque cursor handed to clients.
+// It holds the sort key of the last task of a page; Value is the key
+// formatted as text (RFC 3339 for timestamps). Ordering by priority also
+// keeps the due date sort key in Due.
+type TaskCursor struct {
+	SortBy     TaskSortField `json:"s"`
+	Descending bool          `json:"d,omitempty"`
+	Value      string        `json:"v,omitempty"`
+	Due        string        `json:"due,omitempty"`
+	ID         int           `json:"id"`
+}
+
+// NewTaskCursor returns the cursor pointing past task in the order of q.
+func NewTaskCursor(q *TaskQuery, task *Task) TaskCursor {
+	c := TaskCursor{
+		SortBy:     q.SortBy,
+		Descending: q.Descending,
+		ID:         task.ID,
+	}
+
+	switch q.SortBy {
+	case SortByCreatedAt:
+		c.Value = task.CreatedAt.Format(time.RFC3339Nano)
+	case SortByScheduledFor:
+		c.Value = task.ScheduledFor.Format(time.RFC3339Nano)
+	case SortByTitle:
+		c.Value = task.Title
+	case SortByPriority:
+		c.Value = strconv.Itoa(int(task.Priority))
+		c.Due = task.DueSortKey(q.Descending).Format(time.RFC3339Nano)
+	}
+
+	return c
+}
+
+func (c TaskCursor) Encode() string {
+	b, _ := json.Marshal(c)
+	return base64.RawURLEncoding.EncodeToString(b)
+}
+
+// Time returns Value parsed as a timestamp.
+func (c TaskCursor) Time() (time.Time, error) {
+	t, err := time.Parse(time.RFC3339Nano, c.Value)
+	if err != nil {
+		return time.Time{}, ErrInvalidCursor
+	}
+	return t, nil
+}
+
+// Priority returns the priority and due date sort keys of a cursor issued
+// for SortByPriority.
+func (c TaskCursor) Priority() (TaskPriority, time.Time, error) {
+	n, err := strconv.Atoi(c.Value)
+	if err != nil || !TaskPriority(n).Valid() {
+		return 0, time.Time{}, ErrInvalidCursor
+	}
+	due, err := time.Parse(time.RFC3339Nano, c.Due)
+	if err != nil {
+		return 0, time.Time{}, ErrInvalidCursor
+	}
+	return TaskPriority(n), due, nil
+}
+
+// DecodeTaskCursor parses an encoded cursor and checks that it was issued
+// for the same ordering as q.
+func DecodeTaskCursor(q *TaskQuery, s string) (TaskCursor, error) {
+	var c TaskCursor
+
+	b, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return c, ErrInvalidCursor
+	}
+	if err := json.Unmarshal(b, &c); err != nil {
+		return c, ErrInvalidCursor
+	}
+	if c.SortBy != q.SortBy || c.Descending != q.Descending {
+		return c, ErrInvalidCursor
+	}
+
+	return c, nil
+}
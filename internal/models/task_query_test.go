@@ -0,0 +1,82 @@
+package models
+
+import (
+	"encoding/base64"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestTaskCursorRoundTrip(t *testing.T) {
+	created := time.Date(2030, 1, 1, 9, 0, 0, 123456789, time.UTC)
+	due := created.Add(48 * time.Hour)
+	task := &Task{ID: 7, Title: "Write, tests", CreatedAt: created, ScheduledFor: created.Add(time.Hour), Priority: PriorityP1, DueAt: &due}
+
+	for _, sortBy := range []TaskSortField{SortByID, SortByCreatedAt, SortByScheduledFor, SortByTitle, SortByPriority} {
+		for _, descending := range []bool{false, true} {
+			q := &TaskQuery{SortBy: sortBy, Descending: descending}
+			want := NewTaskCursor(q, task)
+
+			got, err := DecodeTaskCursor(q, want.Encode())
+			if err != nil {
+				t.Errorf("%s desc=%v: %v", sortBy, descending, err)
+				continue
+			}
+			if got != want {
+				t.Errorf("%s desc=%v: decoded %+v, want %+v", sortBy, descending, got, want)
+			}
+		}
+	}
+
+	q := &TaskQuery{SortBy: SortByCreatedAt}
+	if at, err := NewTaskCursor(q, task).Time(); err != nil || !at.Equal(created) {
+		t.Errorf("created_at cursor time = %s, %v, want %s", at, err, created)
+	}
+
+	q = &TaskQuery{SortBy: SortByPriority}
+	priority, dueKey, err := NewTaskCursor(q, task).Priority()
+	if err != nil || priority != PriorityP1 || !dueKey.Equal(due) {
+		t.Errorf("priority cursor = %v, %s, %v, want P1, %s", priority, dueKey, err, due)
+	}
+
+	for _, descending := range []bool{false, true} {
+		q := &TaskQuery{SortBy: SortByPriority, Descending: descending}
+		_, dueKey, err := NewTaskCursor(q, &Task{Priority: PriorityP3}).Priority()
+		if err != nil || !dueKey.Equal(NoDueDateFor(descending)) {
+			t.Errorf("undated priority cursor, desc=%v: due %s, %v", descending, dueKey, err)
+		}
+	}
+}
+
+func TestDecodeTaskCursorRejects(t *testing.T) {
+	q := &TaskQuery{SortBy: SortByTitle}
+	valid := NewTaskCursor(q, &Task{ID: 1, Title: "a"}).Encode()
+
+	tests := []struct {
+		name   string
+		query  *TaskQuery
+		cursor string
+	}{
+		{"not base64", q, "%%%"},
+		{"not JSON", q, base64.RawURLEncoding.EncodeToString([]byte("title"))},
+		{"other sort field", &TaskQuery{SortBy: SortByID}, valid},
+		{"other order", &TaskQuery{SortBy: SortByTitle, Descending: true}, valid},
+	}
+	for _, tt := range tests {
+		if _, err := DecodeTaskCursor(tt.query, tt.cursor); !errors.Is(err, ErrInvalidCursor) {
+			t.Errorf("%s: err = %v, want ErrInvalidCursor", tt.name, err)
+		}
+	}
+
+	for _, c := range []TaskCursor{
+		{SortBy: SortByPriority, Value: "9", Due: time.Now().Format(time.RFC3339Nano)},
+		{SortBy: SortByPriority, Value: "1", Due: "tomorrow"},
+	} {
+		if _, _, err := c.Priority(); !errors.Is(err, ErrInvalidCursor) {
+			t.Errorf("Priority() of %+v = %v, want ErrInvalidCursor", c, err)
+		}
+	}
+	if _, err := (TaskCursor{SortBy: SortByCreatedAt, Value: "yesterday"}).Time(); !errors.Is(err, ErrInvalidCursor) {
+		t.Errorf("Time() of a bad value = %v, want ErrInvalidCursor", err)
+	}
+}
@@ -0,0 +1,38 @@
+package models
+
+import "testing"
+
+func TestTaskStatusTransitions(t *testing.T) {
+	statuses := []TaskStatus{StatusTodo, StatusInProgress, StatusBlocked, StatusDone, StatusCancelled}
+	allowed := map[TaskStatus][]TaskStatus{
+		StatusTodo:       {StatusInProgress, StatusBlocked, StatusDone, StatusCancelled},
+		StatusInProgress: {StatusTodo, StatusBlocked, StatusDone, StatusCancelled},
+		StatusBlocked:    {StatusTodo, StatusInProgress, StatusCancelled},
+		StatusDone:       {StatusTodo},
+		StatusCancelled:  {StatusTodo},
+	}
+
+	for _, from := range statuses {
+		if !from.Valid() {
+			t.Errorf("%s is not valid", from)
+		}
+		for _, to := range statuses {
+			want := false
+			for _, next := range allowed[from] {
+				want = want || next == to
+			}
+			if got := from.CanTransitionTo(to); got != want {
+				t.Errorf("%s.CanTransitionTo(%s) = %v, want %v", from, to, got, want)
+			}
+		}
+	}
+
+	for _, status := range []TaskStatus{"", "archived", "Todo"} {
+		if status.Valid() {
+			t.Errorf("%q is valid", status)
+		}
+		if StatusTodo.CanTransitionTo(status) || status.CanTransitionTo(StatusTodo) {
+			t.Errorf("transition with %q allowed", status)
+		}
+	}
+}
@@ -1,5 +1,9 @@
 package models
 
+import "errors"
+
+var ErrUserNotFound = errors.New("user not found")
+
 type User struct {
 	ID       int    `json:"id"`
 	Username string `json:"username"`
@@ -0,0 +1,133 @@
+package models
+
+import (
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+)
+
+var (
+	ErrWebhookNotFound = errors.New("webhook not found")
+	ErrInvalidWebhook  = errors.New("invalid webhook")
+)
+
+// EventType names a change delivered to webhooks.
+type EventType string
+
+const (
+	EventTaskCreated   EventType = "task.created"
+	EventTaskUpdated   EventType = "task.updated"
+	EventTaskDeleted   EventType = "task.deleted"
+	EventTaskCompleted EventType = "task.completed"
+	EventTaskReminder  EventType = "task.reminder"
+)
+
+// Valid reports whether t is a known event type.
+func (t EventType) Valid() bool {
+	switch t {
+	case EventTaskCreated, EventTaskUpdated, EventTaskDeleted, EventTaskCompleted, EventTaskReminder:
+		return true
+	}
+	return false
+}
+
+// EventFilter lists the events a webhook receives; an empty filter
+// receives all of them. It is stored as a comma-separated list.
+type EventFilter []EventType
+
+// Matches reports whether an event of type t passes the filter.
+func (f EventFilter) Matches(t EventType) bool {
+	if len(f) == 0 {
+		return true
+	}
+	for _, e := range f {
+		if e == t {
+			return true
+		}
+	}
+	return false
+}
+
+// Value implements driver.Valuer.
+func (f EventFilter) Value() (driver.Value, error) {
+	events := make([]string, len(f))
+	for i, e := range f {
+		events[i] = string(e)
+	}
+	return strings.Join(events, ","), nil
+}
+
+// Scan implements sql.Scanner.
+func (f *EventFilter) Scan(src interface{}) error {
+	var s string
+	switch v := src.(type) {
+	case string:
+		s = v
+	case []byte:
+		s = string(v)
+	case nil:
+	default:
+		return fmt.Errorf("cannot scan %T into EventFilter", src)
+	}
+
+	*f = EventFilter{}
+	for _, e := range strings.Split(s, ",") {
+		if e != "" {
+			*f = append(*f, EventType(e))
+		}
+	}
+	return nil
+}
+
+// Webhook is a user's subscription to task events, delivered by POST to
+// URL and signed with Secret.
+// @Summary Webhook details
+// @Description Webhook subscription. The secret is only returned when the webhook is created.
+type Webhook struct {
+	ID        int         `db:"id" json:"id"`
+	UserID    int         `db:"user_id" json:"-"`
+	URL       string      `db:"url" json:"url"`
+	Secret    string      `db:"secret" json:"secret,omitempty"`
+	Events    EventFilter `db:"events" json:"events" swaggertype:"array,string"`
+	CreatedAt time.Time   `db:"created_at" json:"created_at"`
+}
+
+// Validate checks the URL and the event filter of a webhook.
+func (w *Webhook) Validate() error {
+	u, err := url.Parse(w.URL)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return fmt.Errorf("%w: url must be an absolute http or https URL", ErrInvalidWebhook)
+	}
+
+	for _, e := range w.Events {
+		if !e.Valid() {
+			return fmt.Errorf("%w: unknown event %q", ErrInvalidWebhook, e)
+		}
+	}
+	return nil
+}
+
+// Event is the payload delivered to webhooks.
+type Event struct {
+	Type       EventType `json:"event"`
+	OccurredAt time.Time `json:"occurred_at"`
+	Task       *Task     `json:"task,omitempty"`
+	Reminder   *Reminder `json:"reminder,omitempty"`
+}
+
+// WebhookDelivery is an event waiting in the outbox to be delivered to a
+// webhook.
+type WebhookDelivery struct {
+	ID        int       `db:"id"`
+	WebhookID int       `db:"webhook_id"`
+	URL       string    `db:"url"`
+	Secret    string    `db:"secret"`
+	Event     EventType `db:"event"`
+	Payload   []byte    `db:"payload"`
+	// Attempts counts the deliveries tried so far, the current one
+	// included.
+	Attempts int `db:"attempts"`
+}
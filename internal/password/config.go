@@ -0,0 +1,21 @@
+package password
+
+import "golang.org/x/crypto/bcrypt"
+
+type Config struct {
+	Algorithm     string
+	BcryptCost    int
+	Argon2Time    uint32
+	Argon2Memory  uint32
+	Argon2Threads uint8
+}
+
+func NewConfig() *Config {
+	return &Config{
+		Algorithm:     Bcrypt,
+		BcryptCost:    bcrypt.DefaultCost,
+		Argon2Time:    1,
+		Argon2Memory:  64 * 1024,
+		Argon2Threads: 4,
+	}
+}
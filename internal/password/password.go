@@ -0,0 +1,172 @@
+package password
+
+import (
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"strings"
+
+	"golang.org/x/crypto/argon2"
+	"golang.org/x/crypto/bcrypt"
+)
+
+const (
+	Bcrypt   = "bcrypt"
+	Argon2id = "argon2id"
+
+	argon2SaltLen = 16
+	argon2KeyLen  = 32
+)
+
+// MaxLength is the longest password in bytes that can be hashed. It is
+// the limit of bcrypt, also kept for argon2id so that switching
+// algorithms does not lock users out.
+const MaxLength = 72
+
+var (
+	ErrUnknownAlgorithm = errors.New("unknown password hashing algorithm")
+	ErrTooLong          = fmt.Errorf("password must be at most %d bytes", MaxLength)
+)
+
+type Hasher struct {
+	config *Config
+}
+
+func New(config *Config) (*Hasher, error) {
+	switch config.Algorithm {
+	case Bcrypt:
+		if config.BcryptCost < bcrypt.MinCost || config.BcryptCost > bcrypt.MaxCost {
+			return nil, fmt.Errorf("bcrypt cost must be between %d and %d", bcrypt.MinCost, bcrypt.MaxCost)
+		}
+	case Argon2id:
+		if config.Argon2Time == 0 || config.Argon2Memory == 0 || config.Argon2Threads == 0 {
+			return nil, errors.New("argon2 time, memory and threads must be positive")
+		}
+	default:
+		return nil, ErrUnknownAlgorithm
+	}
+
+	return &Hasher{
+		config: config,
+	}, nil
+}
+
+// Hash returns a salted hash of password using the configured algorithm.
+func (h *Hasher) Hash(password string) (string, error) {
+	if len(password) > MaxLength {
+		return "", ErrTooLong
+	}
+	if h.config.Algorithm == Argon2id {
+		return h.hashArgon2(password)
+	}
+
+	hash, err := bcrypt.GenerateFromPassword([]byte(password), h.config.BcryptCost)
+	if err != nil {
+		return "", err
+	}
+	return string(hash), nil
+}
+
+// Verify compares password with a stored hash. needsRehash is true when the
+// stored value was produced with another algorithm or cost, or is a legacy
+// plaintext password, and should be replaced with a fresh Hash.
+func (h *Hasher) Verify(hash, password string) (ok bool, needsRehash bool, err error) {
+	switch {
+	case strings.HasPrefix(hash, "$argon2id$"):
+		ok, params, err := verifyArgon2(hash, password)
+		if err != nil || !ok {
+			return false, false, err
+		}
+		return true, h.config.Algorithm != Argon2id || params != h.argon2Params(), nil
+
+	case isBcrypt(hash):
+		err := bcrypt.CompareHashAndPassword([]byte(hash), []byte(password))
+		if errors.Is(err, bcrypt.ErrMismatchedHashAndPassword) {
+			return false, false, nil
+		}
+		if err != nil {
+			return false, false, err
+		}
+		cost, err := bcrypt.Cost([]byte(hash))
+		if err != nil {
+			return false, false, err
+		}
+		return true, h.config.Algorithm != Bcrypt || cost != h.config.BcryptCost, nil
+
+	default:
+		// Rows written before passwords were hashed hold the plaintext.
+		ok := subtle.ConstantTimeCompare([]byte(hash), []byte(password)) == 1
+		return ok, ok, nil
+	}
+}
+
+type argon2Params struct {
+	time    uint32
+	memory  uint32
+	threads uint8
+}
+
+func (h *Hasher) argon2Params() argon2Params {
+	return argon2Params{
+		time:    h.config.Argon2Time,
+		memory:  h.config.Argon2Memory,
+		threads: h.config.Argon2Threads,
+	}
+}
+
+func (h *Hasher) hashArgon2(password string) (string, error) {
+	salt := make([]byte, argon2SaltLen)
+	if _, err := rand.Read(salt); err != nil {
+		return "", err
+	}
+
+	p := h.argon2Params()
+	key := argon2.IDKey([]byte(password), salt, p.time, p.memory, p.threads, argon2KeyLen)
+
+	return fmt.Sprintf("$argon2id$v=%d$m=%d,t=%d,p=%d$%s$%s",
+		argon2.Version, p.memory, p.time, p.threads,
+		base64.RawStdEncoding.EncodeToString(salt),
+		base64.RawStdEncoding.EncodeToString(key)), nil
+}
+
+func verifyArgon2(hash, password string) (bool, argon2Params, error) {
+	var p argon2Params
+
+	parts := strings.Split(hash, "$")
+	if len(parts) != 6 {
+		return false, p, errors.New("malformed argon2id hash")
+	}
+
+	var version int
+	if _, err := fmt.Sscanf(parts[2], "v=%d", &version); err != nil {
+		return false, p, err
+	}
+	if version != argon2.Version {
+		return false, p, fmt.Errorf("unsupported argon2 version %d", version)
+	}
+
+	if _, err := fmt.Sscanf(parts[3], "m=%d,t=%d,p=%d", &p.memory, &p.time, &p.threads); err != nil {
+		return false, p, err
+	}
+
+	salt, err := base64.RawStdEncoding.DecodeString(parts[4])
+	if err != nil {
+		return false, p, err
+	}
+	key, err := base64.RawStdEncoding.DecodeString(parts[5])
+	if err != nil {
+		return false, p, err
+	}
+
+	other := argon2.IDKey([]byte(password), salt, p.time, p.memory, p.threads, uint32(len(key)))
+
+	return subtle.ConstantTimeCompare(key, other) == 1, p, nil
+}
+
+func isBcrypt(hash string) bool {
+	return strings.HasPrefix(hash, "$2a$") ||
+		strings.HasPrefix(hash, "$2b$") ||
+		strings.HasPrefix(hash, "$2y$")
+}
@@ -0,0 +1,152 @@
+package password
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"golang.org/x/crypto/bcrypt"
+)
+
+func newTestHasher(t *testing.T, algorithm string, tweak func(*Config)) *Hasher {
+	t.Helper()
+
+	config := NewConfig()
+	config.Algorithm = algorithm
+	config.BcryptCost = bcrypt.MinCost
+	config.Argon2Memory = 1024
+	config.Argon2Threads = 1
+	if tweak != nil {
+		tweak(config)
+	}
+
+	h, err := New(config)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return h
+}
+
+func TestHashAndVerify(t *testing.T) {
+	for _, algorithm := range []string{Bcrypt, Argon2id} {
+		h := newTestHasher(t, algorithm, nil)
+
+		hash, err := h.Hash("secret")
+		if err != nil {
+			t.Fatalf("%s: %v", algorithm, err)
+		}
+		if hash == "secret" || !strings.HasPrefix(hash, "$") {
+			t.Errorf("%s: hash = %q", algorithm, hash)
+		}
+		if other, _ := h.Hash("secret"); other == hash {
+			t.Errorf("%s: hashes are not salted", algorithm)
+		}
+
+		ok, needsRehash, err := h.Verify(hash, "secret")
+		if err != nil || !ok || needsRehash {
+			t.Errorf("%s: Verify(right password) = %v, %v, %v, want true, false, nil", algorithm, ok, needsRehash, err)
+		}
+		ok, needsRehash, err = h.Verify(hash, "wrong")
+		if err != nil || ok || needsRehash {
+			t.Errorf("%s: Verify(wrong password) = %v, %v, %v, want false, false, nil", algorithm, ok, needsRehash, err)
+		}
+	}
+}
+
+func TestVerifyNeedsRehash(t *testing.T) {
+	bcryptHasher := newTestHasher(t, Bcrypt, nil)
+	argonHasher := newTestHasher(t, Argon2id, nil)
+
+	bcryptHash, err := bcryptHasher.Hash("secret")
+	if err != nil {
+		t.Fatal(err)
+	}
+	argonHash, err := argonHasher.Hash("secret")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name   string
+		hasher *Hasher
+		hash   string
+	}{
+		{"bcrypt hash, argon2id configured", argonHasher, bcryptHash},
+		{"argon2id hash, bcrypt configured", bcryptHasher, argonHash},
+		{"bcrypt cost raised", newTestHasher(t, Bcrypt, func(c *Config) { c.BcryptCost++ }), bcryptHash},
+		{"argon2 time raised", newTestHasher(t, Argon2id, func(c *Config) { c.Argon2Time++ }), argonHash},
+		{"argon2 memory raised", newTestHasher(t, Argon2id, func(c *Config) { c.Argon2Memory *= 2 }), argonHash},
+	}
+	for _, tt := range tests {
+		ok, needsRehash, err := tt.hasher.Verify(tt.hash, "secret")
+		if err != nil || !ok || !needsRehash {
+			t.Errorf("%s: Verify = %v, %v, %v, want true, true, nil", tt.name, ok, needsRehash, err)
+		}
+
+		// A wrong password never asks for a rehash.
+		if ok, needsRehash, _ := tt.hasher.Verify(tt.hash, "wrong"); ok || needsRehash {
+			t.Errorf("%s: Verify(wrong password) = %v, %v", tt.name, ok, needsRehash)
+		}
+	}
+}
+
+func TestVerifyLegacyPlaintext(t *testing.T) {
+	h := newTestHasher(t, Bcrypt, nil)
+
+	ok, needsRehash, err := h.Verify("secret", "secret")
+	if err != nil || !ok || !needsRehash {
+		t.Errorf("Verify(plaintext, right password) = %v, %v, %v, want true, true, nil", ok, needsRehash, err)
+	}
+
+	for _, password := range []string{"wrong", "secre", "secret!", ""} {
+		ok, needsRehash, err := h.Verify("secret", password)
+		if err != nil || ok || needsRehash {
+			t.Errorf("Verify(plaintext, %q) = %v, %v, %v, want false, false, nil", password, ok, needsRehash, err)
+		}
+	}
+}
+
+func TestVerifyMalformed(t *testing.T) {
+	h := newTestHasher(t, Argon2id, nil)
+
+	for _, hash := range []string{
+		"$argon2id$v=19$m=1024,t=1,p=1$c2FsdA",
+		"$argon2id$v=18$m=1024,t=1,p=1$c2FsdA$a2V5",
+		"$argon2id$v=19$m=1024,t=1,p=1$!!!$a2V5",
+		"$2a$04$short",
+	} {
+		if ok, _, err := h.Verify(hash, "secret"); ok || err == nil {
+			t.Errorf("Verify(%q) = %v, %v, want an error", hash, ok, err)
+		}
+	}
+}
+
+func TestHashTooLong(t *testing.T) {
+	h := newTestHasher(t, Bcrypt, nil)
+
+	if _, err := h.Hash(strings.Repeat("x", MaxLength)); err != nil {
+		t.Errorf("Hash of %d bytes: %v", MaxLength, err)
+	}
+	if _, err := h.Hash(strings.Repeat("x", MaxLength+1)); !errors.Is(err, ErrTooLong) {
+		t.Errorf("Hash of %d bytes = %v, want ErrTooLong", MaxLength+1, err)
+	}
+}
+
+func TestNewRejectsBadConfig(t *testing.T) {
+	tests := []struct {
+		name  string
+		tweak func(*Config)
+	}{
+		{"unknown algorithm", func(c *Config) { c.Algorithm = "md5" }},
+		{"bcrypt cost too low", func(c *Config) { c.BcryptCost = bcrypt.MinCost - 1 }},
+		{"bcrypt cost too high", func(c *Config) { c.BcryptCost = bcrypt.MaxCost + 1 }},
+		{"argon2 without memory", func(c *Config) { c.Algorithm, c.Argon2Memory = Argon2id, 0 }},
+	}
+	for _, tt := range tests {
+		config := NewConfig()
+		tt.tweak(config)
+		if _, err := New(config); err == nil {
+			t.Errorf("%s: New succeeded", tt.name)
+		}
+	}
+}
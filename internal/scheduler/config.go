@@ -0,0 +1,24 @@
+package scheduler
+
+import "time"
+
+type Config struct {
+	Enabled bool `toml:"enabled"`
+	// Interval is how often the scheduler looks for due reminders.
+	Interval time.Duration `toml:"interval"`
+	// Offsets lists how long before the scheduled time of a task its
+	// reminders fire; "0s" fires one at the scheduled time.
+	Offsets []time.Duration `toml:"offsets"`
+	// CatchUp bounds how late a reminder is still fired, e.g. after no
+	// instance was running at its time. Older reminders are skipped.
+	CatchUp time.Duration `toml:"catch_up"`
+}
+
+func NewConfig() *Config {
+	return &Config{
+		Enabled:  true,
+		Interval: 30 * time.Second,
+		Offsets:  []time.Duration{15 * time.Minute, 0},
+		CatchUp:  time.Hour,
+	}
+}
@@ -0,0 +1,142 @@
+// Package scheduler fires reminders for tasks as their scheduled time
+// approaches and creates the upcoming occurrences of recurring tasks.
+package scheduler
+
+import (
+	"TaskManager/internal/models"
+	"context"
+	"errors"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+type Storage interface {
+	FireDueReminders(ctx context.Context, offsets []time.Duration, from, to time.Time) ([]models.Reminder, error)
+	MaterializeDueOccurrences(ctx context.Context, until time.Time) ([]models.Task, error)
+}
+
+// Handler is called for every reminder fired.
+type Handler func(ctx context.Context, reminder *models.Reminder)
+
+// OccurrenceHandler is called for every occurrence of a recurring task
+// created.
+type OccurrenceHandler func(ctx context.Context, task *models.Task)
+
+// Scheduler polls the storage for due reminders. The storage records
+// which reminders were fired, so they are not repeated after a restart or
+// by another instance sharing the database.
+type Scheduler struct {
+	config   *Config
+	storage  Storage
+	logger   logrus.FieldLogger
+	handlers []Handler
+
+	// materialize is set by Materialize.
+	materialize        bool
+	horizon            time.Duration
+	occurrenceHandlers []OccurrenceHandler
+}
+
+func New(config *Config, storage Storage, logger logrus.FieldLogger) (*Scheduler, error) {
+	if config == nil {
+		return nil, errors.New("Config is nil")
+	}
+	if config.Interval <= 0 {
+		return nil, errors.New("scheduler interval must be positive")
+	}
+
+	return &Scheduler{
+		config:  config,
+		storage: storage,
+		logger:  logger,
+	}, nil
+}
+
+// Handle registers h to be called for every reminder fired.
+func (s *Scheduler) Handle(h Handler) {
+	s.handlers = append(s.handlers, h)
+}
+
+// Materialize makes the scheduler also create the occurrences of
+// recurring tasks up to horizon ahead, so that series nobody changes keep
+// getting new ones. h is called for every occurrence created.
+func (s *Scheduler) Materialize(horizon time.Duration, h OccurrenceHandler) {
+	s.materialize = true
+	s.horizon = horizon
+	s.occurrenceHandlers = append(s.occurrenceHandlers, h)
+}
+
+// Run fires the due reminders, and creates the due occurrences if enabled,
+// every interval until ctx is done.
+func (s *Scheduler) Run(ctx context.Context) {
+	ticker := time.NewTicker(s.config.Interval)
+	defer ticker.Stop()
+
+	for {
+		now := time.Now().UTC()
+		// Occurrences come first, so that those created at their
+		// scheduled time still get their reminders.
+		if s.materialize {
+			s.createOccurrences(ctx, now)
+		}
+		s.fire(ctx, now)
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// fire fires the reminders due at now.
+func (s *Scheduler) fire(ctx context.Context, now time.Time) {
+	// Look back at least one interval, so that a slow poll does not skip
+	// the reminders due in between.
+	lookback := s.config.CatchUp
+	if lookback < s.config.Interval {
+		lookback = s.config.Interval
+	}
+
+	reminders, err := s.storage.FireDueReminders(ctx, s.config.Offsets, now.Add(-lookback), now)
+	if err != nil {
+		s.logger.Error("Error firing reminders: ", err)
+		return
+	}
+
+	for i := range reminders {
+		reminder := &reminders[i]
+		s.logger.WithFields(logrus.Fields{
+			"task_id":   reminder.TaskID,
+			"user_id":   reminder.UserID,
+			"remind_at": reminder.RemindAt,
+		}).Info("Reminder: ", reminder.Title)
+
+		for _, h := range s.handlers {
+			h(ctx, reminder)
+		}
+	}
+}
+
+// createOccurrences creates the occurrences due by now plus the horizon.
+func (s *Scheduler) createOccurrences(ctx context.Context, now time.Time) {
+	tasks, err := s.storage.MaterializeDueOccurrences(ctx, now.Add(s.horizon))
+	if err != nil {
+		s.logger.Error("Error creating occurrences: ", err)
+		return
+	}
+
+	for i := range tasks {
+		task := &tasks[i]
+		s.logger.WithFields(logrus.Fields{
+			"task_id":       task.ID,
+			"user_id":       task.UserID,
+			"scheduled_for": task.ScheduledFor,
+		}).Debug("Occurrence created: ", task.Title)
+
+		for _, h := range s.occurrenceHandlers {
+			h(ctx, task)
+		}
+	}
+}
@@ -0,0 +1,72 @@
+package scheduler
+
+import (
+	"TaskManager/internal/models"
+	"TaskManager/internal/storage/memory"
+	"context"
+	"io"
+	"testing"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+func newTestScheduler(t *testing.T, storage Storage) *Scheduler {
+	t.Helper()
+
+	logger := logrus.New()
+	logger.SetOutput(io.Discard)
+
+	s, err := New(NewConfig(), storage, logger)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return s
+}
+
+func TestCreateOccurrences(t *testing.T) {
+	ctx := context.Background()
+	storage := memory.New()
+	userID, err := storage.CreateUser(ctx, "alice", "hash")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	start := time.Now().UTC().Truncate(time.Hour).Add(time.Hour)
+	task := &models.Task{Title: "Daily", ScheduledFor: start, RRule: "FREQ=DAILY"}
+	if err := storage.CreateTask(ctx, userID, task); err != nil {
+		t.Fatal(err)
+	}
+
+	var created []models.Task
+	s := newTestScheduler(t, storage)
+	s.Materialize(72*time.Hour, func(ctx context.Context, task *models.Task) {
+		created = append(created, *task)
+	})
+
+	now := time.Now().UTC()
+	s.createOccurrences(ctx, now)
+	if len(created) != 2 {
+		t.Fatalf("%d occurrences created, want 2", len(created))
+	}
+	for i, occurrence := range created {
+		if occurrence.ID == 0 || occurrence.UserID != userID || *occurrence.SeriesID != *task.SeriesID {
+			t.Errorf("occurrence %d = %+v", i, occurrence)
+		}
+		if want := start.Add(time.Duration(i+1) * 24 * time.Hour); !occurrence.ScheduledFor.Equal(want) {
+			t.Errorf("occurrence %d scheduled for %s, want %s", i, occurrence.ScheduledFor, want)
+		}
+	}
+
+	// Nothing is due until the horizon moves on.
+	created = nil
+	s.createOccurrences(ctx, now)
+	if len(created) != 0 {
+		t.Errorf("%d occurrences created again", len(created))
+	}
+
+	s.createOccurrences(ctx, now.Add(24*time.Hour))
+	if len(created) != 1 {
+		t.Errorf("%d occurrences created a day later, want 1", len(created))
+	}
+}
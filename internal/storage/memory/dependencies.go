@@ -0,0 +1,105 @@
+package memory
+
+import (
+	"TaskManager/internal/models"
+	"context"
+	"sort"
+)
+
+// AddDependency marks task taskID as blocked by dependsOnID. Both tasks
+// must belong to the user, and the new edge must not close a cycle.
+func (s *Storage) AddDependency(ctx context.Context, userID, taskID, dependsOnID int) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	task, err := s.checkDependency(userID, taskID, dependsOnID)
+	if err != nil {
+		return err
+	}
+	if s.dependsOn(dependsOnID, taskID, make(map[int]bool)) {
+		return models.ErrDependencyCycle
+	}
+
+	if s.dependencies[taskID][dependsOnID] {
+		return nil
+	}
+	if s.dependencies[taskID] == nil {
+		s.dependencies[taskID] = make(map[int]bool)
+	}
+	s.dependencies[taskID][dependsOnID] = true
+	task.Version++
+	return nil
+}
+
+// RemoveDependency removes the dependency of taskID on dependsOnID.
+// Removing a dependency that does not exist is not an error.
+func (s *Storage) RemoveDependency(ctx context.Context, userID, taskID, dependsOnID int) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	task, err := s.checkDependency(userID, taskID, dependsOnID)
+	if err != nil {
+		return err
+	}
+
+	if !s.dependencies[taskID][dependsOnID] {
+		return nil
+	}
+	delete(s.dependencies[taskID], dependsOnID)
+	task.Version++
+	return nil
+}
+
+// checkDependency makes sure both ends of a dependency belong to the user
+// and returns the dependent task. The caller must hold s.mu.
+func (s *Storage) checkDependency(userID, taskID, dependsOnID int) (*models.Task, error) {
+	task, err := s.task(userID, taskID)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := s.task(userID, dependsOnID); err != nil {
+		return nil, models.ErrDependencyNotFound
+	}
+	if taskID == dependsOnID {
+		return nil, models.ErrDependencyCycle
+	}
+	return task, nil
+}
+
+// dependsOn reports whether task from is blocked by task to, directly or
+// through other tasks. The caller must hold s.mu.
+func (s *Storage) dependsOn(from, to int, seen map[int]bool) bool {
+	if from == to {
+		return true
+	}
+	seen[from] = true
+	for id := range s.dependencies[from] {
+		if !seen[id] && s.dependsOn(id, to, seen) {
+			return true
+		}
+	}
+	return false
+}
+
+// withDependencies fills in the dependencies of a task copy. The caller
+// must hold s.mu.
+func (s *Storage) withDependencies(t *models.Task) {
+	t.BlockedBy = []int{}
+	t.Blocked = false
+	for id := range s.dependencies[t.ID] {
+		t.BlockedBy = append(t.BlockedBy, id)
+		if !s.tasks[id].Status.Finished() {
+			t.Blocked = true
+		}
+	}
+	sort.Ints(t.BlockedBy)
+}
+
+// deleteDependencies removes every dependency from or on a task. The
+// caller must hold s.mu.
+func (s *Storage) deleteDependencies(taskID int) {
+	delete(s.dependencies, taskID)
+	for _, deps := range s.dependencies {
+		delete(deps, taskID)
+	}
+}
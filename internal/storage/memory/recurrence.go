@@ -0,0 +1,221 @@
+package memory
+
+import (
+	"TaskManager/internal/models"
+	"context"
+	"sort"
+	"time"
+)
+
+// MaterializeOccurrences creates the occurrences of a series up to until,
+// and the next one if every occurrence created so far is finished. It
+// returns the occurrences created.
+func (s *Storage) MaterializeOccurrences(ctx context.Context, userID, seriesID int, until time.Time) ([]models.Task, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	series, err := s.taskSeries(userID, seriesID)
+	if err != nil {
+		return nil, err
+	}
+
+	return s.materialize(series, until)
+}
+
+// MaterializeDueOccurrences is MaterializeOccurrences for every series.
+func (s *Storage) MaterializeDueOccurrences(ctx context.Context, until time.Time) ([]models.Task, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	ids := make([]int, 0, len(s.series))
+	for id := range s.series {
+		ids = append(ids, id)
+	}
+	sort.Ints(ids)
+
+	var created []models.Task
+	for _, id := range ids {
+		tasks, err := s.materialize(s.series[id], until)
+		if err != nil {
+			return nil, err
+		}
+		created = append(created, tasks...)
+	}
+
+	return created, nil
+}
+
+// materialize creates the pending occurrences of a series. The caller
+// must hold s.mu.
+func (s *Storage) materialize(series *models.TaskSeries, until time.Time) ([]models.Task, error) {
+	open := false
+	for _, task := range s.tasks {
+		if task.SeriesID != nil && *task.SeriesID == series.ID && !task.Status.Finished() {
+			open = true
+			break
+		}
+	}
+
+	pending, err := series.Pending(until, !open)
+	if err != nil || len(pending) == 0 {
+		return nil, err
+	}
+
+	created := make([]models.Task, 0, len(pending))
+	for _, at := range pending {
+		task := series.Occurrence(at)
+		s.insertTask(&task)
+		created = append(created, task)
+	}
+	series.MaterializedUntil = pending[len(pending)-1]
+
+	return created, nil
+}
+
+// UpdateFollowingOccurrences applies patch to a recurring task and to the
+// unfinished occurrences after it, which are split off into a series of
+// their own. A task that does not recur yet starts a new series if patch
+// sets a rule.
+func (s *Storage) UpdateFollowingOccurrences(ctx context.Context, userID, taskID int, patch *models.SeriesPatch) (*models.Task, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	stored, err := s.task(userID, taskID)
+	if err != nil {
+		return nil, err
+	}
+
+	if stored.SeriesID == nil {
+		if patch.RRule == nil || *patch.RRule == "" {
+			return nil, models.ErrNotRecurring
+		}
+
+		patch.ApplyTo(stored)
+		series := s.insertSeries(models.NewTaskSeries(stored, *patch.RRule))
+		seriesID, occurrenceAt := series.ID, series.DTStart
+		stored.SeriesID, stored.OccurrenceAt = &seriesID, &occurrenceAt
+	} else if err := s.updateFollowing(stored, patch); err != nil {
+		return nil, err
+	}
+	stored.Version++
+
+	task := s.withDetails(stored)
+	return &task, nil
+}
+
+// updateFollowing splits the series of task at it and applies patch to the
+// new series and its unfinished occurrences, the task included. The caller
+// must hold s.mu.
+func (s *Storage) updateFollowing(task *models.Task, patch *models.SeriesPatch) error {
+	series, err := s.taskSeries(task.UserID, *task.SeriesID)
+	if err != nil {
+		return err
+	}
+
+	at := *task.OccurrenceAt
+	before, after, err := series.Split(at)
+	if err != nil {
+		return err
+	}
+
+	stop := patch.RRule != nil && *patch.RRule == ""
+	split := at.After(series.DTStart)
+	if split || stop {
+		// Earlier occurrences stay with the series as it was.
+		next := *series
+		series.RRule = before
+		series = &next
+	}
+
+	if stop {
+		s.deleteOccurrencesAfter(series.ID, at, task.ID)
+		patch.ApplyTo(task)
+		task.SeriesID, task.OccurrenceAt = nil, nil
+		return nil
+	}
+
+	if split {
+		previousID := series.ID
+		series.RRule, series.DTStart = after, at
+		s.insertSeries(series)
+		for _, t := range s.tasks {
+			if t.SeriesID != nil && *t.SeriesID == previousID && !t.OccurrenceAt.Before(at) {
+				seriesID := series.ID
+				t.SeriesID = &seriesID
+			}
+		}
+	}
+
+	reschedule := patch.Reschedules(task)
+	series.Apply(patch)
+	if patch.RRule != nil {
+		series.RRule = *patch.RRule
+	}
+	patch.ApplyTo(task)
+
+	if reschedule {
+		// Later occurrences no longer fit and are created again from the
+		// new rule and start.
+		s.deleteOccurrencesAfter(series.ID, at, task.ID)
+
+		series.DTStart = task.ScheduledFor.Truncate(time.Second).UTC()
+		series.MaterializedUntil = series.DTStart
+		occurrenceAt := series.DTStart
+		task.OccurrenceAt = &occurrenceAt
+		return nil
+	}
+
+	fields := &models.SeriesPatch{Title: patch.Title, Description: patch.Description, Priority: patch.Priority}
+	for _, t := range s.tasks {
+		if isLaterOccurrence(t, series.ID, at) {
+			fields.ApplyTo(t)
+			t.Version++
+		}
+	}
+	return nil
+}
+
+// insertSeries stores a new series and assigns its ID. The caller must
+// hold s.mu.
+func (s *Storage) insertSeries(series *models.TaskSeries) *models.TaskSeries {
+	s.lastSeriesID++
+	series.ID = s.lastSeriesID
+	s.series[series.ID] = series
+	return series
+}
+
+// taskSeries returns the stored series if it belongs to the user. The
+// caller must hold s.mu.
+func (s *Storage) taskSeries(userID, seriesID int) (*models.TaskSeries, error) {
+	series, ok := s.series[seriesID]
+	if !ok || series.UserID != userID {
+		return nil, models.ErrNotRecurring
+	}
+	return series, nil
+}
+
+// isLaterOccurrence reports whether task is an unfinished occurrence of
+// the series after at.
+func isLaterOccurrence(task *models.Task, seriesID int, at time.Time) bool {
+	return task.SeriesID != nil && *task.SeriesID == seriesID &&
+		task.OccurrenceAt.After(at) && !task.Status.Finished()
+}
+
+// deleteOccurrencesAfter removes the unfinished occurrences of a series
+// after at, except task keepID. The caller must hold s.mu.
+func (s *Storage) deleteOccurrencesAfter(seriesID int, at time.Time, keepID int) {
+	for id, task := range s.tasks {
+		if id != keepID && isLaterOccurrence(task, seriesID, at) {
+			s.deleteSubtree(id)
+		}
+	}
+}
+
+// withRRule fills in the recurrence rule of a task that belongs to a
+// series.
+func (s *Storage) withRRule(task *models.Task) {
+	task.RRule = ""
+	if task.SeriesID != nil {
+		task.RRule = s.series[*task.SeriesID].RRule
+	}
+}
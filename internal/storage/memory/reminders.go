@@ -0,0 +1,54 @@
+package memory
+
+import (
+	"TaskManager/internal/models"
+	"context"
+	"sort"
+	"time"
+)
+
+// FireDueReminders records and returns the reminders of unfinished tasks
+// that fall in (from, to] for any of offsets and were not fired before.
+func (s *Storage) FireDueReminders(ctx context.Context, offsets []time.Duration, from, to time.Time) ([]models.Reminder, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var reminders []models.Reminder
+	for _, task := range s.tasks {
+		if task.Status.Finished() || task.ScheduledFor.IsZero() {
+			continue
+		}
+
+		for _, offset := range offsets {
+			remindAt := task.ScheduledFor.Add(-offset).UTC()
+			if !remindAt.After(from) || remindAt.After(to) {
+				continue
+			}
+
+			reminder := models.Reminder{
+				TaskID:   task.ID,
+				UserID:   task.UserID,
+				Title:    task.Title,
+				Offset:   int64(offset / time.Second),
+				RemindAt: remindAt,
+				FiredAt:  to,
+			}
+			if s.reminders[reminder.Key()] {
+				continue
+			}
+
+			s.lastReminderID++
+			reminder.ID = s.lastReminderID
+			s.reminders[reminder.Key()] = true
+			reminders = append(reminders, reminder)
+		}
+	}
+
+	sort.Slice(reminders, func(i, j int) bool {
+		if !reminders[i].RemindAt.Equal(reminders[j].RemindAt) {
+			return reminders[i].RemindAt.Before(reminders[j].RemindAt)
+		}
+		return reminders[i].ID < reminders[j].ID
+	})
+	return reminders, nil
+}
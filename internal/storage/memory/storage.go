@@ -0,0 +1,540 @@
+package memory
+
+import (
+	"TaskManager/internal/models"
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+type user struct {
+	models.User
+	tokensRevokedAt time.Time
+}
+
+// Storage keeps users and tasks in process memory. It mirrors the
+// semantics of postgres.Storage and is meant for tests and local runs.
+type Storage struct {
+	mu sync.RWMutex
+
+	users     map[int]*user
+	usernames map[string]int
+	tasks     map[int]*models.Task
+	tags      map[int]*models.Tag
+	taskTags  map[int]map[int]bool // task ID -> IDs of its tags
+
+	checklistItems map[int]*models.ChecklistItem
+	dependencies   map[int]map[int]bool // task ID -> IDs of the tasks blocking it
+	series         map[int]*models.TaskSeries
+	reminders      map[models.ReminderKey]bool // fired reminders
+	webhooks       map[int]*models.Webhook
+	deliveries     map[int]*delivery
+
+	refreshTokens map[string]*models.RefreshToken
+	revokedTokens map[string]time.Time
+
+	lastUserID     int
+	lastTaskID     int
+	lastTagID      int
+	lastItemID     int
+	lastSeriesID   int
+	lastReminderID int
+	lastWebhookID  int
+	lastDeliveryID int
+	lastTokenID    int
+}
+
+func New() *Storage {
+	return &Storage{
+		users:          make(map[int]*user),
+		usernames:      make(map[string]int),
+		tasks:          make(map[int]*models.Task),
+		tags:           make(map[int]*models.Tag),
+		taskTags:       make(map[int]map[int]bool),
+		checklistItems: make(map[int]*models.ChecklistItem),
+		dependencies:   make(map[int]map[int]bool),
+		series:         make(map[int]*models.TaskSeries),
+		reminders:      make(map[models.ReminderKey]bool),
+		webhooks:       make(map[int]*models.Webhook),
+		deliveries:     make(map[int]*delivery),
+		refreshTokens:  make(map[string]*models.RefreshToken),
+		revokedTokens:  make(map[string]time.Time),
+	}
+}
+
+func (s *Storage) Open() error {
+	return nil
+}
+
+func (s *Storage) Close() error {
+	return nil
+}
+
+func (s *Storage) Ping(ctx context.Context) error {
+	return nil
+}
+
+func (s *Storage) CreateUser(ctx context.Context, username, passwordHash string) (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.usernames[username]; ok {
+		return 0, fmt.Errorf("username %q already exists", username)
+	}
+
+	s.lastUserID++
+	s.users[s.lastUserID] = &user{
+		User: models.User{
+			ID:       s.lastUserID,
+			Username: username,
+			Password: passwordHash,
+		},
+	}
+	s.usernames[username] = s.lastUserID
+
+	return s.lastUserID, nil
+}
+
+func (s *Storage) GetUserByUsername(ctx context.Context, username string) (*models.User, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	id, ok := s.usernames[username]
+	if !ok {
+		return nil, models.ErrUserNotFound
+	}
+
+	u := s.users[id].User
+	return &u, nil
+}
+
+func (s *Storage) UpdateUserPassword(ctx context.Context, userID int, passwordHash string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	u, ok := s.users[userID]
+	if !ok {
+		return models.ErrUserNotFound
+	}
+
+	u.Password = passwordHash
+	return nil
+}
+
+func (s *Storage) CreateRefreshToken(ctx context.Context, token *models.RefreshToken) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.storeRefreshToken(token)
+	return nil
+}
+
+func (s *Storage) storeRefreshToken(token *models.RefreshToken) {
+	s.lastTokenID++
+	token.ID = s.lastTokenID
+	token.CreatedAt = time.Now()
+
+	stored := *token
+	s.refreshTokens[token.TokenHash] = &stored
+}
+
+func (s *Storage) RotateRefreshToken(ctx context.Context, oldHash string, next *models.RefreshToken) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	old, ok := s.refreshTokens[oldHash]
+	if !ok {
+		return models.ErrRefreshTokenInvalid
+	}
+
+	if old.RevokedAt != nil {
+		s.revokeAllTokens(old.UserID)
+		return models.ErrRefreshTokenReused
+	}
+
+	now := time.Now()
+	if now.After(old.ExpiresAt) {
+		return models.ErrRefreshTokenInvalid
+	}
+
+	old.RevokedAt = &now
+	next.UserID = old.UserID
+	s.storeRefreshToken(next)
+
+	return nil
+}
+
+func (s *Storage) RevokeRefreshToken(ctx context.Context, userID int, tokenHash string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	token, ok := s.refreshTokens[tokenHash]
+	if ok && token.UserID == userID && token.RevokedAt == nil {
+		now := time.Now()
+		token.RevokedAt = &now
+	}
+
+	return nil
+}
+
+func (s *Storage) RevokeAllTokens(ctx context.Context, userID int) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.revokeAllTokens(userID)
+	return nil
+}
+
+func (s *Storage) revokeAllTokens(userID int) {
+	now := time.Now()
+
+	for _, token := range s.refreshTokens {
+		if token.UserID == userID && token.RevokedAt == nil {
+			token.RevokedAt = &now
+		}
+	}
+
+	if u, ok := s.users[userID]; ok {
+		u.tokensRevokedAt = now
+	}
+}
+
+func (s *Storage) RevokeAccessToken(ctx context.Context, jti string, expiresAt time.Time) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := time.Now()
+	for id, exp := range s.revokedTokens {
+		if exp.Before(now) {
+			delete(s.revokedTokens, id)
+		}
+	}
+
+	s.revokedTokens[jti] = expiresAt
+	return nil
+}
+
+func (s *Storage) IsAccessTokenRevoked(ctx context.Context, userID int, jti string, issuedAt time.Time) (bool, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	if _, ok := s.revokedTokens[jti]; ok {
+		return true, nil
+	}
+
+	u, ok := s.users[userID]
+	return ok && !u.tokensRevokedAt.IsZero() && !u.tokensRevokedAt.Truncate(time.Second).Before(issuedAt), nil
+}
+
+func (s *Storage) GetTasks(ctx context.Context, userID int, query *models.TaskQuery) (*models.TaskPage, error) {
+	if query.SortBy == "" {
+		query.SortBy = models.SortByID
+	}
+	if !query.SortBy.Valid() {
+		return nil, fmt.Errorf("unknown sort field %q", query.SortBy)
+	}
+
+	var after *models.Task
+	if query.Cursor != "" {
+		cursor, err := models.DecodeTaskCursor(query, query.Cursor)
+		if err != nil {
+			return nil, err
+		}
+		if after, err = cursorTask(query, cursor); err != nil {
+			return nil, err
+		}
+	}
+
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	page := &models.TaskPage{Tasks: []models.Task{}}
+	for _, task := range s.tasks {
+		if task.UserID != userID || !matches(query, task) || !s.hasTags(query, task) {
+			continue
+		}
+
+		page.Total++
+		if after == nil || less(query, after, task) {
+			page.Tasks = append(page.Tasks, s.withDetails(task))
+		}
+	}
+
+	sort.Slice(page.Tasks, func(i, j int) bool {
+		return less(query, &page.Tasks[i], &page.Tasks[j])
+	})
+
+	if query.Limit > 0 && len(page.Tasks) > query.Limit {
+		page.Tasks = page.Tasks[:query.Limit]
+		page.NextCursor = models.NewTaskCursor(query, &page.Tasks[query.Limit-1]).Encode()
+	}
+
+	return page, nil
+}
+
+func (s *Storage) CreateTask(ctx context.Context, userID int, task *models.Task) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if task.Status == "" {
+		task.Status = models.StatusTodo
+	}
+	task.Tags = []models.Tag{}
+	task.Checklist = []models.ChecklistItem{}
+	task.Progress = nil
+	task.SeriesID, task.OccurrenceAt = nil, nil
+	if task.ParentID != nil {
+		if err := s.checkParent(userID, 0, *task.ParentID); err != nil {
+			return err
+		}
+	}
+
+	task.UserID = userID
+	if task.RRule != "" {
+		// The task is the first occurrence of its series.
+		series := s.insertSeries(models.NewTaskSeries(task, task.RRule))
+		seriesID, occurrenceAt := series.ID, series.DTStart
+		task.SeriesID, task.OccurrenceAt = &seriesID, &occurrenceAt
+	}
+
+	s.insertTask(task)
+	return nil
+}
+
+// insertTask stores a copy of a new task and fills in its ID, creation
+// time and version. The caller must hold s.mu.
+func (s *Storage) insertTask(task *models.Task) {
+	s.lastTaskID++
+	task.ID = s.lastTaskID
+	task.CreatedAt = time.Now()
+	task.Version = 1
+
+	stored := *task
+	s.tasks[task.ID] = &stored
+}
+
+func (s *Storage) GetTaskByID(ctx context.Context, userID, taskID int) (*models.Task, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	task, err := s.task(userID, taskID)
+	if err != nil {
+		return nil, err
+	}
+
+	t := s.withDetails(task)
+	return &t, nil
+}
+
+// UpdateTask writes the task and stores its new version in task.Version.
+// A non-zero task.Version makes the update conditional on the stored
+// version still being the same.
+func (s *Storage) UpdateTask(ctx context.Context, userID int, task *models.Task) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	stored, err := s.task(userID, task.ID)
+	if err != nil {
+		return err
+	}
+	if task.Version > 0 && task.Version != stored.Version {
+		return models.ErrVersionConflict
+	}
+	if task.ParentID != nil {
+		if err := s.checkParent(userID, task.ID, *task.ParentID); err != nil {
+			return err
+		}
+	}
+
+	stored.Title = task.Title
+	stored.Description = task.Description
+	stored.ScheduledFor = task.ScheduledFor
+	stored.DueAt = task.DueAt
+	stored.Priority = task.Priority
+	stored.ParentID = task.ParentID
+	stored.Status = task.Status
+	stored.Version++
+	task.Version = stored.Version
+
+	return nil
+}
+
+// PatchTask writes the fields set in patch and returns the updated task.
+// A non-zero version makes the update conditional on the stored version.
+func (s *Storage) PatchTask(ctx context.Context, userID, taskID int, patch *models.TaskPatch, version int) (*models.Task, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	stored, err := s.task(userID, taskID)
+	if err != nil {
+		return nil, err
+	}
+	if version > 0 && version != stored.Version {
+		return nil, models.ErrVersionConflict
+	}
+	if patch.ParentID != nil {
+		if err := s.checkParent(userID, taskID, *patch.ParentID); err != nil {
+			return nil, err
+		}
+	}
+
+	if patch.Title != nil {
+		stored.Title = *patch.Title
+	}
+	if patch.Description != nil {
+		stored.Description = *patch.Description
+	}
+	if patch.ScheduledFor != nil {
+		stored.ScheduledFor = *patch.ScheduledFor
+	}
+	if patch.DueAt != nil {
+		stored.DueAt = patch.DueAt
+	} else if patch.ClearDueAt {
+		stored.DueAt = nil
+	}
+	if patch.Priority != nil {
+		stored.Priority = *patch.Priority
+	}
+	if patch.ParentID != nil {
+		stored.ParentID = patch.ParentID
+	} else if patch.ClearParent {
+		stored.ParentID = nil
+	}
+	if patch.Status != nil {
+		stored.Status = *patch.Status
+	}
+	stored.Version++
+
+	task := s.withDetails(stored)
+	return &task, nil
+}
+
+// UpdateTaskStatus moves the task to status. A non-zero version makes the
+// update conditional on the stored version of the task.
+func (s *Storage) UpdateTaskStatus(ctx context.Context, userID, taskID int, status models.TaskStatus, version int) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	stored, err := s.task(userID, taskID)
+	if err != nil {
+		return err
+	}
+	if version > 0 && version != stored.Version {
+		return models.ErrVersionConflict
+	}
+
+	stored.Status = status
+	stored.Version++
+	return nil
+}
+
+// DeleteTask removes the task together with its subtasks and returns the
+// tasks removed. A non-zero version makes the delete conditional on the
+// stored version of the task.
+func (s *Storage) DeleteTask(ctx context.Context, userID, taskID, version int) ([]models.Task, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	stored, err := s.task(userID, taskID)
+	if err != nil {
+		return nil, err
+	}
+	if version > 0 && version != stored.Version {
+		return nil, models.ErrVersionConflict
+	}
+
+	return s.deleteSubtree(taskID), nil
+}
+
+// task returns the stored task if it belongs to the user. The caller must
+// hold s.mu.
+func (s *Storage) task(userID, taskID int) (*models.Task, error) {
+	task, ok := s.tasks[taskID]
+	if !ok || task.UserID != userID {
+		return nil, models.ErrTaskNotFound
+	}
+	return task, nil
+}
+
+func matches(query *models.TaskQuery, task *models.Task) bool {
+	if query.ScheduledFrom != nil && task.ScheduledFor.Before(*query.ScheduledFrom) {
+		return false
+	}
+	if query.ScheduledTo != nil && !task.ScheduledFor.Before(*query.ScheduledTo) {
+		return false
+	}
+	if query.CreatedFrom != nil && task.CreatedAt.Before(*query.CreatedFrom) {
+		return false
+	}
+	if query.CreatedTo != nil && !task.CreatedAt.Before(*query.CreatedTo) {
+		return false
+	}
+	if query.Title != "" && !strings.Contains(strings.ToLower(task.Title), strings.ToLower(query.Title)) {
+		return false
+	}
+	if len(query.Statuses) > 0 {
+		found := false
+		for _, status := range query.Statuses {
+			if task.Status == status {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+	return true
+}
+
+// less reports whether a comes before b in the order of query, breaking
+// ties by ID.
+func less(query *models.TaskQuery, a, b *models.Task) bool {
+	c := 0
+	switch query.SortBy {
+	case models.SortByCreatedAt:
+		c = a.CreatedAt.Compare(b.CreatedAt)
+	case models.SortByScheduledFor:
+		c = a.ScheduledFor.Compare(b.ScheduledFor)
+	case models.SortByTitle:
+		c = strings.Compare(a.Title, b.Title)
+	case models.SortByPriority:
+		c = int(a.Priority - b.Priority)
+		if c == 0 {
+			c = a.DueSortKey(query.Descending).Compare(b.DueSortKey(query.Descending))
+		}
+	}
+	if c == 0 {
+		c = a.ID - b.ID
+	}
+
+	if query.Descending {
+		return c > 0
+	}
+	return c < 0
+}
+
+// cursorTask returns a task carrying the sort key stored in cursor.
+func cursorTask(query *models.TaskQuery, cursor models.TaskCursor) (*models.Task, error) {
+	task := &models.Task{ID: cursor.ID}
+
+	var err error
+	switch query.SortBy {
+	case models.SortByCreatedAt:
+		task.CreatedAt, err = cursor.Time()
+	case models.SortByScheduledFor:
+		task.ScheduledFor, err = cursor.Time()
+	case models.SortByTitle:
+		task.Title = cursor.Value
+	case models.SortByPriority:
+		var due time.Time
+		if task.Priority, due, err = cursor.Priority(); err == nil && !due.Equal(models.NoDueDateFor(query.Descending)) {
+			task.DueAt = &due
+		}
+	}
+
+	return task, err
+}
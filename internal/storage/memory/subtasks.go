@@ -0,0 +1,234 @@
+package memory
+
+import (
+	"TaskManager/internal/models"
+	"context"
+	"sort"
+)
+
+// GetSubtasks returns the direct subtasks of a task, oldest first.
+func (s *Storage) GetSubtasks(ctx context.Context, userID, taskID int) ([]models.Task, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	if _, err := s.task(userID, taskID); err != nil {
+		return nil, err
+	}
+
+	subtasks := []models.Task{}
+	for _, task := range s.tasks {
+		if task.ParentID != nil && *task.ParentID == taskID {
+			subtasks = append(subtasks, s.withDetails(task))
+		}
+	}
+	sort.Slice(subtasks, func(i, j int) bool {
+		return subtasks[i].ID < subtasks[j].ID
+	})
+
+	return subtasks, nil
+}
+
+// AddChecklistItem appends an item to the checklist of a task.
+func (s *Storage) AddChecklistItem(ctx context.Context, userID, taskID int, item *models.ChecklistItem) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	task, err := s.task(userID, taskID)
+	if err != nil {
+		return err
+	}
+
+	items := s.checklist(taskID)
+
+	s.lastItemID++
+	item.ID = s.lastItemID
+	item.TaskID = taskID
+	item.Done = false
+	item.Position = 0
+	if len(items) > 0 {
+		item.Position = items[len(items)-1].Position + 1
+	}
+
+	stored := *item
+	s.checklistItems[item.ID] = &stored
+	task.Version++
+
+	return nil
+}
+
+// UpdateChecklistItem writes the fields set in patch and returns the
+// updated item.
+func (s *Storage) UpdateChecklistItem(ctx context.Context, userID, taskID, itemID int, patch *models.ChecklistItemPatch) (*models.ChecklistItem, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	task, err := s.task(userID, taskID)
+	if err != nil {
+		return nil, err
+	}
+
+	stored, ok := s.checklistItems[itemID]
+	if !ok || stored.TaskID != taskID {
+		return nil, models.ErrChecklistItemNotFound
+	}
+
+	if patch.Text != nil {
+		stored.Text = *patch.Text
+	}
+	if patch.Done != nil {
+		stored.Done = *patch.Done
+	}
+	task.Version++
+
+	item := *stored
+	return &item, nil
+}
+
+func (s *Storage) DeleteChecklistItem(ctx context.Context, userID, taskID, itemID int) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	task, err := s.task(userID, taskID)
+	if err != nil {
+		return err
+	}
+
+	stored, ok := s.checklistItems[itemID]
+	if !ok || stored.TaskID != taskID {
+		return models.ErrChecklistItemNotFound
+	}
+
+	delete(s.checklistItems, itemID)
+	task.Version++
+	return nil
+}
+
+// ReorderChecklist puts the checklist items of a task in the order of
+// itemIDs, which must list every item exactly once.
+func (s *Storage) ReorderChecklist(ctx context.Context, userID, taskID int, itemIDs []int) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	task, err := s.task(userID, taskID)
+	if err != nil {
+		return err
+	}
+
+	items := s.checklist(taskID)
+	ids := make([]int, len(items))
+	for i, item := range items {
+		ids[i] = item.ID
+	}
+	if err := models.CheckChecklistOrder(ids, itemIDs); err != nil {
+		return err
+	}
+
+	for position, id := range itemIDs {
+		s.checklistItems[id].Position = position
+	}
+	task.Version++
+	return nil
+}
+
+// checkParent validates nesting task taskID under parentID. A zero taskID
+// stands for a task that is being created. The caller must hold s.mu.
+func (s *Storage) checkParent(userID, taskID, parentID int) error {
+	parent, err := s.task(userID, parentID)
+	if err != nil {
+		return models.ErrParentNotFound
+	}
+
+	var ancestors []int
+	for task := parent; task != nil; {
+		ancestors = append(ancestors, task.ID)
+		if task.ParentID == nil || len(ancestors) > models.MaxTaskDepth {
+			break
+		}
+		task = s.tasks[*task.ParentID]
+	}
+
+	height := 1
+	if taskID != 0 {
+		height = s.height(taskID, 1)
+	}
+
+	return models.CheckParent(taskID, ancestors, height)
+}
+
+// height returns the number of levels of the subtree of a task at the given
+// depth, looking no further than one level past MaxTaskDepth.
+func (s *Storage) height(taskID, depth int) int {
+	h := depth
+	if depth > models.MaxTaskDepth {
+		return h
+	}
+	for _, task := range s.tasks {
+		if task.ParentID != nil && *task.ParentID == taskID {
+			if d := s.height(task.ID, depth+1); d > h {
+				h = d
+			}
+		}
+	}
+	return h
+}
+
+// deleteSubtree removes a task with its subtasks, tags, checklist and
+// dependencies, and returns the tasks removed. The caller must hold s.mu.
+func (s *Storage) deleteSubtree(taskID int) []models.Task {
+	deleted := []models.Task{*s.tasks[taskID]}
+	for _, task := range s.tasks {
+		if task.ParentID != nil && *task.ParentID == taskID {
+			deleted = append(deleted, s.deleteSubtree(task.ID)...)
+		}
+	}
+
+	for _, item := range s.checklist(taskID) {
+		delete(s.checklistItems, item.ID)
+	}
+	delete(s.taskTags, taskID)
+	s.deleteDependencies(taskID)
+	delete(s.tasks, taskID)
+	return deleted
+}
+
+// checklist returns the checklist items of a task in order. The caller must
+// hold s.mu.
+func (s *Storage) checklist(taskID int) []models.ChecklistItem {
+	items := []models.ChecklistItem{}
+	for _, item := range s.checklistItems {
+		if item.TaskID == taskID {
+			items = append(items, *item)
+		}
+	}
+
+	sort.Slice(items, func(i, j int) bool {
+		if items[i].Position != items[j].Position {
+			return items[i].Position < items[j].Position
+		}
+		return items[i].ID < items[j].ID
+	})
+	return items
+}
+
+// withDetails returns a copy of the task with its tags, checklist, progress
+// and dependencies filled in. The caller must hold s.mu.
+func (s *Storage) withDetails(task *models.Task) models.Task {
+	t := s.withTags(task)
+	t.Checklist = s.checklist(task.ID)
+
+	total, done := 0, 0
+	for _, sub := range s.tasks {
+		if sub.ParentID == nil || *sub.ParentID != task.ID || sub.Status == models.StatusCancelled {
+			continue
+		}
+		total++
+		if sub.Status == models.StatusDone {
+			done++
+		}
+	}
+	t.Progress = models.Progress(total, done)
+	s.withDependencies(&t)
+	s.withRRule(&t)
+
+	return t
+}
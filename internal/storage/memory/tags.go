@@ -0,0 +1,192 @@
+package memory
+
+import (
+	"TaskManager/internal/models"
+	"context"
+	"sort"
+)
+
+func (s *Storage) GetTags(ctx context.Context, userID int) ([]models.Tag, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	tags := []models.Tag{}
+	for _, tag := range s.tags {
+		if tag.UserID == userID {
+			tags = append(tags, *tag)
+		}
+	}
+	sortTags(tags)
+
+	return tags, nil
+}
+
+func (s *Storage) CreateTag(ctx context.Context, userID int, tag *models.Tag) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.tagNameTaken(userID, tag.Name) {
+		return models.ErrTagExists
+	}
+
+	s.lastTagID++
+	tag.ID = s.lastTagID
+	tag.UserID = userID
+
+	stored := *tag
+	s.tags[tag.ID] = &stored
+
+	return nil
+}
+
+// RenameTag changes the name of a tag. Tasks carrying the tag get a new
+// version, as their representation changes with it.
+func (s *Storage) RenameTag(ctx context.Context, userID, tagID int, name string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	tag, err := s.tag(userID, tagID)
+	if err != nil {
+		return err
+	}
+	if tag.Name == name {
+		return nil
+	}
+	if s.tagNameTaken(userID, name) {
+		return models.ErrTagExists
+	}
+
+	tag.Name = name
+	s.touchTaggedTasks(tagID)
+	return nil
+}
+
+// DeleteTag removes a tag and detaches it from every task.
+func (s *Storage) DeleteTag(ctx context.Context, userID, tagID int) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, err := s.tag(userID, tagID); err != nil {
+		return err
+	}
+
+	s.touchTaggedTasks(tagID)
+	for _, tags := range s.taskTags {
+		delete(tags, tagID)
+	}
+	delete(s.tags, tagID)
+	return nil
+}
+
+// AttachTag attaches a tag to a task, both owned by the user. Attaching a
+// tag twice is not an error.
+func (s *Storage) AttachTag(ctx context.Context, userID, taskID, tagID int) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	task, err := s.task(userID, taskID)
+	if err != nil {
+		return err
+	}
+	if _, err := s.tag(userID, tagID); err != nil {
+		return err
+	}
+
+	if s.taskTags[taskID][tagID] {
+		return nil
+	}
+	if s.taskTags[taskID] == nil {
+		s.taskTags[taskID] = make(map[int]bool)
+	}
+	s.taskTags[taskID][tagID] = true
+	task.Version++
+	return nil
+}
+
+// DetachTag removes a tag from a task. Detaching a tag the task does not
+// carry is not an error.
+func (s *Storage) DetachTag(ctx context.Context, userID, taskID, tagID int) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	task, err := s.task(userID, taskID)
+	if err != nil {
+		return err
+	}
+	if _, err := s.tag(userID, tagID); err != nil {
+		return err
+	}
+
+	if !s.taskTags[taskID][tagID] {
+		return nil
+	}
+	delete(s.taskTags[taskID], tagID)
+	task.Version++
+	return nil
+}
+
+// tag returns the stored tag if it belongs to the user. The caller must
+// hold s.mu.
+func (s *Storage) tag(userID, tagID int) (*models.Tag, error) {
+	tag, ok := s.tags[tagID]
+	if !ok || tag.UserID != userID {
+		return nil, models.ErrTagNotFound
+	}
+	return tag, nil
+}
+
+func (s *Storage) tagNameTaken(userID int, name string) bool {
+	for _, tag := range s.tags {
+		if tag.UserID == userID && tag.Name == name {
+			return true
+		}
+	}
+	return false
+}
+
+func (s *Storage) touchTaggedTasks(tagID int) {
+	for taskID, tags := range s.taskTags {
+		if tags[tagID] {
+			s.tasks[taskID].Version++
+		}
+	}
+}
+
+// withTags returns a copy of the task with its tags filled in. The caller
+// must hold s.mu.
+func (s *Storage) withTags(task *models.Task) models.Task {
+	t := *task
+	t.Tags = []models.Tag{}
+	for tagID := range s.taskTags[task.ID] {
+		t.Tags = append(t.Tags, *s.tags[tagID])
+	}
+	sortTags(t.Tags)
+	return t
+}
+
+// hasTags reports whether the task carries the tags of query.
+func (s *Storage) hasTags(query *models.TaskQuery, task *models.Task) bool {
+	if len(query.Tags) == 0 {
+		return true
+	}
+
+	found := 0
+	for tagID := range s.taskTags[task.ID] {
+		for _, name := range query.Tags {
+			if s.tags[tagID].Name == name {
+				found++
+			}
+		}
+	}
+
+	if query.TagMode == models.TagModeAny {
+		return found > 0
+	}
+	return found == len(query.Tags)
+}
+
+func sortTags(tags []models.Tag) {
+	sort.Slice(tags, func(i, j int) bool {
+		return tags[i].Name < tags[j].Name
+	})
+}
@@ -0,0 +1,186 @@
+package memory
+
+import (
+	"TaskManager/internal/models"
+	"context"
+	"sort"
+	"time"
+)
+
+// delivery is an outbox entry; nextAttemptAt is nil once it was delivered
+// or given up on.
+type delivery struct {
+	models.WebhookDelivery
+	nextAttemptAt *time.Time
+	deliveredAt   *time.Time
+	lastError     string
+	createdAt     time.Time
+}
+
+func (s *Storage) GetWebhooks(ctx context.Context, userID int) ([]models.Webhook, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	webhooks := []models.Webhook{}
+	for _, webhook := range s.webhooks {
+		if webhook.UserID == userID {
+			webhooks = append(webhooks, *webhook)
+		}
+	}
+	sort.Slice(webhooks, func(i, j int) bool { return webhooks[i].ID < webhooks[j].ID })
+	return webhooks, nil
+}
+
+func (s *Storage) CreateWebhook(ctx context.Context, userID int, webhook *models.Webhook) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.lastWebhookID++
+	webhook.ID = s.lastWebhookID
+	webhook.UserID = userID
+	webhook.CreatedAt = time.Now()
+
+	stored := *webhook
+	s.webhooks[webhook.ID] = &stored
+	return nil
+}
+
+// UpdateWebhook changes the URL and events of a webhook, and its secret
+// unless webhook.Secret is empty. The stored webhook is read back into
+// webhook.
+func (s *Storage) UpdateWebhook(ctx context.Context, userID int, webhook *models.Webhook) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	stored, ok := s.webhooks[webhook.ID]
+	if !ok || stored.UserID != userID {
+		return models.ErrWebhookNotFound
+	}
+
+	stored.URL, stored.Events = webhook.URL, webhook.Events
+	if webhook.Secret != "" {
+		stored.Secret = webhook.Secret
+	}
+	*webhook = *stored
+	return nil
+}
+
+// DeleteWebhook removes a webhook together with its pending deliveries.
+func (s *Storage) DeleteWebhook(ctx context.Context, userID, webhookID int) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	webhook, ok := s.webhooks[webhookID]
+	if !ok || webhook.UserID != userID {
+		return models.ErrWebhookNotFound
+	}
+
+	delete(s.webhooks, webhookID)
+	for id, d := range s.deliveries {
+		if d.WebhookID == webhookID {
+			delete(s.deliveries, id)
+		}
+	}
+	return nil
+}
+
+// EnqueueEvent adds an event to the outbox of every webhook of the user
+// that subscribes to it.
+func (s *Storage) EnqueueEvent(ctx context.Context, userID int, event models.EventType, payload []byte) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := time.Now()
+	for _, webhook := range s.webhooks {
+		if webhook.UserID != userID || !webhook.Events.Matches(event) {
+			continue
+		}
+
+		s.lastDeliveryID++
+		next := now
+		s.deliveries[s.lastDeliveryID] = &delivery{
+			WebhookDelivery: models.WebhookDelivery{
+				ID:        s.lastDeliveryID,
+				WebhookID: webhook.ID,
+				Event:     event,
+				Payload:   append([]byte(nil), payload...),
+			},
+			nextAttemptAt: &next,
+			createdAt:     now,
+		}
+	}
+	return nil
+}
+
+// ClaimDeliveries returns up to limit deliveries due at now and hides them
+// from other callers until leaseUntil, counting the attempt.
+func (s *Storage) ClaimDeliveries(ctx context.Context, now, leaseUntil time.Time, limit int) ([]models.WebhookDelivery, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var due []*delivery
+	for _, d := range s.deliveries {
+		if d.nextAttemptAt != nil && !d.nextAttemptAt.After(now) {
+			due = append(due, d)
+		}
+	}
+	sort.Slice(due, func(i, j int) bool {
+		if !due[i].nextAttemptAt.Equal(*due[j].nextAttemptAt) {
+			return due[i].nextAttemptAt.Before(*due[j].nextAttemptAt)
+		}
+		return due[i].ID < due[j].ID
+	})
+	if len(due) > limit {
+		due = due[:limit]
+	}
+
+	deliveries := []models.WebhookDelivery{}
+	for _, d := range due {
+		d.Attempts++
+		lease := leaseUntil
+		d.nextAttemptAt = &lease
+
+		webhook := s.webhooks[d.WebhookID]
+		claimed := d.WebhookDelivery
+		claimed.URL, claimed.Secret = webhook.URL, webhook.Secret
+		deliveries = append(deliveries, claimed)
+	}
+	return deliveries, nil
+}
+
+// CompleteDelivery marks a delivery as delivered.
+func (s *Storage) CompleteDelivery(ctx context.Context, deliveryID int, at time.Time) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if d, ok := s.deliveries[deliveryID]; ok {
+		d.nextAttemptAt, d.deliveredAt, d.lastError = nil, &at, ""
+	}
+	return nil
+}
+
+// RetryDelivery records a failed attempt and schedules the next one, or
+// gives up on the delivery if next is nil.
+func (s *Storage) RetryDelivery(ctx context.Context, deliveryID int, next *time.Time, lastError string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if d, ok := s.deliveries[deliveryID]; ok {
+		d.nextAttemptAt, d.lastError = next, lastError
+	}
+	return nil
+}
+
+// DeleteDeliveries removes the deliveries created before the given time
+// that were delivered or given up on.
+func (s *Storage) DeleteDeliveries(ctx context.Context, before time.Time) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for id, d := range s.deliveries {
+		if d.nextAttemptAt == nil && d.createdAt.Before(before) {
+			delete(s.deliveries, id)
+		}
+	}
+	return nil
+}
@@ -1,9 +1,19 @@
 package postgres
 
+import "time"
+
 type Config struct {
 	DataBaseURL string `toml:"database_url"`
+	// AutoMigrate applies pending migrations when the storage is opened.
+	AutoMigrate bool `toml:"auto_migrate"`
+	// QueryTimeout bounds every storage call, including its transaction;
+	// zero leaves calls bounded only by the caller's context.
+	QueryTimeout time.Duration `toml:"query_timeout"`
 }
 
 func NewConfig() *Config {
-	return &Config{}
-}
\ No newline at end of file
+	return &Config{
+		AutoMigrate:  true,
+		QueryTimeout: 5 * time.Second,
+	}
+}
@@ -0,0 +1,134 @@
+package postgres
+
+import (
+	"TaskManager/internal/models"
+	"context"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
+)
+
+// dependenciesLock namespaces the per-user advisory locks taken while
+// dependencies are added, keeping them apart from other locks on user IDs.
+const dependenciesLock int32 = 0x64657073 // "deps"
+
+// AddDependency marks task taskID as blocked by dependsOnID. Both tasks
+// must belong to the user, and the new edge must not close a cycle.
+func (s *Storage) AddDependency(ctx context.Context, userID, taskID, dependsOnID int) error {
+	ctx, cancel := s.withTimeout(ctx)
+	defer cancel()
+
+	tx, err := s.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	// Serialise dependency changes per user, so that two concurrent
+	// inserts cannot close a cycle between them.
+	if _, err := tx.ExecContext(ctx, "SELECT pg_advisory_xact_lock($1, $2)", dependenciesLock, userID); err != nil {
+		return err
+	}
+
+	if err := checkDependency(ctx, tx, userID, taskID, dependsOnID); err != nil {
+		return err
+	}
+
+	var cycle bool
+	err = tx.GetContext(ctx, &cycle, `WITH RECURSIVE reachable(id) AS (
+			SELECT $1::int
+			UNION
+			SELECT d.depends_on_id FROM task_dependencies d JOIN reachable r ON d.task_id = r.id
+		) SELECT EXISTS (SELECT 1 FROM reachable WHERE id=$2)`, dependsOnID, taskID)
+	if err != nil {
+		return err
+	}
+	if cycle {
+		return models.ErrDependencyCycle
+	}
+
+	res, err := tx.ExecContext(ctx, "INSERT INTO task_dependencies (task_id, depends_on_id) VALUES ($1, $2) ON CONFLICT DO NOTHING", taskID, dependsOnID)
+	if err != nil {
+		return err
+	}
+	if err := touchTask(ctx, tx, res, taskID); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// RemoveDependency removes the dependency of taskID on dependsOnID.
+// Removing a dependency that does not exist is not an error.
+func (s *Storage) RemoveDependency(ctx context.Context, userID, taskID, dependsOnID int) error {
+	ctx, cancel := s.withTimeout(ctx)
+	defer cancel()
+
+	tx, err := s.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if err := checkDependency(ctx, tx, userID, taskID, dependsOnID); err != nil {
+		return err
+	}
+
+	res, err := tx.ExecContext(ctx, "DELETE FROM task_dependencies WHERE task_id=$1 AND depends_on_id=$2", taskID, dependsOnID)
+	if err != nil {
+		return err
+	}
+	if err := touchTask(ctx, tx, res, taskID); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// checkDependency makes sure both ends of a dependency belong to the user.
+func checkDependency(ctx context.Context, tx *sqlx.Tx, userID, taskID, dependsOnID int) error {
+	var task, dependency bool
+	err := tx.QueryRowContext(ctx, `SELECT
+		EXISTS (SELECT 1 FROM tasks WHERE id=$1 AND user_id=$3),
+		EXISTS (SELECT 1 FROM tasks WHERE id=$2 AND user_id=$3)`,
+		taskID, dependsOnID, userID).Scan(&task, &dependency)
+	if err != nil {
+		return err
+	}
+
+	if !task {
+		return models.ErrTaskNotFound
+	}
+	if !dependency {
+		return models.ErrDependencyNotFound
+	}
+	if taskID == dependsOnID {
+		return models.ErrDependencyCycle
+	}
+	return nil
+}
+
+// loadDependencies fills in the dependencies of the given tasks and whether
+// any of them is unfinished.
+func loadDependencies(ctx context.Context, db sqlx.QueryerContext, byID map[int]*models.Task, ids []int64) error {
+	var rows []struct {
+		TaskID      int               `db:"task_id"`
+		DependsOnID int               `db:"depends_on_id"`
+		Status      models.TaskStatus `db:"status"`
+	}
+	err := sqlx.SelectContext(ctx, db, &rows, `SELECT d.task_id, d.depends_on_id, t.status
+		FROM task_dependencies d JOIN tasks t ON t.id = d.depends_on_id
+		WHERE d.task_id = ANY($1) ORDER BY d.depends_on_id`, pq.Array(ids))
+	if err != nil {
+		return err
+	}
+
+	for _, row := range rows {
+		task := byID[row.TaskID]
+		task.BlockedBy = append(task.BlockedBy, row.DependsOnID)
+		if !row.Status.Finished() {
+			task.Blocked = true
+		}
+	}
+	return nil
+}
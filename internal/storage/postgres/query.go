@@ -0,0 +1,36 @@
+package postgres
+
+import (
+	"strconv"
+	"strings"
+)
+
+// queryBuilder collects WHERE conditions and their positional arguments.
+type queryBuilder struct {
+	conditions []string
+	args       []interface{}
+}
+
+// arg appends v to the arguments and returns its placeholder.
+func (b *queryBuilder) arg(v interface{}) string {
+	b.args = append(b.args, v)
+	return "$" + strconv.Itoa(len(b.args))
+}
+
+func (b *queryBuilder) where(condition string) {
+	b.conditions = append(b.conditions, condition)
+}
+
+func (b *queryBuilder) whereClause() string {
+	if len(b.conditions) == 0 {
+		return ""
+	}
+	return " WHERE " + strings.Join(b.conditions, " AND ")
+}
+
+var likeEscaper = strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`)
+
+// escapeLike escapes the LIKE wildcards in s so it matches literally.
+func escapeLike(s string) string {
+	return likeEscaper.Replace(s)
+}
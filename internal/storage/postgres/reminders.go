@@ -0,0 +1,63 @@
+package postgres
+
+import (
+	"TaskManager/internal/models"
+	"context"
+	"time"
+
+	"github.com/lib/pq"
+)
+
+// remindersLock is the advisory lock held while reminders are fired, so
+// that of several instances sharing the database only one fires them.
+const remindersLock int64 = 0x72656d696e64 // "remind"
+
+// FireDueReminders records and returns the reminders of unfinished tasks
+// that fall in (from, to] for any of offsets and were not fired before.
+// While another instance is firing reminders it returns none.
+func (s *Storage) FireDueReminders(ctx context.Context, offsets []time.Duration, from, to time.Time) ([]models.Reminder, error) {
+	ctx, cancel := s.withTimeout(ctx)
+	defer cancel()
+
+	tx, err := s.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	var locked bool
+	if err := tx.GetContext(ctx, &locked, "SELECT pg_try_advisory_xact_lock($1)", remindersLock); err != nil {
+		return nil, err
+	}
+	if !locked {
+		return nil, nil
+	}
+
+	seconds := make([]int64, len(offsets))
+	for i, offset := range offsets {
+		seconds[i] = int64(offset / time.Second)
+	}
+
+	// The unique key on reminders skips the ones fired before, also by
+	// an instance that held the lock earlier.
+	var reminders []models.Reminder
+	err = tx.SelectContext(ctx, &reminders, `WITH fired AS (
+			INSERT INTO reminders (task_id, user_id, remind_offset, remind_at, fired_at)
+			SELECT tasks.id, tasks.user_id, o.seconds, tasks.scheduled_for - make_interval(secs => o.seconds), $1::timestamptz
+			FROM tasks CROSS JOIN unnest($2::bigint[]) AS o(seconds)
+			WHERE tasks.scheduled_for > $3::timestamptz + make_interval(secs => o.seconds)
+				AND tasks.scheduled_for <= $1::timestamptz + make_interval(secs => o.seconds)
+				AND `+unfinished+`
+			ON CONFLICT (task_id, remind_offset, remind_at) DO NOTHING
+			RETURNING id, task_id, user_id, remind_offset, remind_at, fired_at
+		)
+		SELECT fired.id, fired.task_id, fired.user_id, tasks.title, fired.remind_offset, fired.remind_at, fired.fired_at
+		FROM fired JOIN tasks ON tasks.id = fired.task_id
+		ORDER BY fired.remind_at, fired.id`,
+		to, pq.Array(seconds), from)
+	if err != nil {
+		return nil, err
+	}
+
+	return reminders, tx.Commit()
+}
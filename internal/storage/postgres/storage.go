@@ -2,12 +2,37 @@ package postgres
 
 import (
 	"TaskManager/internal/models"
+	"TaskManager/internal/storage/schema"
+	"TaskManager/migrations"
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
 	"time"
 
+	pgmigrate "github.com/golang-migrate/migrate/v4/database/postgres"
 	"github.com/jmoiron/sqlx"
-	_ "github.com/lib/pq"
+	"github.com/lib/pq"
 )
 
+const taskColumns = "id, title, description, created_at, scheduled_for, due_at, priority, status, parent_id, series_id, occurrence_at, version, user_id"
+
+var taskSortColumns = map[models.TaskSortField]string{
+	models.SortByID:           "id",
+	models.SortByCreatedAt:    "created_at",
+	models.SortByScheduledFor: "scheduled_for",
+	models.SortByTitle:        "title",
+	models.SortByPriority:     "priority",
+}
+
+// dueSortKey orders tasks by due date with undated ones last in the given
+// direction. It must agree with models.NoDueDateFor, which cursors carry
+// for undated tasks.
+func dueSortKey(descending bool) string {
+	return "COALESCE(due_at, '" + models.NoDueDateFor(descending).Format(time.RFC3339) + "'::timestamptz)"
+}
+
 type Storage struct {
 	config *Config
 	db     *sqlx.DB
@@ -19,13 +44,37 @@ func New(config *Config) *Storage {
 	}
 }
 
+// Open connects to the database and brings its schema up to date, or, with
+// AutoMigrate disabled, checks that the schema is not left dirty. It may be
+// called again after an error, reusing the connection if it was made.
 func (s *Storage) Open() error {
+	if s.db == nil {
+		if err := s.Connect(); err != nil {
+			return err
+		}
+	}
+
+	m, err := s.Migrator()
+	if err != nil {
+		return err
+	}
+	defer m.Close()
+
+	if s.config.AutoMigrate {
+		return m.Up(0)
+	}
+	return m.CheckClean()
+}
+
+// Connect opens the database without touching its schema.
+func (s *Storage) Connect() error {
 	db, err := sqlx.Open("postgres", s.config.DataBaseURL)
 	if err != nil {
 		return err
 	}
 
 	if err := db.Ping(); err != nil {
+		db.Close()
 		return err
 	}
 
@@ -34,6 +83,23 @@ func (s *Storage) Open() error {
 	return nil
 }
 
+// Migrator returns a schema.Migrator for the embedded Postgres migrations.
+// It holds a dedicated connection until closed.
+func (s *Storage) Migrator() (*schema.Migrator, error) {
+	conn, err := s.db.DB.Conn(context.Background())
+	if err != nil {
+		return nil, err
+	}
+
+	driver, err := pgmigrate.WithConnection(context.Background(), conn, &pgmigrate.Config{})
+	if err != nil {
+		conn.Close()
+		return nil, err
+	}
+
+	return schema.New(migrations.Postgres, ".", "postgres", driver)
+}
+
 func (s *Storage) Close() error {
 	if err := s.db.Close(); err != nil {
 		return err
@@ -42,58 +108,540 @@ func (s *Storage) Close() error {
 	return nil
 }
 
-func (s *Storage) CreateUser(username, password string) (int, error) {
+// DB returns the connection pool, e.g. to export its statistics.
+func (s *Storage) DB() *sql.DB {
+	return s.db.DB
+}
+
+// withTimeout bounds a storage call by QueryTimeout, if it is set.
+func (s *Storage) withTimeout(ctx context.Context) (context.Context, context.CancelFunc) {
+	if s.config.QueryTimeout <= 0 {
+		return ctx, func() {}
+	}
+	return context.WithTimeout(ctx, s.config.QueryTimeout)
+}
+
+// Ping checks that the database can be reached.
+func (s *Storage) Ping(ctx context.Context) error {
+	return s.db.PingContext(ctx)
+}
+
+func (s *Storage) CreateUser(ctx context.Context, username, passwordHash string) (int, error) {
+	ctx, cancel := s.withTimeout(ctx)
+	defer cancel()
+
 	var userID int
-	err := s.db.QueryRow("INSERT INTO users (username, password) VALUES ($1, $2) RETURNING id", username, password).Scan(&userID)
+	err := s.db.QueryRowContext(ctx, "INSERT INTO users (username, password) VALUES ($1, $2) RETURNING id", username, passwordHash).Scan(&userID)
 	if err != nil {
 		return 0, err
 	}
 	return userID, nil
 }
 
-func (s *Storage) GetUserByUsername(username string) (*models.User, error) {
+func (s *Storage) GetUserByUsername(ctx context.Context, username string) (*models.User, error) {
+	ctx, cancel := s.withTimeout(ctx)
+	defer cancel()
+
 	var user models.User
-	err := s.db.Get(&user, "SELECT * FROM users WHERE username=$1", username)
+	err := s.db.GetContext(ctx, &user, "SELECT id, username, password FROM users WHERE username=$1", username)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, models.ErrUserNotFound
+	}
 	if err != nil {
 		return nil, err
 	}
 	return &user, nil
 }
 
-func (s *Storage) GetUserByUsernameAndPassword(username, password string) (*models.User, error) {
-	var user models.User
-	err := s.db.Get(&user, "SELECT * FROM users WHERE username=$1 AND password=$2", username, password)
+func (s *Storage) UpdateUserPassword(ctx context.Context, userID int, passwordHash string) error {
+	ctx, cancel := s.withTimeout(ctx)
+	defer cancel()
+
+	res, err := s.db.ExecContext(ctx, "UPDATE users SET password=$1 WHERE id=$2", passwordHash, userID)
 	if err != nil {
-		return nil, err
+		return err
 	}
-	return &user, nil
+	return checkAffected(res, models.ErrUserNotFound)
 }
 
-func (s *Storage) GetTasks(userID int) ([]models.Task, error) {
-	var tasks []models.Task
-	err := s.db.Select(&tasks, "SELECT * FROM tasks WHERE user_id=$1", userID)
-	return tasks, err
+func (s *Storage) CreateRefreshToken(ctx context.Context, token *models.RefreshToken) error {
+	ctx, cancel := s.withTimeout(ctx)
+	defer cancel()
+
+	return s.db.QueryRowContext(ctx, "INSERT INTO refresh_tokens (user_id, token_hash, expires_at) VALUES ($1, $2, $3) RETURNING id, created_at",
+		token.UserID, token.TokenHash, token.ExpiresAt).Scan(&token.ID, &token.CreatedAt)
+}
+
+// RotateRefreshToken revokes the refresh token with hash oldHash and stores
+// next in its place for the same user. Presenting an already revoked token
+// revokes every token of its owner, as the token has most likely leaked.
+func (s *Storage) RotateRefreshToken(ctx context.Context, oldHash string, next *models.RefreshToken) error {
+	ctx, cancel := s.withTimeout(ctx)
+	defer cancel()
+
+	tx, err := s.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	var old models.RefreshToken
+	err = tx.GetContext(ctx, &old, "SELECT * FROM refresh_tokens WHERE token_hash=$1 FOR UPDATE", oldHash)
+	if errors.Is(err, sql.ErrNoRows) {
+		return models.ErrRefreshTokenInvalid
+	}
+	if err != nil {
+		return err
+	}
+
+	if old.RevokedAt != nil {
+		if err := revokeAllTokens(ctx, tx, old.UserID); err != nil {
+			return err
+		}
+		if err := tx.Commit(); err != nil {
+			return err
+		}
+		return models.ErrRefreshTokenReused
+	}
+
+	if time.Now().After(old.ExpiresAt) {
+		return models.ErrRefreshTokenInvalid
+	}
+
+	if _, err := tx.ExecContext(ctx, "UPDATE refresh_tokens SET revoked_at=now() WHERE id=$1", old.ID); err != nil {
+		return err
+	}
+
+	next.UserID = old.UserID
+	err = tx.QueryRowContext(ctx, "INSERT INTO refresh_tokens (user_id, token_hash, expires_at) VALUES ($1, $2, $3) RETURNING id, created_at",
+		next.UserID, next.TokenHash, next.ExpiresAt).Scan(&next.ID, &next.CreatedAt)
+	if err != nil {
+		return err
+	}
+
+	return tx.Commit()
 }
 
-func (s *Storage) CreateTask(userID int, title, description string, scheduledFor time.Time) error {
-	_, err := s.db.Exec("INSERT INTO tasks (title, description, created_at, scheduled_for, user_id) VALUES ($1, $2, $3, $4, $5)",
-		title, description, time.Now(), scheduledFor, userID)
+func (s *Storage) RevokeRefreshToken(ctx context.Context, userID int, tokenHash string) error {
+	ctx, cancel := s.withTimeout(ctx)
+	defer cancel()
+
+	_, err := s.db.ExecContext(ctx, "UPDATE refresh_tokens SET revoked_at=now() WHERE user_id=$1 AND token_hash=$2 AND revoked_at IS NULL",
+		userID, tokenHash)
 	return err
 }
 
-func (s *Storage) GetTaskByID(userID, taskID int) (*models.Task, error) {
-	var task models.Task
-	err := s.db.Get(&task, "SELECT * FROM tasks WHERE id=$1 AND user_id=$2", taskID, userID)
-	return &task, err
+func (s *Storage) RevokeAllTokens(ctx context.Context, userID int) error {
+	ctx, cancel := s.withTimeout(ctx)
+	defer cancel()
+
+	return revokeAllTokens(ctx, s.db, userID)
 }
 
-func (s *Storage) UpdateTask(userID int, task *models.Task) error {
-	_, err := s.db.Exec("UPDATE tasks SET title=$1, description=$2 WHERE id=$3 AND user_id=$4",
-		task.Title, task.Description, task.ID, userID)
+func revokeAllTokens(ctx context.Context, db sqlx.ExecerContext, userID int) error {
+	if _, err := db.ExecContext(ctx, "UPDATE refresh_tokens SET revoked_at=now() WHERE user_id=$1 AND revoked_at IS NULL", userID); err != nil {
+		return err
+	}
+
+	_, err := db.ExecContext(ctx, "UPDATE users SET tokens_revoked_at=now() WHERE id=$1", userID)
 	return err
 }
 
-func (s *Storage) DeleteTask(userID, taskID int) error {
-	_, err := s.db.Exec("DELETE FROM tasks WHERE id=$1 AND user_id=$2", taskID, userID)
+func (s *Storage) RevokeAccessToken(ctx context.Context, jti string, expiresAt time.Time) error {
+	ctx, cancel := s.withTimeout(ctx)
+	defer cancel()
+
+	// Entries are only useful until the token would have expired anyway.
+	if _, err := s.db.ExecContext(ctx, "DELETE FROM revoked_tokens WHERE expires_at < now()"); err != nil {
+		return err
+	}
+
+	_, err := s.db.ExecContext(ctx, "INSERT INTO revoked_tokens (jti, expires_at) VALUES ($1, $2) ON CONFLICT (jti) DO NOTHING",
+		jti, expiresAt)
 	return err
 }
+
+// IsAccessTokenRevoked reports whether the access token jti was revoked
+// individually, or was issued before its owner logged out everywhere. JWT
+// timestamps have second precision, so tokens issued in the second of the
+// logout are rejected too.
+func (s *Storage) IsAccessTokenRevoked(ctx context.Context, userID int, jti string, issuedAt time.Time) (bool, error) {
+	ctx, cancel := s.withTimeout(ctx)
+	defer cancel()
+
+	var revoked bool
+	err := s.db.GetContext(ctx, &revoked, `SELECT
+		EXISTS (SELECT 1 FROM revoked_tokens WHERE jti=$1) OR
+		EXISTS (SELECT 1 FROM users WHERE id=$2 AND date_trunc('second', tokens_revoked_at) >= $3)`,
+		jti, userID, issuedAt)
+	return revoked, err
+}
+
+func (s *Storage) GetTasks(ctx context.Context, userID int, query *models.TaskQuery) (*models.TaskPage, error) {
+	ctx, cancel := s.withTimeout(ctx)
+	defer cancel()
+
+	if query.SortBy == "" {
+		query.SortBy = models.SortByID
+	}
+	sortColumn, ok := taskSortColumns[query.SortBy]
+	if !ok {
+		return nil, fmt.Errorf("unknown sort field %q", query.SortBy)
+	}
+
+	var q queryBuilder
+	q.where("user_id = " + q.arg(userID))
+	if query.ScheduledFrom != nil {
+		q.where("scheduled_for >= " + q.arg(*query.ScheduledFrom))
+	}
+	if query.ScheduledTo != nil {
+		q.where("scheduled_for < " + q.arg(*query.ScheduledTo))
+	}
+	if query.CreatedFrom != nil {
+		q.where("created_at >= " + q.arg(*query.CreatedFrom))
+	}
+	if query.CreatedTo != nil {
+		q.where("created_at < " + q.arg(*query.CreatedTo))
+	}
+	if query.Title != "" {
+		q.where("title ILIKE " + q.arg("%"+escapeLike(query.Title)+"%"))
+	}
+	if len(query.Statuses) > 0 {
+		statuses := make([]string, len(query.Statuses))
+		for i, status := range query.Statuses {
+			statuses[i] = string(status)
+		}
+		q.where("status = ANY(" + q.arg(pq.Array(statuses)) + ")")
+	}
+	if len(query.Tags) > 0 {
+		tagged := "SELECT tt.task_id FROM task_tags tt JOIN tags t ON t.id = tt.tag_id WHERE t.name = ANY(" + q.arg(pq.Array(query.Tags)) + ")"
+		if query.TagMode != models.TagModeAny {
+			tagged += " GROUP BY tt.task_id HAVING count(*) = " + q.arg(len(query.Tags))
+		}
+		q.where("id IN (" + tagged + ")")
+	}
+
+	page := &models.TaskPage{Tasks: []models.Task{}}
+	if err := s.db.GetContext(ctx, &page.Total, "SELECT count(*) FROM tasks"+q.whereClause(), q.args...); err != nil {
+		return nil, err
+	}
+
+	direction, cmp := "ASC", ">"
+	if query.Descending {
+		direction, cmp = "DESC", "<"
+	}
+
+	if query.Cursor != "" {
+		cursor, err := models.DecodeTaskCursor(query, query.Cursor)
+		if err != nil {
+			return nil, err
+		}
+
+		switch query.SortBy {
+		case models.SortByID:
+			q.where(fmt.Sprintf("id %s %s", cmp, q.arg(cursor.ID)))
+		case models.SortByPriority:
+			priority, due, err := cursor.Priority()
+			if err != nil {
+				return nil, err
+			}
+			q.where(fmt.Sprintf("(priority, %s, id) %s (%s, %s, %s)",
+				dueSortKey(query.Descending), cmp, q.arg(priority), q.arg(due), q.arg(cursor.ID)))
+		default:
+			var value interface{} = cursor.Value
+			if query.SortBy != models.SortByTitle {
+				if value, err = cursor.Time(); err != nil {
+					return nil, err
+				}
+			}
+			q.where(fmt.Sprintf("(%s, id) %s (%s, %s)", sortColumn, cmp, q.arg(value), q.arg(cursor.ID)))
+		}
+	}
+
+	order := fmt.Sprintf("%s %s, id %s", sortColumn, direction, direction)
+	if query.SortBy == models.SortByPriority {
+		order = fmt.Sprintf("priority %s, %s %s, id %s", direction, dueSortKey(query.Descending), direction, direction)
+	}
+
+	stmt := "SELECT " + taskColumns + " FROM tasks" + q.whereClause() + " ORDER BY " + order
+	if query.Limit > 0 {
+		// One extra row tells whether there is a next page.
+		stmt += " LIMIT " + q.arg(query.Limit+1)
+	}
+
+	if err := s.db.SelectContext(ctx, &page.Tasks, stmt, q.args...); err != nil {
+		return nil, err
+	}
+
+	if query.Limit > 0 && len(page.Tasks) > query.Limit {
+		page.Tasks = page.Tasks[:query.Limit]
+		page.NextCursor = models.NewTaskCursor(query, &page.Tasks[query.Limit-1]).Encode()
+	}
+
+	tasks := make([]*models.Task, len(page.Tasks))
+	for i := range page.Tasks {
+		tasks[i] = &page.Tasks[i]
+	}
+	if err := loadDetails(ctx, s.db, tasks...); err != nil {
+		return nil, err
+	}
+
+	return page, nil
+}
+
+func (s *Storage) CreateTask(ctx context.Context, userID int, task *models.Task) error {
+	ctx, cancel := s.withTimeout(ctx)
+	defer cancel()
+
+	if task.Status == "" {
+		task.Status = models.StatusTodo
+	}
+	task.Tags = []models.Tag{}
+	task.Checklist = []models.ChecklistItem{}
+	task.Progress = nil
+	task.SeriesID, task.OccurrenceAt = nil, nil
+	task.UserID = userID
+
+	if task.ParentID == nil && task.RRule == "" {
+		return insertTask(ctx, s.db, task)
+	}
+
+	tx, err := s.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if task.ParentID != nil {
+		if err := checkParent(ctx, tx, userID, 0, *task.ParentID); err != nil {
+			return err
+		}
+	}
+
+	if task.RRule != "" {
+		// The task is the first occurrence of its series.
+		series := models.NewTaskSeries(task, task.RRule)
+		if err := insertSeries(ctx, tx, series); err != nil {
+			return err
+		}
+		task.SeriesID, task.OccurrenceAt = &series.ID, &series.DTStart
+	}
+	if err := insertTask(ctx, tx, task); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// insertTask stores a new task and fills in its ID, creation time and
+// version.
+func insertTask(ctx context.Context, db sqlx.QueryerContext, task *models.Task) error {
+	return db.QueryRowxContext(ctx, "INSERT INTO tasks (title, description, created_at, scheduled_for, due_at, priority, status, parent_id, series_id, occurrence_at, user_id) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11) RETURNING id, created_at, version",
+		task.Title, task.Description, time.Now(), task.ScheduledFor, task.DueAt, task.Priority, task.Status, task.ParentID, task.SeriesID, task.OccurrenceAt, task.UserID).Scan(&task.ID, &task.CreatedAt, &task.Version)
+}
+
+func (s *Storage) GetTaskByID(ctx context.Context, userID, taskID int) (*models.Task, error) {
+	ctx, cancel := s.withTimeout(ctx)
+	defer cancel()
+
+	var task models.Task
+	err := s.db.GetContext(ctx, &task, "SELECT "+taskColumns+" FROM tasks WHERE id=$1 AND user_id=$2", taskID, userID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, models.ErrTaskNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err := loadDetails(ctx, s.db, &task); err != nil {
+		return nil, err
+	}
+	return &task, nil
+}
+
+// UpdateTask writes the task and stores its new version in task.Version.
+// A non-zero task.Version makes the update conditional on the stored
+// version still being the same.
+func (s *Storage) UpdateTask(ctx context.Context, userID int, task *models.Task) error {
+	ctx, cancel := s.withTimeout(ctx)
+	defer cancel()
+
+	tx, err := s.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if task.ParentID != nil {
+		if err := checkParent(ctx, tx, userID, task.ID, *task.ParentID); err != nil {
+			return err
+		}
+	}
+
+	stmt := "UPDATE tasks SET title=$1, description=$2, scheduled_for=$3, due_at=$4, priority=$5, status=$6, parent_id=$7, version=version+1 WHERE id=$8 AND user_id=$9"
+	args := []interface{}{task.Title, task.Description, task.ScheduledFor, task.DueAt, task.Priority, task.Status, task.ParentID, task.ID, userID}
+	if task.Version > 0 {
+		stmt += " AND version=$10"
+		args = append(args, task.Version)
+	}
+
+	err = tx.QueryRowContext(ctx, stmt+" RETURNING version", args...).Scan(&task.Version)
+	if errors.Is(err, sql.ErrNoRows) {
+		return s.missingTask(ctx, userID, task.ID, task.Version)
+	}
+	if err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// PatchTask writes the fields set in patch and returns the updated task.
+// A non-zero version makes the update conditional on the stored version.
+func (s *Storage) PatchTask(ctx context.Context, userID, taskID int, patch *models.TaskPatch, version int) (*models.Task, error) {
+	ctx, cancel := s.withTimeout(ctx)
+	defer cancel()
+
+	tx, err := s.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	var q queryBuilder
+	set := []string{"version=version+1"}
+	if patch.Title != nil {
+		set = append(set, "title="+q.arg(*patch.Title))
+	}
+	if patch.Description != nil {
+		set = append(set, "description="+q.arg(*patch.Description))
+	}
+	if patch.ScheduledFor != nil {
+		set = append(set, "scheduled_for="+q.arg(*patch.ScheduledFor))
+	}
+	if patch.DueAt != nil {
+		set = append(set, "due_at="+q.arg(*patch.DueAt))
+	} else if patch.ClearDueAt {
+		set = append(set, "due_at=NULL")
+	}
+	if patch.Priority != nil {
+		set = append(set, "priority="+q.arg(*patch.Priority))
+	}
+	if patch.ParentID != nil {
+		if err := checkParent(ctx, tx, userID, taskID, *patch.ParentID); err != nil {
+			return nil, err
+		}
+		set = append(set, "parent_id="+q.arg(*patch.ParentID))
+	} else if patch.ClearParent {
+		set = append(set, "parent_id=NULL")
+	}
+	if patch.Status != nil {
+		set = append(set, "status="+q.arg(*patch.Status))
+	}
+
+	q.where("id = " + q.arg(taskID))
+	q.where("user_id = " + q.arg(userID))
+	if version > 0 {
+		q.where("version = " + q.arg(version))
+	}
+
+	var task models.Task
+	err = tx.GetContext(ctx, &task, "UPDATE tasks SET "+strings.Join(set, ", ")+q.whereClause()+" RETURNING "+taskColumns, q.args...)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, s.missingTask(ctx, userID, taskID, version)
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err := loadDetails(ctx, tx, &task); err != nil {
+		return nil, err
+	}
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+	return &task, nil
+}
+
+// UpdateTaskStatus moves the task to status. A non-zero version makes the
+// update conditional on the stored version of the task.
+func (s *Storage) UpdateTaskStatus(ctx context.Context, userID, taskID int, status models.TaskStatus, version int) error {
+	ctx, cancel := s.withTimeout(ctx)
+	defer cancel()
+
+	stmt := "UPDATE tasks SET status=$1, version=version+1 WHERE id=$2 AND user_id=$3"
+	args := []interface{}{status, taskID, userID}
+	if version > 0 {
+		stmt += " AND version=$4"
+		args = append(args, version)
+	}
+
+	res, err := s.db.ExecContext(ctx, stmt, args...)
+	if err != nil {
+		return err
+	}
+	if err := checkAffected(res, models.ErrTaskNotFound); err != nil {
+		if errors.Is(err, models.ErrTaskNotFound) {
+			return s.missingTask(ctx, userID, taskID, version)
+		}
+		return err
+	}
+	return nil
+}
+
+// DeleteTask removes the task together with its subtasks and returns the
+// tasks removed. A non-zero version makes the delete conditional on the
+// stored version of the task.
+func (s *Storage) DeleteTask(ctx context.Context, userID, taskID, version int) ([]models.Task, error) {
+	ctx, cancel := s.withTimeout(ctx)
+	defer cancel()
+
+	root := "SELECT id FROM tasks WHERE id=$1 AND user_id=$2"
+	args := []interface{}{taskID, userID}
+	if version > 0 {
+		root += " AND version=$3"
+		args = append(args, version)
+	}
+
+	// The subtasks would go with the task anyway, by ON DELETE CASCADE;
+	// deleting them in the same statement returns them too.
+	var deleted []models.Task
+	err := s.db.SelectContext(ctx, &deleted, `WITH RECURSIVE subtree AS (
+			`+root+`
+			UNION ALL
+			SELECT tasks.id FROM tasks JOIN subtree ON tasks.parent_id = subtree.id
+		)
+		DELETE FROM tasks WHERE id IN (SELECT id FROM subtree) RETURNING `+taskColumns, args...)
+	if err != nil {
+		return nil, err
+	}
+	if len(deleted) == 0 {
+		return nil, s.missingTask(ctx, userID, taskID, version)
+	}
+	return deleted, nil
+}
+
+// missingTask explains why a conditional write touched no row.
+func (s *Storage) missingTask(ctx context.Context, userID, taskID, version int) error {
+	if version == 0 {
+		return models.ErrTaskNotFound
+	}
+
+	var exists bool
+	if err := s.db.GetContext(ctx, &exists, "SELECT EXISTS (SELECT 1 FROM tasks WHERE id=$1 AND user_id=$2)", taskID, userID); err != nil {
+		return err
+	}
+	if exists {
+		return models.ErrVersionConflict
+	}
+	return models.ErrTaskNotFound
+}
+
+// checkAffected returns notFound if the statement did not touch any row.
+func checkAffected(res sql.Result, notFound error) error {
+	n, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if n == 0 {
+		return notFound
+	}
+	return nil
+}
@@ -0,0 +1,118 @@
+package postgres
+
+import (
+	"TaskManager/internal/models"
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+)
+
+const webhookColumns = "id, user_id, url, secret, events, created_at"
+
+func (s *Storage) GetWebhooks(ctx context.Context, userID int) ([]models.Webhook, error) {
+	ctx, cancel := s.withTimeout(ctx)
+	defer cancel()
+
+	webhooks := []models.Webhook{}
+	err := s.db.SelectContext(ctx, &webhooks, "SELECT "+webhookColumns+" FROM webhooks WHERE user_id=$1 ORDER BY id", userID)
+	return webhooks, err
+}
+
+func (s *Storage) CreateWebhook(ctx context.Context, userID int, webhook *models.Webhook) error {
+	ctx, cancel := s.withTimeout(ctx)
+	defer cancel()
+
+	webhook.UserID = userID
+
+	return s.db.QueryRowContext(ctx, "INSERT INTO webhooks (user_id, url, secret, events) VALUES ($1, $2, $3, $4) RETURNING id, created_at",
+		userID, webhook.URL, webhook.Secret, webhook.Events).Scan(&webhook.ID, &webhook.CreatedAt)
+}
+
+// UpdateWebhook changes the URL and events of a webhook, and its secret
+// unless webhook.Secret is empty. The stored webhook is read back into
+// webhook.
+func (s *Storage) UpdateWebhook(ctx context.Context, userID int, webhook *models.Webhook) error {
+	ctx, cancel := s.withTimeout(ctx)
+	defer cancel()
+
+	err := s.db.QueryRowxContext(ctx, `UPDATE webhooks SET url=$1, events=$2, secret=COALESCE(NULLIF($3, ''), secret)
+		WHERE id=$4 AND user_id=$5 RETURNING `+webhookColumns,
+		webhook.URL, webhook.Events, webhook.Secret, webhook.ID, userID).StructScan(webhook)
+	if errors.Is(err, sql.ErrNoRows) {
+		return models.ErrWebhookNotFound
+	}
+	return err
+}
+
+// DeleteWebhook removes a webhook together with its pending deliveries.
+func (s *Storage) DeleteWebhook(ctx context.Context, userID, webhookID int) error {
+	ctx, cancel := s.withTimeout(ctx)
+	defer cancel()
+
+	res, err := s.db.ExecContext(ctx, "DELETE FROM webhooks WHERE id=$1 AND user_id=$2", webhookID, userID)
+	if err != nil {
+		return err
+	}
+	return checkAffected(res, models.ErrWebhookNotFound)
+}
+
+// EnqueueEvent adds an event to the outbox of every webhook of the user
+// that subscribes to it.
+func (s *Storage) EnqueueEvent(ctx context.Context, userID int, event models.EventType, payload []byte) error {
+	ctx, cancel := s.withTimeout(ctx)
+	defer cancel()
+
+	_, err := s.db.ExecContext(ctx, `INSERT INTO webhook_deliveries (webhook_id, event, payload, next_attempt_at)
+		SELECT id, $2::text, $3, $4 FROM webhooks
+		WHERE user_id=$1 AND (events = '' OR $2::text = ANY(string_to_array(events, ',')))`,
+		userID, event, string(payload), time.Now())
+	return err
+}
+
+// ClaimDeliveries returns up to limit deliveries due at now and hides them
+// from other callers until leaseUntil, counting the attempt. Deliveries
+// claimed by another instance are skipped.
+func (s *Storage) ClaimDeliveries(ctx context.Context, now, leaseUntil time.Time, limit int) ([]models.WebhookDelivery, error) {
+	ctx, cancel := s.withTimeout(ctx)
+	defer cancel()
+
+	deliveries := []models.WebhookDelivery{}
+	err := s.db.SelectContext(ctx, &deliveries, `UPDATE webhook_deliveries d SET attempts=d.attempts+1, next_attempt_at=$2
+		FROM webhooks w
+		WHERE w.id = d.webhook_id AND d.id IN (
+			SELECT id FROM webhook_deliveries WHERE next_attempt_at <= $1
+			ORDER BY next_attempt_at, id LIMIT $3 FOR UPDATE SKIP LOCKED)
+		RETURNING d.id, d.webhook_id, w.url, w.secret, d.event, d.payload, d.attempts`,
+		now, leaseUntil, limit)
+	return deliveries, err
+}
+
+// CompleteDelivery marks a delivery as delivered.
+func (s *Storage) CompleteDelivery(ctx context.Context, deliveryID int, at time.Time) error {
+	ctx, cancel := s.withTimeout(ctx)
+	defer cancel()
+
+	_, err := s.db.ExecContext(ctx, "UPDATE webhook_deliveries SET next_attempt_at=NULL, delivered_at=$1, last_error='' WHERE id=$2", at, deliveryID)
+	return err
+}
+
+// RetryDelivery records a failed attempt and schedules the next one, or
+// gives up on the delivery if next is nil.
+func (s *Storage) RetryDelivery(ctx context.Context, deliveryID int, next *time.Time, lastError string) error {
+	ctx, cancel := s.withTimeout(ctx)
+	defer cancel()
+
+	_, err := s.db.ExecContext(ctx, "UPDATE webhook_deliveries SET next_attempt_at=$1, last_error=$2 WHERE id=$3", next, lastError, deliveryID)
+	return err
+}
+
+// DeleteDeliveries removes the deliveries created before the given time
+// that were delivered or given up on.
+func (s *Storage) DeleteDeliveries(ctx context.Context, before time.Time) error {
+	ctx, cancel := s.withTimeout(ctx)
+	defer cancel()
+
+	_, err := s.db.ExecContext(ctx, "DELETE FROM webhook_deliveries WHERE next_attempt_at IS NULL AND created_at < $1", before)
+	return err
+}
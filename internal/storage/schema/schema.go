@@ -0,0 +1,104 @@
+package schema
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+
+	"github.com/golang-migrate/migrate/v4"
+	"github.com/golang-migrate/migrate/v4/database"
+	"github.com/golang-migrate/migrate/v4/source/iofs"
+)
+
+var ErrDirty = errors.New("database schema is dirty, fix it and run `migrate force`")
+
+// Migrator applies embedded SQL migrations with golang-migrate.
+type Migrator struct {
+	m *migrate.Migrate
+}
+
+// New returns a Migrator reading migrations from dir in files and applying
+// them through driver. Closing the Migrator closes driver.
+func New(files fs.FS, dir, databaseName string, driver database.Driver) (*Migrator, error) {
+	source, err := iofs.New(files, dir)
+	if err != nil {
+		return nil, err
+	}
+
+	m, err := migrate.NewWithInstance("iofs", source, databaseName, driver)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Migrator{
+		m: m,
+	}, nil
+}
+
+func (m *Migrator) Close() error {
+	sourceErr, databaseErr := m.m.Close()
+	if sourceErr != nil {
+		return sourceErr
+	}
+	return databaseErr
+}
+
+// Up applies n pending migrations, or all of them if n is 0.
+func (m *Migrator) Up(n int) error {
+	if err := m.CheckClean(); err != nil {
+		return err
+	}
+
+	var err error
+	if n > 0 {
+		err = m.m.Steps(n)
+	} else {
+		err = m.m.Up()
+	}
+
+	if errors.Is(err, migrate.ErrNoChange) {
+		return nil
+	}
+	return err
+}
+
+// Down reverts the last n applied migrations.
+func (m *Migrator) Down(n int) error {
+	if err := m.CheckClean(); err != nil {
+		return err
+	}
+
+	err := m.m.Steps(-n)
+	if errors.Is(err, migrate.ErrNoChange) {
+		return nil
+	}
+	return err
+}
+
+// Force sets the schema version without running migrations and clears the
+// dirty flag.
+func (m *Migrator) Force(version int) error {
+	return m.m.Force(version)
+}
+
+// Status returns the current schema version. A database without any applied
+// migration has version 0.
+func (m *Migrator) Status() (version uint, dirty bool, err error) {
+	version, dirty, err = m.m.Version()
+	if errors.Is(err, migrate.ErrNilVersion) {
+		return 0, false, nil
+	}
+	return version, dirty, err
+}
+
+// CheckClean returns ErrDirty if a previous migration failed halfway.
+func (m *Migrator) CheckClean() error {
+	version, dirty, err := m.Status()
+	if err != nil {
+		return err
+	}
+	if dirty {
+		return fmt.Errorf("%w (version %d)", ErrDirty, version)
+	}
+	return nil
+}
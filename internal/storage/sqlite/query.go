@@ -0,0 +1,51 @@
+package sqlite
+
+import (
+	"strings"
+	"time"
+)
+
+// queryBuilder collects WHERE conditions and their positional arguments.
+type queryBuilder struct {
+	conditions []string
+	args       []interface{}
+}
+
+// where appends a condition with one ? placeholder per argument.
+func (b *queryBuilder) where(condition string, args ...interface{}) {
+	b.conditions = append(b.conditions, condition)
+	b.args = append(b.args, args...)
+}
+
+func (b *queryBuilder) whereClause() string {
+	if len(b.conditions) == 0 {
+		return ""
+	}
+	return " WHERE " + strings.Join(b.conditions, " AND ")
+}
+
+var likeEscaper = strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`)
+
+// escapeLike escapes the LIKE wildcards in s so it matches literally.
+func escapeLike(s string) string {
+	return likeEscaper.Replace(s)
+}
+
+// placeholders returns n comma separated ? placeholders.
+func placeholders(n int) string {
+	return strings.TrimSuffix(strings.Repeat("?, ", n), ", ")
+}
+
+// utc normalises timestamps before they are written or compared. SQLite
+// stores them as text, so a single zone keeps lexical and time order equal.
+func utc(t time.Time) time.Time {
+	return t.UTC()
+}
+
+// utcPtr is utc for nullable timestamps.
+func utcPtr(t *time.Time) interface{} {
+	if t == nil {
+		return nil
+	}
+	return t.UTC()
+}
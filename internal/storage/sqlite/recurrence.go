@@ -0,0 +1,296 @@
+package sqlite
+
+import (
+	"TaskManager/internal/models"
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+)
+
+const seriesColumns = "id, user_id, rrule, dtstart, materialized_until, title, description, priority, due_offset"
+
+// unfinished matches the tasks that are neither done nor cancelled.
+const unfinished = "status NOT IN ('done', 'cancelled')"
+
+// MaterializeOccurrences creates the occurrences of a series up to until,
+// and the next one if every occurrence created so far is finished. It
+// returns the occurrences created.
+func (s *Storage) MaterializeOccurrences(ctx context.Context, userID, seriesID int, until time.Time) ([]models.Task, error) {
+	ctx, cancel := s.withTimeout(ctx)
+	defer cancel()
+
+	tx, err := s.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	series, err := lockSeries(ctx, tx, userID, seriesID)
+	if err != nil {
+		return nil, err
+	}
+
+	created, err := materialize(ctx, tx, series, until)
+	if err != nil {
+		return nil, err
+	}
+
+	return created, tx.Commit()
+}
+
+// MaterializeDueOccurrences is MaterializeOccurrences for every series
+// that has not been created up to until yet or whose occurrences are all
+// finished. SQLite databases are not shared between instances, so unlike
+// Postgres no lock is taken.
+func (s *Storage) MaterializeDueOccurrences(ctx context.Context, until time.Time) ([]models.Task, error) {
+	ctx, cancel := s.withTimeout(ctx)
+	defer cancel()
+
+	tx, err := s.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	var due []models.TaskSeries
+	err = tx.SelectContext(ctx, &due, `SELECT `+seriesColumns+` FROM task_series
+		WHERE materialized_until < ? OR NOT EXISTS (SELECT 1 FROM tasks WHERE series_id=task_series.id AND `+unfinished+`)
+		ORDER BY id`, utc(until))
+	if err != nil {
+		return nil, err
+	}
+
+	var created []models.Task
+	for i := range due {
+		tasks, err := materialize(ctx, tx, &due[i], until)
+		if err != nil {
+			return nil, err
+		}
+		created = append(created, tasks...)
+	}
+
+	return created, tx.Commit()
+}
+
+// materialize creates the pending occurrences of a series.
+func materialize(ctx context.Context, tx *sqlx.Tx, series *models.TaskSeries, until time.Time) ([]models.Task, error) {
+	var open bool
+	if err := tx.GetContext(ctx, &open, "SELECT EXISTS (SELECT 1 FROM tasks WHERE series_id=? AND "+unfinished+")", series.ID); err != nil {
+		return nil, err
+	}
+
+	pending, err := series.Pending(until, !open)
+	if err != nil || len(pending) == 0 {
+		return nil, err
+	}
+
+	created := make([]models.Task, 0, len(pending))
+	for _, at := range pending {
+		task := series.Occurrence(at)
+		if err := insertTask(ctx, tx, &task); err != nil {
+			return nil, err
+		}
+		created = append(created, task)
+	}
+
+	if _, err := tx.ExecContext(ctx, "UPDATE task_series SET materialized_until=? WHERE id=?", utc(pending[len(pending)-1]), series.ID); err != nil {
+		return nil, err
+	}
+
+	return created, nil
+}
+
+// UpdateFollowingOccurrences applies patch to a recurring task and to the
+// unfinished occurrences after it, which are split off into a series of
+// their own. A task that does not recur yet starts a new series if patch
+// sets a rule.
+func (s *Storage) UpdateFollowingOccurrences(ctx context.Context, userID, taskID int, patch *models.SeriesPatch) (*models.Task, error) {
+	ctx, cancel := s.withTimeout(ctx)
+	defer cancel()
+
+	tx, err := s.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	var task models.Task
+	err = tx.GetContext(ctx, &task, "SELECT "+taskColumns+" FROM tasks WHERE id=? AND user_id=?", taskID, userID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, models.ErrTaskNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if task.SeriesID == nil {
+		if patch.RRule == nil || *patch.RRule == "" {
+			return nil, models.ErrNotRecurring
+		}
+
+		patch.ApplyTo(&task)
+		series := models.NewTaskSeries(&task, *patch.RRule)
+		if err := insertSeries(ctx, tx, series); err != nil {
+			return nil, err
+		}
+		task.SeriesID, task.OccurrenceAt = &series.ID, &series.DTStart
+	} else if err := updateFollowing(ctx, tx, &task, patch); err != nil {
+		return nil, err
+	}
+
+	err = tx.GetContext(ctx, &task.Version, `UPDATE tasks SET title=?, description=?, priority=?, scheduled_for=?, due_at=?,
+			series_id=?, occurrence_at=?, version=version+1
+		WHERE id=? RETURNING version`,
+		task.Title, task.Description, task.Priority, utc(task.ScheduledFor), utcPtr(task.DueAt), task.SeriesID, utcPtr(task.OccurrenceAt), task.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := loadDetails(ctx, tx, &task); err != nil {
+		return nil, err
+	}
+
+	return &task, tx.Commit()
+}
+
+// updateFollowing splits the series of task at it and applies patch to the
+// new series and its unfinished occurrences. The task itself is left for
+// the caller to write.
+func updateFollowing(ctx context.Context, tx *sqlx.Tx, task *models.Task, patch *models.SeriesPatch) error {
+	series, err := lockSeries(ctx, tx, task.UserID, *task.SeriesID)
+	if err != nil {
+		return err
+	}
+
+	at := *task.OccurrenceAt
+	before, after, err := series.Split(at)
+	if err != nil {
+		return err
+	}
+
+	stop := patch.RRule != nil && *patch.RRule == ""
+	if at.After(series.DTStart) || stop {
+		// Earlier occurrences stay with the series as it was.
+		if _, err := tx.ExecContext(ctx, "UPDATE task_series SET rrule=? WHERE id=?", before, series.ID); err != nil {
+			return err
+		}
+	}
+
+	if stop {
+		_, err := tx.ExecContext(ctx, "DELETE FROM tasks WHERE series_id=? AND occurrence_at > ? AND id <> ? AND "+unfinished,
+			series.ID, utc(at), task.ID)
+		if err != nil {
+			return err
+		}
+
+		patch.ApplyTo(task)
+		task.SeriesID, task.OccurrenceAt = nil, nil
+		return nil
+	}
+
+	if at.After(series.DTStart) {
+		series.RRule, series.DTStart = after, at
+		if err := insertSeries(ctx, tx, series); err != nil {
+			return err
+		}
+		_, err := tx.ExecContext(ctx, "UPDATE tasks SET series_id=? WHERE series_id=? AND occurrence_at >= ?",
+			series.ID, *task.SeriesID, utc(at))
+		if err != nil {
+			return err
+		}
+		task.SeriesID = &series.ID
+	}
+
+	reschedule := patch.Reschedules(task)
+	series.Apply(patch)
+	if patch.RRule != nil {
+		series.RRule = *patch.RRule
+	}
+	patch.ApplyTo(task)
+
+	if reschedule {
+		// Later occurrences no longer fit and are created again from the
+		// new rule and start.
+		_, err := tx.ExecContext(ctx, "DELETE FROM tasks WHERE series_id=? AND occurrence_at > ? AND id <> ? AND "+unfinished,
+			series.ID, utc(at), task.ID)
+		if err != nil {
+			return err
+		}
+
+		series.DTStart = task.ScheduledFor.Truncate(time.Second).UTC()
+		series.MaterializedUntil = series.DTStart
+		task.OccurrenceAt = &series.DTStart
+	} else {
+		_, err := tx.ExecContext(ctx, `UPDATE tasks SET title=COALESCE(?, title), description=COALESCE(?, description),
+				priority=COALESCE(?, priority), version=version+1
+			WHERE series_id=? AND occurrence_at > ? AND `+unfinished,
+			patch.Title, patch.Description, patch.Priority, series.ID, utc(at))
+		if err != nil {
+			return err
+		}
+	}
+
+	_, err = tx.ExecContext(ctx, `UPDATE task_series SET rrule=?, dtstart=?, materialized_until=?, title=?, description=?, priority=?
+		WHERE id=?`,
+		series.RRule, utc(series.DTStart), utc(series.MaterializedUntil), series.Title, series.Description, series.Priority, series.ID)
+	return err
+}
+
+// insertSeries stores a new series and fills in its ID.
+func insertSeries(ctx context.Context, tx *sqlx.Tx, series *models.TaskSeries) error {
+	return tx.QueryRowContext(ctx, `INSERT INTO task_series (user_id, rrule, dtstart, materialized_until, title, description, priority, due_offset)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?) RETURNING id`,
+		series.UserID, series.RRule, utc(series.DTStart), utc(series.MaterializedUntil), series.Title, series.Description, series.Priority, series.DueOffset).Scan(&series.ID)
+}
+
+// lockSeries loads the series after checking that it belongs to the user.
+// SQLite transactions are serialised on the single connection, so no row
+// lock is needed.
+func lockSeries(ctx context.Context, tx *sqlx.Tx, userID, seriesID int) (*models.TaskSeries, error) {
+	var series models.TaskSeries
+	err := tx.GetContext(ctx, &series, "SELECT "+seriesColumns+" FROM task_series WHERE id=? AND user_id=?", seriesID, userID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, models.ErrNotRecurring
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &series, nil
+}
+
+// loadRRules fills in the recurrence rule of the given tasks that belong to
+// a series.
+func loadRRules(ctx context.Context, db sqlx.QueryerContext, tasks ...*models.Task) error {
+	var ids []interface{}
+	for _, task := range tasks {
+		task.RRule = ""
+		if task.SeriesID != nil {
+			ids = append(ids, *task.SeriesID)
+		}
+	}
+	if len(ids) == 0 {
+		return nil
+	}
+
+	var rows []struct {
+		ID    int    `db:"id"`
+		RRule string `db:"rrule"`
+	}
+	if err := sqlx.SelectContext(ctx, db, &rows, "SELECT id, rrule FROM task_series WHERE id IN ("+placeholders(len(ids))+")", ids...); err != nil {
+		return err
+	}
+
+	rules := make(map[int]string, len(rows))
+	for _, row := range rows {
+		rules[row.ID] = row.RRule
+	}
+	for _, task := range tasks {
+		if task.SeriesID != nil {
+			task.RRule = rules[*task.SeriesID]
+		}
+	}
+	return nil
+}
@@ -0,0 +1,91 @@
+package sqlite
+
+import (
+	"TaskManager/internal/models"
+	"context"
+	"sort"
+	"time"
+)
+
+// FireDueReminders records and returns the reminders of unfinished tasks
+// that fall in (from, to] for any of offsets and were not fired before.
+// SQLite databases are not shared between instances, so unlike Postgres no
+// lock is taken.
+func (s *Storage) FireDueReminders(ctx context.Context, offsets []time.Duration, from, to time.Time) ([]models.Reminder, error) {
+	ctx, cancel := s.withTimeout(ctx)
+	defer cancel()
+
+	if len(offsets) == 0 {
+		return nil, nil
+	}
+
+	earliest, latest := offsets[0], offsets[0]
+	for _, offset := range offsets {
+		if offset < earliest {
+			earliest = offset
+		}
+		if offset > latest {
+			latest = offset
+		}
+	}
+
+	tx, err := s.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	var tasks []models.Task
+	err = tx.SelectContext(ctx, &tasks, "SELECT id, user_id, title, scheduled_for FROM tasks WHERE scheduled_for > ? AND scheduled_for <= ? AND "+unfinished+" ORDER BY scheduled_for, id",
+		utc(from.Add(earliest)), utc(to.Add(latest)))
+	if err != nil {
+		return nil, err
+	}
+
+	var reminders []models.Reminder
+	for _, task := range tasks {
+		for _, offset := range offsets {
+			reminder := models.Reminder{
+				TaskID:   task.ID,
+				UserID:   task.UserID,
+				Title:    task.Title,
+				Offset:   int64(offset / time.Second),
+				RemindAt: utc(task.ScheduledFor.Add(-offset)),
+				FiredAt:  utc(to),
+			}
+			if !reminder.RemindAt.After(from) || reminder.RemindAt.After(to) {
+				continue
+			}
+
+			res, err := tx.ExecContext(ctx, `INSERT OR IGNORE INTO reminders (task_id, user_id, remind_offset, remind_at, fired_at)
+				VALUES (?, ?, ?, ?, ?)`,
+				reminder.TaskID, reminder.UserID, reminder.Offset, reminder.RemindAt, reminder.FiredAt)
+			if err != nil {
+				return nil, err
+			}
+			n, err := res.RowsAffected()
+			if err != nil {
+				return nil, err
+			}
+			if n == 0 {
+				// Fired before.
+				continue
+			}
+
+			id, err := res.LastInsertId()
+			if err != nil {
+				return nil, err
+			}
+			reminder.ID = int(id)
+			reminders = append(reminders, reminder)
+		}
+	}
+
+	sort.Slice(reminders, func(i, j int) bool {
+		if !reminders[i].RemindAt.Equal(reminders[j].RemindAt) {
+			return reminders[i].RemindAt.Before(reminders[j].RemindAt)
+		}
+		return reminders[i].ID < reminders[j].ID
+	})
+	return reminders, tx.Commit()
+}
@@ -0,0 +1,704 @@
+package sqlite
+
+import (
+	"TaskManager/internal/models"
+	"TaskManager/internal/storage/schema"
+	"TaskManager/migrations"
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/golang-migrate/migrate/v4/database"
+	sqlitemigrate "github.com/golang-migrate/migrate/v4/database/sqlite3"
+	"github.com/jmoiron/sqlx"
+	"github.com/mattn/go-sqlite3"
+)
+
+const taskColumns = "id, title, description, created_at, scheduled_for, due_at, priority, status, parent_id, series_id, occurrence_at, version, user_id"
+
+var taskSortColumns = map[models.TaskSortField]string{
+	models.SortByID:           "id",
+	models.SortByCreatedAt:    "created_at",
+	models.SortByScheduledFor: "scheduled_for",
+	models.SortByTitle:        "title",
+	models.SortByPriority:     "priority",
+}
+
+// dueSortKey orders tasks by due date with undated ones last in the given
+// direction. It must agree with models.NoDueDateFor, which cursors carry
+// for undated tasks, and with the text format the driver stores timestamps
+// in.
+func dueSortKey(descending bool) string {
+	return "COALESCE(due_at, '" + models.NoDueDateFor(descending).Format(sqlite3.SQLiteTimestampFormats[0]) + "')"
+}
+
+type Storage struct {
+	config *Config
+	db     *sqlx.DB
+}
+
+func New(config *Config) *Storage {
+	return &Storage{
+		config: config,
+	}
+}
+
+// Open connects to the database and brings its schema up to date, or, with
+// AutoMigrate disabled, checks that the schema is not left dirty. It may be
+// called again after an error, reusing the connection if it was made.
+func (s *Storage) Open() error {
+	if s.db == nil {
+		if err := s.Connect(); err != nil {
+			return err
+		}
+	}
+
+	m, err := s.Migrator()
+	if err != nil {
+		return err
+	}
+	defer m.Close()
+
+	if s.config.AutoMigrate {
+		return m.Up(0)
+	}
+	return m.CheckClean()
+}
+
+// Connect opens the database without touching its schema.
+func (s *Storage) Connect() error {
+	dsn, err := DSN(s.config.DataBaseURL)
+	if err != nil {
+		return err
+	}
+
+	db, err := sqlx.Open("sqlite3", dsn)
+	if err != nil {
+		return err
+	}
+
+	// SQLite allows a single writer; one connection avoids "database is
+	// locked" errors and keeps :memory: databases shared.
+	db.SetMaxOpenConns(1)
+
+	if err := db.Ping(); err != nil {
+		db.Close()
+		return err
+	}
+
+	s.db = db
+
+	return nil
+}
+
+func (s *Storage) Close() error {
+	if err := s.db.Close(); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// Migrator returns a schema.Migrator for the embedded SQLite migrations.
+func (s *Storage) Migrator() (*schema.Migrator, error) {
+	driver, err := sqlitemigrate.WithInstance(s.db.DB, &sqlitemigrate.Config{})
+	if err != nil {
+		return nil, err
+	}
+
+	return schema.New(migrations.SQLite, "sqlite", "sqlite3", keepOpen{driver})
+}
+
+// keepOpen stops the migration driver from closing the shared *sql.DB.
+type keepOpen struct {
+	database.Driver
+}
+
+func (keepOpen) Close() error {
+	return nil
+}
+
+// DSN converts a sqlite://path database URL into a go-sqlite3 data source
+// name with foreign keys enabled.
+func DSN(databaseURL string) (string, error) {
+	path, found := strings.CutPrefix(databaseURL, "sqlite://")
+	if !found {
+		path, found = strings.CutPrefix(databaseURL, "sqlite3://")
+	}
+	if !found {
+		return "", fmt.Errorf("not a sqlite database url: %q", databaseURL)
+	}
+
+	path, rawQuery, _ := strings.Cut(path, "?")
+	params, err := url.ParseQuery(rawQuery)
+	if err != nil {
+		return "", err
+	}
+	params.Set("_foreign_keys", "1")
+	params.Set("_busy_timeout", "5000")
+
+	return "file:" + path + "?" + params.Encode(), nil
+}
+
+// DB returns the connection pool, e.g. to export its statistics.
+func (s *Storage) DB() *sql.DB {
+	return s.db.DB
+}
+
+// withTimeout bounds a storage call by QueryTimeout, if it is set.
+func (s *Storage) withTimeout(ctx context.Context) (context.Context, context.CancelFunc) {
+	if s.config.QueryTimeout <= 0 {
+		return ctx, func() {}
+	}
+	return context.WithTimeout(ctx, s.config.QueryTimeout)
+}
+
+// Ping checks that the database can be reached.
+func (s *Storage) Ping(ctx context.Context) error {
+	return s.db.PingContext(ctx)
+}
+
+func (s *Storage) CreateUser(ctx context.Context, username, passwordHash string) (int, error) {
+	ctx, cancel := s.withTimeout(ctx)
+	defer cancel()
+
+	var userID int
+	err := s.db.QueryRowContext(ctx, "INSERT INTO users (username, password) VALUES (?, ?) RETURNING id", username, passwordHash).Scan(&userID)
+	if err != nil {
+		return 0, err
+	}
+	return userID, nil
+}
+
+func (s *Storage) GetUserByUsername(ctx context.Context, username string) (*models.User, error) {
+	ctx, cancel := s.withTimeout(ctx)
+	defer cancel()
+
+	var user models.User
+	err := s.db.GetContext(ctx, &user, "SELECT id, username, password FROM users WHERE username=?", username)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, models.ErrUserNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &user, nil
+}
+
+func (s *Storage) UpdateUserPassword(ctx context.Context, userID int, passwordHash string) error {
+	ctx, cancel := s.withTimeout(ctx)
+	defer cancel()
+
+	res, err := s.db.ExecContext(ctx, "UPDATE users SET password=? WHERE id=?", passwordHash, userID)
+	if err != nil {
+		return err
+	}
+	return checkAffected(res, models.ErrUserNotFound)
+}
+
+func (s *Storage) CreateRefreshToken(ctx context.Context, token *models.RefreshToken) error {
+	ctx, cancel := s.withTimeout(ctx)
+	defer cancel()
+
+	return createRefreshToken(ctx, s.db, token)
+}
+
+func createRefreshToken(ctx context.Context, db sqlx.QueryerContext, token *models.RefreshToken) error {
+	return db.QueryRowxContext(ctx, "INSERT INTO refresh_tokens (user_id, token_hash, created_at, expires_at) VALUES (?, ?, ?, ?) RETURNING id, created_at",
+		token.UserID, token.TokenHash, utc(time.Now()), utc(token.ExpiresAt)).Scan(&token.ID, &token.CreatedAt)
+}
+
+// RotateRefreshToken revokes the refresh token with hash oldHash and stores
+// next in its place for the same user. Presenting an already revoked token
+// revokes every token of its owner, as the token has most likely leaked.
+func (s *Storage) RotateRefreshToken(ctx context.Context, oldHash string, next *models.RefreshToken) error {
+	ctx, cancel := s.withTimeout(ctx)
+	defer cancel()
+
+	tx, err := s.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	var old models.RefreshToken
+	err = tx.GetContext(ctx, &old, "SELECT * FROM refresh_tokens WHERE token_hash=?", oldHash)
+	if errors.Is(err, sql.ErrNoRows) {
+		return models.ErrRefreshTokenInvalid
+	}
+	if err != nil {
+		return err
+	}
+
+	if old.RevokedAt != nil {
+		if err := revokeAllTokens(ctx, tx, old.UserID); err != nil {
+			return err
+		}
+		if err := tx.Commit(); err != nil {
+			return err
+		}
+		return models.ErrRefreshTokenReused
+	}
+
+	if time.Now().After(old.ExpiresAt) {
+		return models.ErrRefreshTokenInvalid
+	}
+
+	if _, err := tx.ExecContext(ctx, "UPDATE refresh_tokens SET revoked_at=? WHERE id=?", utc(time.Now()), old.ID); err != nil {
+		return err
+	}
+
+	next.UserID = old.UserID
+	if err := createRefreshToken(ctx, tx, next); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+func (s *Storage) RevokeRefreshToken(ctx context.Context, userID int, tokenHash string) error {
+	ctx, cancel := s.withTimeout(ctx)
+	defer cancel()
+
+	_, err := s.db.ExecContext(ctx, "UPDATE refresh_tokens SET revoked_at=? WHERE user_id=? AND token_hash=? AND revoked_at IS NULL",
+		utc(time.Now()), userID, tokenHash)
+	return err
+}
+
+func (s *Storage) RevokeAllTokens(ctx context.Context, userID int) error {
+	ctx, cancel := s.withTimeout(ctx)
+	defer cancel()
+
+	return revokeAllTokens(ctx, s.db, userID)
+}
+
+func revokeAllTokens(ctx context.Context, db sqlx.ExecerContext, userID int) error {
+	now := utc(time.Now())
+
+	if _, err := db.ExecContext(ctx, "UPDATE refresh_tokens SET revoked_at=? WHERE user_id=? AND revoked_at IS NULL", now, userID); err != nil {
+		return err
+	}
+
+	_, err := db.ExecContext(ctx, "UPDATE users SET tokens_revoked_at=? WHERE id=?", now, userID)
+	return err
+}
+
+func (s *Storage) RevokeAccessToken(ctx context.Context, jti string, expiresAt time.Time) error {
+	ctx, cancel := s.withTimeout(ctx)
+	defer cancel()
+
+	// Entries are only useful until the token would have expired anyway.
+	if _, err := s.db.ExecContext(ctx, "DELETE FROM revoked_tokens WHERE expires_at < ?", utc(time.Now())); err != nil {
+		return err
+	}
+
+	_, err := s.db.ExecContext(ctx, "INSERT INTO revoked_tokens (jti, expires_at) VALUES (?, ?) ON CONFLICT (jti) DO NOTHING",
+		jti, utc(expiresAt))
+	return err
+}
+
+// IsAccessTokenRevoked reports whether the access token jti was revoked
+// individually, or was issued before its owner logged out everywhere. JWT
+// timestamps have second precision, so tokens issued in the second of the
+// logout are rejected too.
+func (s *Storage) IsAccessTokenRevoked(ctx context.Context, userID int, jti string, issuedAt time.Time) (bool, error) {
+	ctx, cancel := s.withTimeout(ctx)
+	defer cancel()
+
+	var revoked bool
+	if err := s.db.GetContext(ctx, &revoked, "SELECT EXISTS (SELECT 1 FROM revoked_tokens WHERE jti=?)", jti); err != nil || revoked {
+		return revoked, err
+	}
+
+	var revokedAt *time.Time
+	err := s.db.GetContext(ctx, &revokedAt, "SELECT tokens_revoked_at FROM users WHERE id=?", userID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+
+	return revokedAt != nil && !revokedAt.Truncate(time.Second).Before(issuedAt), nil
+}
+
+func (s *Storage) GetTasks(ctx context.Context, userID int, query *models.TaskQuery) (*models.TaskPage, error) {
+	ctx, cancel := s.withTimeout(ctx)
+	defer cancel()
+
+	if query.SortBy == "" {
+		query.SortBy = models.SortByID
+	}
+	sortColumn, ok := taskSortColumns[query.SortBy]
+	if !ok {
+		return nil, fmt.Errorf("unknown sort field %q", query.SortBy)
+	}
+
+	var q queryBuilder
+	q.where("user_id = ?", userID)
+	if query.ScheduledFrom != nil {
+		q.where("scheduled_for >= ?", utc(*query.ScheduledFrom))
+	}
+	if query.ScheduledTo != nil {
+		q.where("scheduled_for < ?", utc(*query.ScheduledTo))
+	}
+	if query.CreatedFrom != nil {
+		q.where("created_at >= ?", utc(*query.CreatedFrom))
+	}
+	if query.CreatedTo != nil {
+		q.where("created_at < ?", utc(*query.CreatedTo))
+	}
+	if query.Title != "" {
+		// LIKE is case-insensitive for ASCII in SQLite.
+		q.where(`title LIKE ? ESCAPE '\'`, "%"+escapeLike(query.Title)+"%")
+	}
+	if len(query.Statuses) > 0 {
+		statuses := make([]interface{}, len(query.Statuses))
+		for i, status := range query.Statuses {
+			statuses[i] = string(status)
+		}
+		q.where("status IN ("+placeholders(len(statuses))+")", statuses...)
+	}
+	if len(query.Tags) > 0 {
+		names := make([]interface{}, len(query.Tags))
+		for i, name := range query.Tags {
+			names[i] = name
+		}
+		tagged := "SELECT tt.task_id FROM task_tags tt JOIN tags t ON t.id = tt.tag_id WHERE t.name IN (" + placeholders(len(names)) + ")"
+		if query.TagMode != models.TagModeAny {
+			tagged += " GROUP BY tt.task_id HAVING count(*) = ?"
+			names = append(names, len(query.Tags))
+		}
+		q.where("id IN ("+tagged+")", names...)
+	}
+
+	page := &models.TaskPage{Tasks: []models.Task{}}
+	if err := s.db.GetContext(ctx, &page.Total, "SELECT count(*) FROM tasks"+q.whereClause(), q.args...); err != nil {
+		return nil, err
+	}
+
+	direction, cmp := "ASC", ">"
+	if query.Descending {
+		direction, cmp = "DESC", "<"
+	}
+
+	if query.Cursor != "" {
+		cursor, err := models.DecodeTaskCursor(query, query.Cursor)
+		if err != nil {
+			return nil, err
+		}
+
+		switch query.SortBy {
+		case models.SortByID:
+			q.where(fmt.Sprintf("id %s ?", cmp), cursor.ID)
+		case models.SortByPriority:
+			priority, due, err := cursor.Priority()
+			if err != nil {
+				return nil, err
+			}
+			q.where(fmt.Sprintf("(priority, %s, id) %s (?, ?, ?)", dueSortKey(query.Descending), cmp), priority, utc(due), cursor.ID)
+		default:
+			var value interface{} = cursor.Value
+			if query.SortBy != models.SortByTitle {
+				t, err := cursor.Time()
+				if err != nil {
+					return nil, err
+				}
+				value = utc(t)
+			}
+			q.where(fmt.Sprintf("(%s, id) %s (?, ?)", sortColumn, cmp), value, cursor.ID)
+		}
+	}
+
+	order := fmt.Sprintf("%s %s, id %s", sortColumn, direction, direction)
+	if query.SortBy == models.SortByPriority {
+		order = fmt.Sprintf("priority %s, %s %s, id %s", direction, dueSortKey(query.Descending), direction, direction)
+	}
+
+	stmt := "SELECT " + taskColumns + " FROM tasks" + q.whereClause() + " ORDER BY " + order
+	args := q.args
+	if query.Limit > 0 {
+		// One extra row tells whether there is a next page.
+		stmt += " LIMIT ?"
+		args = append(args, query.Limit+1)
+	}
+
+	if err := s.db.SelectContext(ctx, &page.Tasks, stmt, args...); err != nil {
+		return nil, err
+	}
+
+	if query.Limit > 0 && len(page.Tasks) > query.Limit {
+		page.Tasks = page.Tasks[:query.Limit]
+		page.NextCursor = models.NewTaskCursor(query, &page.Tasks[query.Limit-1]).Encode()
+	}
+
+	tasks := make([]*models.Task, len(page.Tasks))
+	for i := range page.Tasks {
+		tasks[i] = &page.Tasks[i]
+	}
+	if err := loadDetails(ctx, s.db, tasks...); err != nil {
+		return nil, err
+	}
+
+	return page, nil
+}
+
+func (s *Storage) CreateTask(ctx context.Context, userID int, task *models.Task) error {
+	ctx, cancel := s.withTimeout(ctx)
+	defer cancel()
+
+	if task.Status == "" {
+		task.Status = models.StatusTodo
+	}
+	task.Tags = []models.Tag{}
+	task.Checklist = []models.ChecklistItem{}
+	task.Progress = nil
+	task.SeriesID, task.OccurrenceAt = nil, nil
+	task.UserID = userID
+
+	if task.ParentID != nil {
+		if err := checkParent(ctx, s.db, userID, 0, *task.ParentID); err != nil {
+			return err
+		}
+	}
+
+	if task.RRule == "" {
+		return insertTask(ctx, s.db, task)
+	}
+
+	tx, err := s.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	// The task is the first occurrence of its series.
+	series := models.NewTaskSeries(task, task.RRule)
+	if err := insertSeries(ctx, tx, series); err != nil {
+		return err
+	}
+	task.SeriesID, task.OccurrenceAt = &series.ID, &series.DTStart
+	if err := insertTask(ctx, tx, task); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// insertTask stores a new task and fills in its ID, creation time and
+// version.
+func insertTask(ctx context.Context, db sqlx.QueryerContext, task *models.Task) error {
+	return db.QueryRowxContext(ctx, "INSERT INTO tasks (title, description, created_at, scheduled_for, due_at, priority, status, parent_id, series_id, occurrence_at, user_id) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?) RETURNING id, created_at, version",
+		task.Title, task.Description, utc(time.Now()), utc(task.ScheduledFor), utcPtr(task.DueAt), task.Priority, task.Status, task.ParentID, task.SeriesID, utcPtr(task.OccurrenceAt), task.UserID).Scan(&task.ID, &task.CreatedAt, &task.Version)
+}
+
+func (s *Storage) GetTaskByID(ctx context.Context, userID, taskID int) (*models.Task, error) {
+	ctx, cancel := s.withTimeout(ctx)
+	defer cancel()
+
+	var task models.Task
+	err := s.db.GetContext(ctx, &task, "SELECT "+taskColumns+" FROM tasks WHERE id=? AND user_id=?", taskID, userID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, models.ErrTaskNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err := loadDetails(ctx, s.db, &task); err != nil {
+		return nil, err
+	}
+	return &task, nil
+}
+
+// UpdateTask writes the task and stores its new version in task.Version.
+// A non-zero task.Version makes the update conditional on the stored
+// version still being the same.
+func (s *Storage) UpdateTask(ctx context.Context, userID int, task *models.Task) error {
+	ctx, cancel := s.withTimeout(ctx)
+	defer cancel()
+
+	if task.ParentID != nil {
+		if err := checkParent(ctx, s.db, userID, task.ID, *task.ParentID); err != nil {
+			return err
+		}
+	}
+
+	stmt := "UPDATE tasks SET title=?, description=?, scheduled_for=?, due_at=?, priority=?, status=?, parent_id=?, version=version+1 WHERE id=? AND user_id=?"
+	args := []interface{}{task.Title, task.Description, utc(task.ScheduledFor), utcPtr(task.DueAt), task.Priority, task.Status, task.ParentID, task.ID, userID}
+	if task.Version > 0 {
+		stmt += " AND version=?"
+		args = append(args, task.Version)
+	}
+
+	err := s.db.QueryRowContext(ctx, stmt+" RETURNING version", args...).Scan(&task.Version)
+	if errors.Is(err, sql.ErrNoRows) {
+		return s.missingTask(ctx, userID, task.ID, task.Version)
+	}
+	return err
+}
+
+// PatchTask writes the fields set in patch and returns the updated task.
+// A non-zero version makes the update conditional on the stored version.
+func (s *Storage) PatchTask(ctx context.Context, userID, taskID int, patch *models.TaskPatch, version int) (*models.Task, error) {
+	ctx, cancel := s.withTimeout(ctx)
+	defer cancel()
+
+	set := []string{"version=version+1"}
+	var args []interface{}
+	if patch.Title != nil {
+		set = append(set, "title=?")
+		args = append(args, *patch.Title)
+	}
+	if patch.Description != nil {
+		set = append(set, "description=?")
+		args = append(args, *patch.Description)
+	}
+	if patch.ScheduledFor != nil {
+		set = append(set, "scheduled_for=?")
+		args = append(args, utc(*patch.ScheduledFor))
+	}
+	if patch.DueAt != nil {
+		set = append(set, "due_at=?")
+		args = append(args, utc(*patch.DueAt))
+	} else if patch.ClearDueAt {
+		set = append(set, "due_at=NULL")
+	}
+	if patch.Priority != nil {
+		set = append(set, "priority=?")
+		args = append(args, *patch.Priority)
+	}
+	if patch.ParentID != nil {
+		if err := checkParent(ctx, s.db, userID, taskID, *patch.ParentID); err != nil {
+			return nil, err
+		}
+		set = append(set, "parent_id=?")
+		args = append(args, *patch.ParentID)
+	} else if patch.ClearParent {
+		set = append(set, "parent_id=NULL")
+	}
+	if patch.Status != nil {
+		set = append(set, "status=?")
+		args = append(args, *patch.Status)
+	}
+
+	q := queryBuilder{args: args}
+	q.where("id = ?", taskID)
+	q.where("user_id = ?", userID)
+	if version > 0 {
+		q.where("version = ?", version)
+	}
+
+	var task models.Task
+	err := s.db.GetContext(ctx, &task, "UPDATE tasks SET "+strings.Join(set, ", ")+q.whereClause()+" RETURNING "+taskColumns, q.args...)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, s.missingTask(ctx, userID, taskID, version)
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err := loadDetails(ctx, s.db, &task); err != nil {
+		return nil, err
+	}
+	return &task, nil
+}
+
+// UpdateTaskStatus moves the task to status. A non-zero version makes the
+// update conditional on the stored version of the task.
+func (s *Storage) UpdateTaskStatus(ctx context.Context, userID, taskID int, status models.TaskStatus, version int) error {
+	ctx, cancel := s.withTimeout(ctx)
+	defer cancel()
+
+	stmt := "UPDATE tasks SET status=?, version=version+1 WHERE id=? AND user_id=?"
+	args := []interface{}{status, taskID, userID}
+	if version > 0 {
+		stmt += " AND version=?"
+		args = append(args, version)
+	}
+
+	res, err := s.db.ExecContext(ctx, stmt, args...)
+	if err != nil {
+		return err
+	}
+	if err := checkAffected(res, models.ErrTaskNotFound); err != nil {
+		if errors.Is(err, models.ErrTaskNotFound) {
+			return s.missingTask(ctx, userID, taskID, version)
+		}
+		return err
+	}
+	return nil
+}
+
+// DeleteTask removes the task together with its subtasks and returns the
+// tasks removed. A non-zero version makes the delete conditional on the
+// stored version of the task.
+func (s *Storage) DeleteTask(ctx context.Context, userID, taskID, version int) ([]models.Task, error) {
+	ctx, cancel := s.withTimeout(ctx)
+	defer cancel()
+
+	root := "SELECT id FROM tasks WHERE id=? AND user_id=?"
+	args := []interface{}{taskID, userID}
+	if version > 0 {
+		root += " AND version=?"
+		args = append(args, version)
+	}
+
+	tx, err := s.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	// SQLite cascades row by row, so the subtasks would be gone before a
+	// DELETE ... RETURNING reached them. They are read first instead.
+	var deleted []models.Task
+	err = tx.SelectContext(ctx, &deleted, `WITH RECURSIVE subtree AS (
+			`+root+`
+			UNION ALL
+			SELECT tasks.id FROM tasks JOIN subtree ON tasks.parent_id = subtree.id
+		)
+		SELECT `+taskColumns+` FROM tasks WHERE id IN (SELECT id FROM subtree)`, args...)
+	if err != nil {
+		return nil, err
+	}
+	if len(deleted) == 0 {
+		return nil, s.missingTask(ctx, userID, taskID, version)
+	}
+
+	// The subtasks go with the task by ON DELETE CASCADE.
+	if _, err := tx.ExecContext(ctx, "DELETE FROM tasks WHERE id=?", taskID); err != nil {
+		return nil, err
+	}
+	return deleted, tx.Commit()
+}
+
+// missingTask explains why a conditional write touched no row.
+func (s *Storage) missingTask(ctx context.Context, userID, taskID, version int) error {
+	if version == 0 {
+		return models.ErrTaskNotFound
+	}
+
+	var exists bool
+	if err := s.db.GetContext(ctx, &exists, "SELECT EXISTS (SELECT 1 FROM tasks WHERE id=? AND user_id=?)", taskID, userID); err != nil {
+		return err
+	}
+	if exists {
+		return models.ErrVersionConflict
+	}
+	return models.ErrTaskNotFound
+}
+
+// checkAffected returns notFound if the statement did not touch any row.
+func checkAffected(res sql.Result, notFound error) error {
+	n, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if n == 0 {
+		return notFound
+	}
+	return nil
+}
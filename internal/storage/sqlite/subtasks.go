@@ -0,0 +1,267 @@
+package sqlite
+
+import (
+	"TaskManager/internal/models"
+	"context"
+	"database/sql"
+	"errors"
+
+	"github.com/jmoiron/sqlx"
+)
+
+// GetSubtasks returns the direct subtasks of a task, oldest first.
+func (s *Storage) GetSubtasks(ctx context.Context, userID, taskID int) ([]models.Task, error) {
+	ctx, cancel := s.withTimeout(ctx)
+	defer cancel()
+
+	var exists bool
+	if err := s.db.GetContext(ctx, &exists, "SELECT EXISTS (SELECT 1 FROM tasks WHERE id=? AND user_id=?)", taskID, userID); err != nil {
+		return nil, err
+	}
+	if !exists {
+		return nil, models.ErrTaskNotFound
+	}
+
+	subtasks := []models.Task{}
+	if err := s.db.SelectContext(ctx, &subtasks, "SELECT "+taskColumns+" FROM tasks WHERE parent_id=? AND user_id=? ORDER BY id", taskID, userID); err != nil {
+		return nil, err
+	}
+
+	tasks := make([]*models.Task, len(subtasks))
+	for i := range subtasks {
+		tasks[i] = &subtasks[i]
+	}
+	if err := loadDetails(ctx, s.db, tasks...); err != nil {
+		return nil, err
+	}
+
+	return subtasks, nil
+}
+
+// AddChecklistItem appends an item to the checklist of a task.
+func (s *Storage) AddChecklistItem(ctx context.Context, userID, taskID int, item *models.ChecklistItem) error {
+	ctx, cancel := s.withTimeout(ctx)
+	defer cancel()
+
+	tx, err := s.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if err := lockTask(ctx, tx, userID, taskID); err != nil {
+		return err
+	}
+
+	item.TaskID = taskID
+	err = tx.QueryRowContext(ctx, `INSERT INTO checklist_items (task_id, text, position)
+		SELECT ?, ?, COALESCE(max(position)+1, 0) FROM checklist_items WHERE task_id=?
+		RETURNING id, done, position`, taskID, item.Text, taskID).Scan(&item.ID, &item.Done, &item.Position)
+	if err != nil {
+		return err
+	}
+
+	if _, err := tx.ExecContext(ctx, "UPDATE tasks SET version=version+1 WHERE id=?", taskID); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// UpdateChecklistItem writes the fields set in patch and returns the
+// updated item.
+func (s *Storage) UpdateChecklistItem(ctx context.Context, userID, taskID, itemID int, patch *models.ChecklistItemPatch) (*models.ChecklistItem, error) {
+	ctx, cancel := s.withTimeout(ctx)
+	defer cancel()
+
+	tx, err := s.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	if err := lockTask(ctx, tx, userID, taskID); err != nil {
+		return nil, err
+	}
+
+	var item models.ChecklistItem
+	err = tx.GetContext(ctx, &item, `UPDATE checklist_items SET text=COALESCE(?, text), done=COALESCE(?, done)
+		WHERE id=? AND task_id=? RETURNING id, task_id, text, done, position`,
+		patch.Text, patch.Done, itemID, taskID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, models.ErrChecklistItemNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if _, err := tx.ExecContext(ctx, "UPDATE tasks SET version=version+1 WHERE id=?", taskID); err != nil {
+		return nil, err
+	}
+
+	return &item, tx.Commit()
+}
+
+func (s *Storage) DeleteChecklistItem(ctx context.Context, userID, taskID, itemID int) error {
+	ctx, cancel := s.withTimeout(ctx)
+	defer cancel()
+
+	tx, err := s.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if err := lockTask(ctx, tx, userID, taskID); err != nil {
+		return err
+	}
+
+	res, err := tx.ExecContext(ctx, "DELETE FROM checklist_items WHERE id=? AND task_id=?", itemID, taskID)
+	if err != nil {
+		return err
+	}
+	if err := checkAffected(res, models.ErrChecklistItemNotFound); err != nil {
+		return err
+	}
+
+	if _, err := tx.ExecContext(ctx, "UPDATE tasks SET version=version+1 WHERE id=?", taskID); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// ReorderChecklist puts the checklist items of a task in the order of
+// itemIDs, which must list every item exactly once.
+func (s *Storage) ReorderChecklist(ctx context.Context, userID, taskID int, itemIDs []int) error {
+	ctx, cancel := s.withTimeout(ctx)
+	defer cancel()
+
+	tx, err := s.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if err := lockTask(ctx, tx, userID, taskID); err != nil {
+		return err
+	}
+
+	var ids []int
+	if err := tx.SelectContext(ctx, &ids, "SELECT id FROM checklist_items WHERE task_id=?", taskID); err != nil {
+		return err
+	}
+	if err := models.CheckChecklistOrder(ids, itemIDs); err != nil {
+		return err
+	}
+
+	for position, id := range itemIDs {
+		if _, err := tx.ExecContext(ctx, "UPDATE checklist_items SET position=? WHERE id=?", position, id); err != nil {
+			return err
+		}
+	}
+
+	if _, err := tx.ExecContext(ctx, "UPDATE tasks SET version=version+1 WHERE id=?", taskID); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// checkParent validates nesting task taskID under parentID. A zero taskID
+// stands for a task that is being created and has no subtasks yet.
+func checkParent(ctx context.Context, db sqlx.QueryerContext, userID, taskID, parentID int) error {
+	// UNION rather than UNION ALL stops at a cycle left by concurrent moves.
+	var ancestors []int
+	err := sqlx.SelectContext(ctx, db, &ancestors, `WITH RECURSIVE chain(id, parent_id) AS (
+			SELECT id, parent_id FROM tasks WHERE id=? AND user_id=?
+			UNION
+			SELECT t.id, t.parent_id FROM tasks t JOIN chain c ON t.id = c.parent_id
+		) SELECT id FROM chain`, parentID, userID)
+	if err != nil {
+		return err
+	}
+	if len(ancestors) == 0 {
+		return models.ErrParentNotFound
+	}
+
+	height := 1
+	if taskID != 0 {
+		err := sqlx.GetContext(ctx, db, &height, `WITH RECURSIVE subtree(id, depth) AS (
+				SELECT id, 1 FROM tasks WHERE id=?
+				UNION ALL
+				SELECT t.id, s.depth+1 FROM tasks t JOIN subtree s ON t.parent_id = s.id WHERE s.depth <= ?
+			) SELECT COALESCE(max(depth), 1) FROM subtree`, taskID, models.MaxTaskDepth)
+		if err != nil {
+			return err
+		}
+	}
+
+	return models.CheckParent(taskID, ancestors, height)
+}
+
+// lockTask checks that the task belongs to the user. SQLite transactions
+// are serialised on the single connection, so no row lock is needed.
+func lockTask(ctx context.Context, tx *sqlx.Tx, userID, taskID int) error {
+	var id int
+	err := tx.GetContext(ctx, &id, "SELECT id FROM tasks WHERE id=? AND user_id=?", taskID, userID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return models.ErrTaskNotFound
+	}
+	return err
+}
+
+// loadDetails fills in the tags, checklist, progress, dependencies and
+// recurrence rule of the given tasks.
+func loadDetails(ctx context.Context, db sqlx.QueryerContext, tasks ...*models.Task) error {
+	if len(tasks) == 0 {
+		return nil
+	}
+
+	if err := loadTags(ctx, db, tasks...); err != nil {
+		return err
+	}
+
+	byID := make(map[int]*models.Task, len(tasks))
+	ids := make([]interface{}, len(tasks))
+	for i, task := range tasks {
+		task.Checklist = []models.ChecklistItem{}
+		task.BlockedBy = []int{}
+		task.Blocked = false
+		byID[task.ID] = task
+		ids[i] = task.ID
+	}
+
+	var items []models.ChecklistItem
+	err := sqlx.SelectContext(ctx, db, &items, `SELECT id, task_id, text, done, position FROM checklist_items
+		WHERE task_id IN (`+placeholders(len(ids))+`) ORDER BY position, id`, ids...)
+	if err != nil {
+		return err
+	}
+	for _, item := range items {
+		task := byID[item.TaskID]
+		task.Checklist = append(task.Checklist, item)
+	}
+
+	var counts []struct {
+		ParentID int `db:"parent_id"`
+		Total    int `db:"total"`
+		Done     int `db:"done"`
+	}
+	err = sqlx.SelectContext(ctx, db, &counts, `SELECT parent_id,
+			count(*) FILTER (WHERE status <> 'cancelled') AS total,
+			count(*) FILTER (WHERE status = 'done') AS done
+		FROM tasks WHERE parent_id IN (`+placeholders(len(ids))+`) GROUP BY parent_id`, ids...)
+	if err != nil {
+		return err
+	}
+	for _, c := range counts {
+		byID[c.ParentID].Progress = models.Progress(c.Total, c.Done)
+	}
+
+	if err := loadDependencies(ctx, db, byID, ids); err != nil {
+		return err
+	}
+
+	return loadRRules(ctx, db, tasks...)
+}
@@ -0,0 +1,218 @@
+package sqlite
+
+import (
+	"TaskManager/internal/models"
+	"context"
+	"database/sql"
+	"errors"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/mattn/go-sqlite3"
+)
+
+func (s *Storage) GetTags(ctx context.Context, userID int) ([]models.Tag, error) {
+	ctx, cancel := s.withTimeout(ctx)
+	defer cancel()
+
+	tags := []models.Tag{}
+	err := s.db.SelectContext(ctx, &tags, "SELECT id, name, user_id FROM tags WHERE user_id=? ORDER BY name", userID)
+	return tags, err
+}
+
+func (s *Storage) CreateTag(ctx context.Context, userID int, tag *models.Tag) error {
+	ctx, cancel := s.withTimeout(ctx)
+	defer cancel()
+
+	tag.UserID = userID
+
+	err := s.db.QueryRowContext(ctx, "INSERT INTO tags (user_id, name) VALUES (?, ?) RETURNING id", userID, tag.Name).Scan(&tag.ID)
+	if isUniqueViolation(err) {
+		return models.ErrTagExists
+	}
+	return err
+}
+
+// RenameTag changes the name of a tag. Tasks carrying the tag get a new
+// version, as their representation changes with it.
+func (s *Storage) RenameTag(ctx context.Context, userID, tagID int, name string) error {
+	ctx, cancel := s.withTimeout(ctx)
+	defer cancel()
+
+	tx, err := s.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	res, err := tx.ExecContext(ctx, "UPDATE tags SET name=? WHERE id=? AND user_id=?", name, tagID, userID)
+	if isUniqueViolation(err) {
+		return models.ErrTagExists
+	}
+	if err != nil {
+		return err
+	}
+	if err := checkAffected(res, models.ErrTagNotFound); err != nil {
+		return err
+	}
+
+	if err := touchTaggedTasks(ctx, tx, tagID); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// DeleteTag removes a tag and detaches it from every task.
+func (s *Storage) DeleteTag(ctx context.Context, userID, tagID int) error {
+	ctx, cancel := s.withTimeout(ctx)
+	defer cancel()
+
+	tx, err := s.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if err := touchTaggedTasks(ctx, tx, tagID); err != nil {
+		return err
+	}
+
+	res, err := tx.ExecContext(ctx, "DELETE FROM tags WHERE id=? AND user_id=?", tagID, userID)
+	if err != nil {
+		return err
+	}
+	if err := checkAffected(res, models.ErrTagNotFound); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// AttachTag attaches a tag to a task, both owned by the user. Attaching a
+// tag twice is not an error.
+func (s *Storage) AttachTag(ctx context.Context, userID, taskID, tagID int) error {
+	ctx, cancel := s.withTimeout(ctx)
+	defer cancel()
+
+	tx, err := s.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if err := checkTaskAndTag(ctx, tx, userID, taskID, tagID); err != nil {
+		return err
+	}
+
+	res, err := tx.ExecContext(ctx, "INSERT INTO task_tags (task_id, tag_id) VALUES (?, ?) ON CONFLICT DO NOTHING", taskID, tagID)
+	if err != nil {
+		return err
+	}
+	if err := touchTask(ctx, tx, res, taskID); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// DetachTag removes a tag from a task. Detaching a tag the task does not
+// carry is not an error.
+func (s *Storage) DetachTag(ctx context.Context, userID, taskID, tagID int) error {
+	ctx, cancel := s.withTimeout(ctx)
+	defer cancel()
+
+	tx, err := s.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if err := checkTaskAndTag(ctx, tx, userID, taskID, tagID); err != nil {
+		return err
+	}
+
+	res, err := tx.ExecContext(ctx, "DELETE FROM task_tags WHERE task_id=? AND tag_id=?", taskID, tagID)
+	if err != nil {
+		return err
+	}
+	if err := touchTask(ctx, tx, res, taskID); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// checkTaskAndTag makes sure both the task and the tag belong to the user.
+func checkTaskAndTag(ctx context.Context, tx *sqlx.Tx, userID, taskID, tagID int) error {
+	var task, tag bool
+	err := tx.QueryRowContext(ctx, `SELECT
+		EXISTS (SELECT 1 FROM tasks WHERE id=? AND user_id=?),
+		EXISTS (SELECT 1 FROM tags WHERE id=? AND user_id=?)`,
+		taskID, userID, tagID, userID).Scan(&task, &tag)
+	if err != nil {
+		return err
+	}
+
+	if !task {
+		return models.ErrTaskNotFound
+	}
+	if !tag {
+		return models.ErrTagNotFound
+	}
+	return nil
+}
+
+// touchTask bumps the version of a task if res changed any of its tags.
+func touchTask(ctx context.Context, tx *sqlx.Tx, res sql.Result, taskID int) error {
+	n, err := res.RowsAffected()
+	if err != nil || n == 0 {
+		return err
+	}
+
+	_, err = tx.ExecContext(ctx, "UPDATE tasks SET version=version+1 WHERE id=?", taskID)
+	return err
+}
+
+// touchTaggedTasks bumps the version of every task carrying the tag.
+func touchTaggedTasks(ctx context.Context, tx *sqlx.Tx, tagID int) error {
+	_, err := tx.ExecContext(ctx, "UPDATE tasks SET version=version+1 WHERE id IN (SELECT task_id FROM task_tags WHERE tag_id=?)", tagID)
+	return err
+}
+
+// loadTags fills in the tags of the given tasks, ordered by name.
+func loadTags(ctx context.Context, db sqlx.QueryerContext, tasks ...*models.Task) error {
+	if len(tasks) == 0 {
+		return nil
+	}
+
+	byID := make(map[int]*models.Task, len(tasks))
+	ids := make([]interface{}, len(tasks))
+	for i, task := range tasks {
+		task.Tags = []models.Tag{}
+		byID[task.ID] = task
+		ids[i] = task.ID
+	}
+
+	var rows []struct {
+		TaskID int `db:"task_id"`
+		models.Tag
+	}
+	err := sqlx.SelectContext(ctx, db, &rows, `SELECT tt.task_id, t.id, t.name, t.user_id
+		FROM task_tags tt JOIN tags t ON t.id = tt.tag_id
+		WHERE tt.task_id IN (`+placeholders(len(ids))+`) ORDER BY t.name`, ids...)
+	if err != nil {
+		return err
+	}
+
+	for _, row := range rows {
+		task := byID[row.TaskID]
+		task.Tags = append(task.Tags, row.Tag)
+	}
+	return nil
+}
+
+// isUniqueViolation reports whether err is a unique constraint violation.
+func isUniqueViolation(err error) bool {
+	var sqliteErr sqlite3.Error
+	return errors.As(err, &sqliteErr) && sqliteErr.ExtendedCode == sqlite3.ErrConstraintUnique
+}
@@ -0,0 +1,154 @@
+package sqlite
+
+import (
+	"TaskManager/internal/models"
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+)
+
+const webhookColumns = "id, user_id, url, secret, events, created_at"
+
+func (s *Storage) GetWebhooks(ctx context.Context, userID int) ([]models.Webhook, error) {
+	ctx, cancel := s.withTimeout(ctx)
+	defer cancel()
+
+	webhooks := []models.Webhook{}
+	err := s.db.SelectContext(ctx, &webhooks, "SELECT "+webhookColumns+" FROM webhooks WHERE user_id=? ORDER BY id", userID)
+	return webhooks, err
+}
+
+func (s *Storage) CreateWebhook(ctx context.Context, userID int, webhook *models.Webhook) error {
+	ctx, cancel := s.withTimeout(ctx)
+	defer cancel()
+
+	webhook.UserID = userID
+
+	return s.db.QueryRowContext(ctx, "INSERT INTO webhooks (user_id, url, secret, events, created_at) VALUES (?, ?, ?, ?, ?) RETURNING id, created_at",
+		userID, webhook.URL, webhook.Secret, webhook.Events, utc(time.Now())).Scan(&webhook.ID, &webhook.CreatedAt)
+}
+
+// UpdateWebhook changes the URL and events of a webhook, and its secret
+// unless webhook.Secret is empty. The stored webhook is read back into
+// webhook.
+func (s *Storage) UpdateWebhook(ctx context.Context, userID int, webhook *models.Webhook) error {
+	ctx, cancel := s.withTimeout(ctx)
+	defer cancel()
+
+	err := s.db.QueryRowxContext(ctx, `UPDATE webhooks SET url=?, events=?, secret=COALESCE(NULLIF(?, ''), secret)
+		WHERE id=? AND user_id=? RETURNING `+webhookColumns,
+		webhook.URL, webhook.Events, webhook.Secret, webhook.ID, userID).StructScan(webhook)
+	if errors.Is(err, sql.ErrNoRows) {
+		return models.ErrWebhookNotFound
+	}
+	return err
+}
+
+// DeleteWebhook removes a webhook together with its pending deliveries.
+func (s *Storage) DeleteWebhook(ctx context.Context, userID, webhookID int) error {
+	ctx, cancel := s.withTimeout(ctx)
+	defer cancel()
+
+	res, err := s.db.ExecContext(ctx, "DELETE FROM webhooks WHERE id=? AND user_id=?", webhookID, userID)
+	if err != nil {
+		return err
+	}
+	return checkAffected(res, models.ErrWebhookNotFound)
+}
+
+// EnqueueEvent adds an event to the outbox of every webhook of the user
+// that subscribes to it.
+func (s *Storage) EnqueueEvent(ctx context.Context, userID int, event models.EventType, payload []byte) error {
+	ctx, cancel := s.withTimeout(ctx)
+	defer cancel()
+
+	webhooks, err := s.GetWebhooks(ctx, userID)
+	if err != nil {
+		return err
+	}
+
+	tx, err := s.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	now := utc(time.Now())
+	for _, webhook := range webhooks {
+		if !webhook.Events.Matches(event) {
+			continue
+		}
+
+		_, err := tx.ExecContext(ctx, "INSERT INTO webhook_deliveries (webhook_id, event, payload, next_attempt_at, created_at) VALUES (?, ?, ?, ?, ?)",
+			webhook.ID, event, string(payload), now, now)
+		if err != nil {
+			return err
+		}
+	}
+
+	return tx.Commit()
+}
+
+// ClaimDeliveries returns up to limit deliveries due at now and hides them
+// from other callers until leaseUntil, counting the attempt. SQLite
+// transactions are serialised on the single connection, so no row lock is
+// needed.
+func (s *Storage) ClaimDeliveries(ctx context.Context, now, leaseUntil time.Time, limit int) ([]models.WebhookDelivery, error) {
+	ctx, cancel := s.withTimeout(ctx)
+	defer cancel()
+
+	tx, err := s.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	deliveries := []models.WebhookDelivery{}
+	err = tx.SelectContext(ctx, &deliveries, `SELECT d.id, d.webhook_id, w.url, w.secret, d.event, d.payload, d.attempts+1 AS attempts
+		FROM webhook_deliveries d JOIN webhooks w ON w.id = d.webhook_id
+		WHERE d.next_attempt_at <= ? ORDER BY d.next_attempt_at, d.id LIMIT ?`,
+		utc(now), limit)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, delivery := range deliveries {
+		_, err := tx.ExecContext(ctx, "UPDATE webhook_deliveries SET attempts=?, next_attempt_at=? WHERE id=?",
+			delivery.Attempts, utc(leaseUntil), delivery.ID)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return deliveries, tx.Commit()
+}
+
+// CompleteDelivery marks a delivery as delivered.
+func (s *Storage) CompleteDelivery(ctx context.Context, deliveryID int, at time.Time) error {
+	ctx, cancel := s.withTimeout(ctx)
+	defer cancel()
+
+	_, err := s.db.ExecContext(ctx, "UPDATE webhook_deliveries SET next_attempt_at=NULL, delivered_at=?, last_error='' WHERE id=?", utc(at), deliveryID)
+	return err
+}
+
+// RetryDelivery records a failed attempt and schedules the next one, or
+// gives up on the delivery if next is nil.
+func (s *Storage) RetryDelivery(ctx context.Context, deliveryID int, next *time.Time, lastError string) error {
+	ctx, cancel := s.withTimeout(ctx)
+	defer cancel()
+
+	_, err := s.db.ExecContext(ctx, "UPDATE webhook_deliveries SET next_attempt_at=?, last_error=? WHERE id=?", utcPtr(next), lastError, deliveryID)
+	return err
+}
+
+// DeleteDeliveries removes the deliveries created before the given time
+// that were delivered or given up on.
+func (s *Storage) DeleteDeliveries(ctx context.Context, before time.Time) error {
+	ctx, cancel := s.withTimeout(ctx)
+	defer cancel()
+
+	_, err := s.db.ExecContext(ctx, "DELETE FROM webhook_deliveries WHERE next_attempt_at IS NULL AND created_at < ?", utc(before))
+	return err
+}
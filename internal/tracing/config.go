@@ -0,0 +1,26 @@
+package tracing
+
+type Config struct {
+	Enabled bool `toml:"enabled"`
+	// Exporter is "otlp", sending spans to an OTLP/HTTP collector, or
+	// "stdout", printing them.
+	Exporter string `toml:"exporter"`
+	// Endpoint is the host:port of the OTLP/HTTP collector.
+	Endpoint string `toml:"endpoint"`
+	// Insecure sends spans to the collector over plain HTTP.
+	Insecure    bool   `toml:"insecure"`
+	ServiceName string `toml:"service_name"`
+	// SampleRatio is the share of new traces recorded. Requests carrying a
+	// traceparent follow the sampling decision of their caller.
+	SampleRatio float64 `toml:"sample_ratio"`
+}
+
+func NewConfig() *Config {
+	return &Config{
+		Exporter:    "otlp",
+		Endpoint:    "localhost:4318",
+		Insecure:    true,
+		ServiceName: "taskmanager",
+		SampleRatio: 1,
+	}
+}
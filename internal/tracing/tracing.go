@@ -0,0 +1,133 @@
+// Package tracing records OpenTelemetry traces of the HTTP requests and
+// propagates W3C trace context across services.
+package tracing
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+
+	"github.com/gin-gonic/gin"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	"go.opentelemetry.io/otel/exporters/stdout/stdouttrace"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	semconv "go.opentelemetry.io/otel/semconv/v1.26.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// instrumentationName identifies the spans of this service's own
+// instrumentation.
+const instrumentationName = "TaskManager"
+
+// Setup installs the global tracer provider exporting the spans, and the
+// W3C trace context and baggage propagators. The returned function
+// flushes the pending spans and must be called on shutdown.
+func Setup(ctx context.Context, config *Config) (func(context.Context) error, error) {
+	if config == nil {
+		return nil, errors.New("Config is nil")
+	}
+
+	exporter, err := newExporter(ctx, config)
+	if err != nil {
+		return nil, err
+	}
+
+	res, err := resource.Merge(resource.Default(), resource.NewSchemaless(semconv.ServiceName(config.ServiceName)))
+	if err != nil {
+		return nil, err
+	}
+
+	provider := sdktrace.NewTracerProvider(
+		sdktrace.WithBatcher(exporter),
+		sdktrace.WithResource(res),
+		sdktrace.WithSampler(sdktrace.ParentBased(sdktrace.TraceIDRatioBased(config.SampleRatio))),
+	)
+	otel.SetTracerProvider(provider)
+	otel.SetTextMapPropagator(propagation.NewCompositeTextMapPropagator(propagation.TraceContext{}, propagation.Baggage{}))
+
+	return provider.Shutdown, nil
+}
+
+func newExporter(ctx context.Context, config *Config) (sdktrace.SpanExporter, error) {
+	switch config.Exporter {
+	case "otlp":
+		options := []otlptracehttp.Option{otlptracehttp.WithEndpoint(config.Endpoint)}
+		if config.Insecure {
+			options = append(options, otlptracehttp.WithInsecure())
+		}
+		return otlptracehttp.New(ctx, options...)
+	case "stdout":
+		return stdouttrace.New(stdouttrace.WithWriter(os.Stdout))
+	default:
+		return nil, fmt.Errorf("unknown trace exporter %q", config.Exporter)
+	}
+}
+
+// Tracer returns the tracer of the global provider, which does nothing
+// until Setup is called.
+func Tracer() trace.Tracer {
+	return otel.Tracer(instrumentationName)
+}
+
+// End ends a span, marking it failed if err is not nil.
+func End(span trace.Span, err error) {
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+	span.End()
+}
+
+// Middleware traces every request, continuing the trace of the caller if
+// the request carries a traceparent header. The span is stored in the
+// request context and its traceparent is returned in the response
+// headers.
+func Middleware() gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		propagator := otel.GetTextMapPropagator()
+		parent := propagator.Extract(ctx.Request.Context(), propagation.HeaderCarrier(ctx.Request.Header))
+
+		route := ctx.FullPath()
+		if route == "" {
+			route = "unmatched"
+		}
+		method := ctx.Request.Method
+
+		spanCtx, span := Tracer().Start(parent, method+" "+route,
+			trace.WithSpanKind(trace.SpanKindServer),
+			trace.WithAttributes(
+				semconv.HTTPRequestMethodKey.String(method),
+				semconv.HTTPRoute(route),
+				semconv.URLPath(ctx.Request.URL.Path),
+			),
+		)
+
+		// The span is finished in a deferred call, so that a panic on its
+		// way to gin.Recovery marks it with the 500 it turns into.
+		panicked := true
+		defer func() {
+			status := ctx.Writer.Status()
+			if panicked {
+				status = http.StatusInternalServerError
+			}
+
+			span.SetAttributes(semconv.HTTPResponseStatusCode(status))
+			if status >= 500 {
+				span.SetStatus(codes.Error, "")
+			}
+			span.End()
+		}()
+
+		ctx.Request = ctx.Request.WithContext(spanCtx)
+		propagator.Inject(spanCtx, propagation.HeaderCarrier(ctx.Writer.Header()))
+
+		ctx.Next()
+		panicked = false
+	}
+}
@@ -0,0 +1,68 @@
+package tracing
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	semconv "go.opentelemetry.io/otel/semconv/v1.26.0"
+)
+
+func TestMiddlewareMarksPanics(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	recorder := tracetest.NewSpanRecorder()
+	provider := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder))
+	previous := otel.GetTracerProvider()
+	otel.SetTracerProvider(provider)
+	t.Cleanup(func() { otel.SetTracerProvider(previous) })
+
+	router := gin.New()
+	router.Use(gin.CustomRecovery(func(ctx *gin.Context, err interface{}) {
+		ctx.AbortWithStatus(http.StatusInternalServerError)
+	}), Middleware())
+	router.GET("/panic", func(ctx *gin.Context) { panic("boom") })
+	router.GET("/ok", func(ctx *gin.Context) { ctx.Status(http.StatusNoContent) })
+
+	for _, path := range []string{"/panic", "/ok"} {
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, path, nil))
+	}
+
+	spans := recorder.Ended()
+	if len(spans) != 2 {
+		t.Fatalf("%d spans ended, want 2", len(spans))
+	}
+	tests := []struct {
+		name   string
+		status int
+		code   codes.Code
+	}{
+		{"GET /panic", http.StatusInternalServerError, codes.Error},
+		{"GET /ok", http.StatusNoContent, codes.Unset},
+	}
+	for i, tt := range tests {
+		span := spans[i]
+		if span.Name() != tt.name {
+			t.Errorf("span %d is %q, want %q", i, span.Name(), tt.name)
+			continue
+		}
+		if span.Status().Code != tt.code {
+			t.Errorf("%s: status code %v, want %v", tt.name, span.Status().Code, tt.code)
+		}
+		want := attribute.KeyValue(semconv.HTTPResponseStatusCode(tt.status))
+		found := false
+		for _, attr := range span.Attributes() {
+			found = found || attr == want
+		}
+		if !found {
+			t.Errorf("%s: attributes %v lack %v", tt.name, span.Attributes(), want)
+		}
+	}
+}
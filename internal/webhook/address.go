@@ -0,0 +1,65 @@
+package webhook
+
+import (
+	"TaskManager/internal/models"
+	"fmt"
+	"net"
+	"net/netip"
+	"net/url"
+	"strings"
+	"syscall"
+)
+
+// ErrPrivateAddress is returned for webhooks pointing at an address that
+// is not publicly routable, such as loopback, private, link-local or cloud
+// metadata addresses. Deliveries must not reach into the server's network.
+var ErrPrivateAddress = fmt.Errorf("%w: url must point to a public address", models.ErrInvalidWebhook)
+
+// sharedAddressSpace is the carrier-grade NAT range of RFC 6598, which is
+// not covered by netip.Addr.IsPrivate.
+var sharedAddressSpace = netip.MustParsePrefix("100.64.0.0/10")
+
+// CheckURL rejects webhook URLs whose host is a literal non-public address
+// or a localhost name. Other hostnames are checked once they are resolved,
+// when a delivery dials them.
+func CheckURL(rawURL string) error {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return fmt.Errorf("%w: malformed url", models.ErrInvalidWebhook)
+	}
+
+	host := strings.TrimSuffix(strings.ToLower(u.Hostname()), ".")
+	if host == "localhost" || strings.HasSuffix(host, ".localhost") {
+		return ErrPrivateAddress
+	}
+	if addr, err := netip.ParseAddr(host); err == nil && !isPublic(addr) {
+		return ErrPrivateAddress
+	}
+	return nil
+}
+
+// isPublic reports whether addr is publicly routable.
+func isPublic(addr netip.Addr) bool {
+	addr = addr.Unmap()
+	return addr.IsGlobalUnicast() &&
+		!addr.IsPrivate() &&
+		!sharedAddressSpace.Contains(addr)
+}
+
+// checkDial is a net.Dialer Control function refusing connections to
+// non-public addresses. It runs after name resolution, so hostnames that
+// resolve to such addresses are caught as well.
+func checkDial(network, address string, _ syscall.RawConn) error {
+	host, _, err := net.SplitHostPort(address)
+	if err != nil {
+		return err
+	}
+	addr, err := netip.ParseAddr(host)
+	if err != nil {
+		return err
+	}
+	if !isPublic(addr) {
+		return fmt.Errorf("refusing to connect to non-public address %s", addr)
+	}
+	return nil
+}
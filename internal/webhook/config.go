@@ -0,0 +1,38 @@
+package webhook
+
+import "time"
+
+type Config struct {
+	Enabled bool `toml:"enabled"`
+	// Interval is how often the outbox is polled for due deliveries.
+	Interval time.Duration `toml:"interval"`
+	// Timeout bounds a single delivery request.
+	Timeout   time.Duration `toml:"timeout"`
+	BatchSize int           `toml:"batch_size"`
+
+	// MaxAttempts is how often a delivery is tried before it is given up.
+	MaxAttempts int `toml:"max_attempts"`
+	// Backoff is the delay before the first retry; it doubles with every
+	// further attempt up to MaxBackoff.
+	Backoff    time.Duration `toml:"backoff"`
+	MaxBackoff time.Duration `toml:"max_backoff"`
+	// Retention is how long finished deliveries are kept in the outbox.
+	Retention time.Duration `toml:"retention"`
+	// AllowPrivateAddresses lets webhooks point at loopback, private and
+	// link-local addresses, e.g. for development. It must stay off where
+	// users are not trusted with the server's network.
+	AllowPrivateAddresses bool `toml:"allow_private_addresses"`
+}
+
+func NewConfig() *Config {
+	return &Config{
+		Enabled:     true,
+		Interval:    5 * time.Second,
+		Timeout:     10 * time.Second,
+		BatchSize:   20,
+		MaxAttempts: 8,
+		Backoff:     30 * time.Second,
+		MaxBackoff:  time.Hour,
+		Retention:   7 * 24 * time.Hour,
+	}
+}
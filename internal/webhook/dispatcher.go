@@ -0,0 +1,234 @@
+// Package webhook delivers task events from the outbox to the webhooks
+// users subscribed.
+package webhook
+
+import (
+	"TaskManager/internal/models"
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+// Headers sent with every delivery. Receivers verify a delivery by
+// comparing HeaderSignature with Sign of the timestamp and the body, and
+// may reject old timestamps to guard against replays.
+const (
+	HeaderEvent     = "X-Webhook-Event"
+	HeaderDelivery  = "X-Webhook-Delivery"
+	HeaderTimestamp = "X-Webhook-Timestamp"
+	HeaderSignature = "X-Webhook-Signature"
+)
+
+// pruneInterval is how often finished deliveries are removed.
+const pruneInterval = time.Hour
+
+type Storage interface {
+	ClaimDeliveries(ctx context.Context, now, leaseUntil time.Time, limit int) ([]models.WebhookDelivery, error)
+	CompleteDelivery(ctx context.Context, deliveryID int, at time.Time) error
+	RetryDelivery(ctx context.Context, deliveryID int, next *time.Time, lastError string) error
+	DeleteDeliveries(ctx context.Context, before time.Time) error
+}
+
+// Dispatcher polls the outbox and posts due deliveries to their webhooks,
+// retrying failed ones with exponential backoff. Deliveries are claimed
+// for a lease, so several instances can share the outbox.
+type Dispatcher struct {
+	config  *Config
+	storage Storage
+	client  *http.Client
+	logger  logrus.FieldLogger
+}
+
+func New(config *Config, storage Storage, logger logrus.FieldLogger) (*Dispatcher, error) {
+	if config == nil {
+		return nil, errors.New("Config is nil")
+	}
+	if config.Interval <= 0 || config.Timeout <= 0 || config.BatchSize <= 0 || config.MaxAttempts <= 0 {
+		return nil, errors.New("webhook interval, timeout, batch size and attempts must be positive")
+	}
+
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	if !config.AllowPrivateAddresses {
+		// Connections are checked at dial time, after name resolution, and
+		// never go through a proxy, which would be dialed instead.
+		transport.Proxy = nil
+		transport.DialContext = (&net.Dialer{
+			Timeout:   30 * time.Second,
+			KeepAlive: 30 * time.Second,
+			Control:   checkDial,
+		}).DialContext
+	}
+
+	return &Dispatcher{
+		config:  config,
+		storage: storage,
+		client: &http.Client{
+			Transport: transport,
+			Timeout:   config.Timeout,
+			// A redirect counts as a failed delivery.
+			CheckRedirect: func(*http.Request, []*http.Request) error {
+				return http.ErrUseLastResponse
+			},
+		},
+		logger: logger,
+	}, nil
+}
+
+// CheckURL is the package CheckURL, unless private addresses are allowed.
+func (d *Dispatcher) CheckURL(rawURL string) error {
+	if d.config.AllowPrivateAddresses {
+		return nil
+	}
+	return CheckURL(rawURL)
+}
+
+// Run delivers the due events every interval until ctx is done.
+func (d *Dispatcher) Run(ctx context.Context) {
+	ticker := time.NewTicker(d.config.Interval)
+	defer ticker.Stop()
+
+	var pruned time.Time
+	for {
+		// A full batch suggests more are waiting.
+		for {
+			n := d.dispatch(ctx)
+			if n < d.config.BatchSize || ctx.Err() != nil {
+				break
+			}
+		}
+
+		if time.Since(pruned) >= pruneInterval {
+			pruned = time.Now()
+			if err := d.storage.DeleteDeliveries(ctx, pruned.Add(-d.config.Retention)); err != nil {
+				d.logger.Error("Error removing webhook deliveries: ", err)
+			}
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// dispatch claims a batch of due deliveries and posts them concurrently.
+// It returns the size of the batch.
+func (d *Dispatcher) dispatch(ctx context.Context) int {
+	now := time.Now()
+	// Deliveries not finished within the lease, e.g. because this
+	// instance stopped, are claimed again.
+	deliveries, err := d.storage.ClaimDeliveries(ctx, now, now.Add(2*d.config.Timeout), d.config.BatchSize)
+	if err != nil {
+		d.logger.Error("Error claiming webhook deliveries: ", err)
+		return 0
+	}
+
+	// Deliveries already claimed are finished on shutdown rather than
+	// aborted and counted as failed; the client timeout bounds them.
+	ctx = context.WithoutCancel(ctx)
+
+	var wg sync.WaitGroup
+	for i := range deliveries {
+		wg.Add(1)
+		go func(delivery *models.WebhookDelivery) {
+			defer wg.Done()
+			d.deliver(ctx, delivery)
+		}(&deliveries[i])
+	}
+	wg.Wait()
+
+	return len(deliveries)
+}
+
+// deliver posts a delivery and records the outcome.
+func (d *Dispatcher) deliver(ctx context.Context, delivery *models.WebhookDelivery) {
+	err := d.post(ctx, delivery)
+	if err == nil {
+		if err := d.storage.CompleteDelivery(ctx, delivery.ID, time.Now()); err != nil {
+			d.logger.Error("Error completing webhook delivery: ", err)
+		}
+		return
+	}
+
+	var next *time.Time
+	if delivery.Attempts < d.config.MaxAttempts {
+		retryAt := time.Now().UTC().Add(d.backoff(delivery.Attempts))
+		next = &retryAt
+	}
+
+	d.logger.WithFields(logrus.Fields{
+		"delivery_id": delivery.ID,
+		"webhook_id":  delivery.WebhookID,
+		"attempts":    delivery.Attempts,
+		"retry_at":    next,
+	}).Warn("Error delivering webhook: ", err)
+
+	if err := d.storage.RetryDelivery(ctx, delivery.ID, next, err.Error()); err != nil {
+		d.logger.Error("Error rescheduling webhook delivery: ", err)
+	}
+}
+
+// post sends a delivery to its webhook. Any response other than 2xx is an
+// error.
+func (d *Dispatcher) post(ctx context.Context, delivery *models.WebhookDelivery) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, delivery.URL, bytes.NewReader(delivery.Payload))
+	if err != nil {
+		return err
+	}
+
+	timestamp := time.Now().Unix()
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "TaskManager-Webhook")
+	req.Header.Set(HeaderEvent, string(delivery.Event))
+	req.Header.Set(HeaderDelivery, strconv.Itoa(delivery.ID))
+	req.Header.Set(HeaderTimestamp, strconv.FormatInt(timestamp, 10))
+	req.Header.Set(HeaderSignature, Sign(delivery.Secret, timestamp, delivery.Payload))
+
+	resp, err := d.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	io.Copy(io.Discard, io.LimitReader(resp.Body, 64<<10))
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return fmt.Errorf("unexpected response status %s", resp.Status)
+	}
+	return nil
+}
+
+// backoff returns the delay before the next attempt after the given
+// number of failed ones.
+func (d *Dispatcher) backoff(attempts int) time.Duration {
+	delay := d.config.Backoff
+	for i := 1; i < attempts && delay < d.config.MaxBackoff; i++ {
+		delay *= 2
+	}
+	if delay > d.config.MaxBackoff {
+		delay = d.config.MaxBackoff
+	}
+	return delay
+}
+
+// Sign returns the signature of a delivery: "sha256=" and the hex
+// HMAC-SHA256, keyed with the webhook secret, of the timestamp, a dot and
+// the body.
+func Sign(secret string, timestamp int64, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	fmt.Fprintf(mac, "%d.", timestamp)
+	mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
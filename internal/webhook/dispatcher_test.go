@@ -0,0 +1,311 @@
+package webhook
+
+import (
+	"TaskManager/internal/models"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+// fakeStorage hands out preset deliveries and records their outcome.
+type fakeStorage struct {
+	mu         sync.Mutex
+	deliveries []models.WebhookDelivery
+	completed  []int
+	retried    map[int]*time.Time
+	lastErrors map[int]string
+}
+
+func newFakeStorage(deliveries ...models.WebhookDelivery) *fakeStorage {
+	return &fakeStorage{
+		deliveries: deliveries,
+		retried:    make(map[int]*time.Time),
+		lastErrors: make(map[int]string),
+	}
+}
+
+func (s *fakeStorage) ClaimDeliveries(ctx context.Context, now, leaseUntil time.Time, limit int) ([]models.WebhookDelivery, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	claimed := s.deliveries
+	s.deliveries = nil
+	return claimed, nil
+}
+
+func (s *fakeStorage) CompleteDelivery(ctx context.Context, deliveryID int, at time.Time) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.completed = append(s.completed, deliveryID)
+	return nil
+}
+
+func (s *fakeStorage) RetryDelivery(ctx context.Context, deliveryID int, next *time.Time, lastError string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.retried[deliveryID] = next
+	s.lastErrors[deliveryID] = lastError
+	return nil
+}
+
+func (s *fakeStorage) DeleteDeliveries(ctx context.Context, before time.Time) error {
+	return nil
+}
+
+func testConfig() *Config {
+	config := NewConfig()
+	config.Timeout = 2 * time.Second
+	config.Backoff = time.Minute
+	config.MaxBackoff = 10 * time.Minute
+	config.MaxAttempts = 3
+	// The receivers listen on loopback.
+	config.AllowPrivateAddresses = true
+	return config
+}
+
+func newTestDispatcher(t *testing.T, config *Config, storage Storage) *Dispatcher {
+	t.Helper()
+
+	logger := logrus.New()
+	logger.SetOutput(io.Discard)
+
+	d, err := New(config, storage, logger)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return d
+}
+
+func TestSign(t *testing.T) {
+	secret, body := "s3cret", []byte(`{"event":"task.created"}`)
+	var timestamp int64 = 1700000000
+
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte("1700000000." + string(body)))
+	want := "sha256=" + hex.EncodeToString(mac.Sum(nil))
+
+	if got := Sign(secret, timestamp, body); got != want {
+		t.Errorf("Sign() = %q, want %q", got, want)
+	}
+	if Sign("other", timestamp, body) == want {
+		t.Error("Sign() does not depend on the secret")
+	}
+	if Sign(secret, timestamp+1, body) == want {
+		t.Error("Sign() does not depend on the timestamp")
+	}
+}
+
+func TestDeliverSigned(t *testing.T) {
+	payload := []byte(`{"event":"task.created"}`)
+
+	var (
+		mu       sync.Mutex
+		received *http.Request
+		body     []byte
+	)
+	receiver := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+		received = r
+		body, _ = io.ReadAll(r.Body)
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer receiver.Close()
+
+	storage := newFakeStorage(models.WebhookDelivery{
+		ID: 7, WebhookID: 3, URL: receiver.URL, Secret: "s3cret",
+		Event: models.EventTaskCreated, Payload: payload, Attempts: 1,
+	})
+	d := newTestDispatcher(t, testConfig(), storage)
+
+	if n := d.dispatch(context.Background()); n != 1 {
+		t.Fatalf("dispatch() = %d, want 1", n)
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	if received == nil {
+		t.Fatal("receiver was not called")
+	}
+	if received.Method != http.MethodPost {
+		t.Errorf("method = %s, want POST", received.Method)
+	}
+	if string(body) != string(payload) {
+		t.Errorf("body = %s, want %s", body, payload)
+	}
+	if got := received.Header.Get(HeaderEvent); got != string(models.EventTaskCreated) {
+		t.Errorf("%s = %q", HeaderEvent, got)
+	}
+	if got := received.Header.Get(HeaderDelivery); got != "7" {
+		t.Errorf("%s = %q, want 7", HeaderDelivery, got)
+	}
+
+	timestamp, err := strconv.ParseInt(received.Header.Get(HeaderTimestamp), 10, 64)
+	if err != nil {
+		t.Fatalf("bad %s: %v", HeaderTimestamp, err)
+	}
+	mac := hmac.New(sha256.New, []byte("s3cret"))
+	mac.Write([]byte(strconv.FormatInt(timestamp, 10) + "." + string(payload)))
+	if got, want := received.Header.Get(HeaderSignature), "sha256="+hex.EncodeToString(mac.Sum(nil)); got != want {
+		t.Errorf("%s = %q, want %q", HeaderSignature, got, want)
+	}
+
+	if len(storage.completed) != 1 || storage.completed[0] != 7 {
+		t.Errorf("completed = %v, want [7]", storage.completed)
+	}
+	if len(storage.retried) != 0 {
+		t.Errorf("retried = %v, want none", storage.retried)
+	}
+}
+
+func TestDeliverRetriesOnErrorStatus(t *testing.T) {
+	receiver := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer receiver.Close()
+
+	config := testConfig()
+	storage := newFakeStorage(
+		models.WebhookDelivery{ID: 1, URL: receiver.URL, Attempts: 1},
+		models.WebhookDelivery{ID: 2, URL: receiver.URL, Attempts: 2},
+		models.WebhookDelivery{ID: 3, URL: receiver.URL, Attempts: config.MaxAttempts},
+	)
+	d := newTestDispatcher(t, config, storage)
+
+	start := time.Now()
+	d.dispatch(context.Background())
+
+	if len(storage.completed) != 0 {
+		t.Errorf("completed = %v, want none", storage.completed)
+	}
+	for id, wantDelay := range map[int]time.Duration{1: time.Minute, 2: 2 * time.Minute} {
+		next := storage.retried[id]
+		if next == nil {
+			t.Errorf("delivery %d was given up, want a retry", id)
+			continue
+		}
+		if delay := next.Sub(start); delay < wantDelay || delay > wantDelay+time.Minute/2 {
+			t.Errorf("delivery %d retried after %s, want %s", id, delay, wantDelay)
+		}
+		if storage.lastErrors[id] == "" {
+			t.Errorf("delivery %d has no error recorded", id)
+		}
+	}
+	if next, ok := storage.retried[3]; !ok || next != nil {
+		t.Errorf("delivery at max attempts: next = %v, want it given up", next)
+	}
+}
+
+func TestDeliverRedirectIsFailure(t *testing.T) {
+	var followed atomic.Bool
+	target := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		followed.Store(true)
+	}))
+	defer target.Close()
+
+	receiver := httptest.NewServer(http.RedirectHandler(target.URL, http.StatusTemporaryRedirect))
+	defer receiver.Close()
+
+	storage := newFakeStorage(models.WebhookDelivery{ID: 1, URL: receiver.URL, Attempts: 1})
+	d := newTestDispatcher(t, testConfig(), storage)
+	d.dispatch(context.Background())
+
+	if followed.Load() {
+		t.Error("redirect was followed")
+	}
+	if len(storage.completed) != 0 {
+		t.Errorf("completed = %v, want none", storage.completed)
+	}
+	if storage.retried[1] == nil {
+		t.Error("redirected delivery was not retried")
+	}
+}
+
+func TestBackoff(t *testing.T) {
+	d := newTestDispatcher(t, testConfig(), newFakeStorage())
+
+	tests := []struct {
+		attempts int
+		want     time.Duration
+	}{
+		{1, time.Minute},
+		{2, 2 * time.Minute},
+		{3, 4 * time.Minute},
+		{4, 8 * time.Minute},
+		{5, 10 * time.Minute},
+		{20, 10 * time.Minute},
+	}
+	for _, tt := range tests {
+		if got := d.backoff(tt.attempts); got != tt.want {
+			t.Errorf("backoff(%d) = %s, want %s", tt.attempts, got, tt.want)
+		}
+	}
+}
+
+func TestCheckURL(t *testing.T) {
+	tests := []struct {
+		url     string
+		private bool
+	}{
+		{"https://example.com/hook", false},
+		{"http://93.184.216.34/hook", false},
+		{"http://[2606:2800:220:1:248:1893:25c8:1946]/", false},
+		{"http://localhost/hook", true},
+		{"http://api.localhost/hook", true},
+		{"http://127.0.0.1:8080/", true},
+		{"http://10.0.0.1/", true},
+		{"http://172.16.5.4/", true},
+		{"http://192.168.1.1/", true},
+		{"http://169.254.169.254/latest/meta-data/", true},
+		{"http://100.64.0.1/", true},
+		{"http://0.0.0.0/", true},
+		{"http://[::1]/", true},
+		{"http://[fe80::1]/", true},
+		{"http://[fd00::1]/", true},
+		{"http://[::ffff:127.0.0.1]/", true},
+	}
+	for _, tt := range tests {
+		err := CheckURL(tt.url)
+		if tt.private && !errors.Is(err, ErrPrivateAddress) {
+			t.Errorf("CheckURL(%q) = %v, want ErrPrivateAddress", tt.url, err)
+		}
+		if !tt.private && err != nil {
+			t.Errorf("CheckURL(%q) = %v, want nil", tt.url, err)
+		}
+	}
+}
+
+func TestDeliverRefusesPrivateAddress(t *testing.T) {
+	var called atomic.Bool
+	receiver := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		called.Store(true)
+	}))
+	defer receiver.Close()
+
+	config := testConfig()
+	config.AllowPrivateAddresses = false
+	storage := newFakeStorage(models.WebhookDelivery{ID: 1, URL: receiver.URL, Attempts: 1})
+	d := newTestDispatcher(t, config, storage)
+	d.dispatch(context.Background())
+
+	if called.Load() {
+		t.Error("delivery reached a loopback address")
+	}
+	if storage.retried[1] == nil {
+		t.Error("refused delivery was not retried")
+	}
+}
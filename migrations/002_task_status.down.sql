@@ -0,0 +1,2 @@
+-- Drop task lifecycle status
+ALTER TABLE tasks DROP COLUMN IF EXISTS status;
\ No newline at end of file
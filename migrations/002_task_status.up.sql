@@ -0,0 +1,4 @@
+-- Add task lifecycle status
+ALTER TABLE tasks
+    ADD COLUMN status VARCHAR(16) NOT NULL DEFAULT 'todo'
+    CHECK (status IN ('todo', 'in_progress', 'blocked', 'done', 'cancelled'));
\ No newline at end of file
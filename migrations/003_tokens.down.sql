@@ -0,0 +1,5 @@
+ALTER TABLE users DROP COLUMN IF EXISTS tokens_revoked_at;
+
+DROP TABLE IF EXISTS revoked_tokens;
+
+DROP TABLE IF EXISTS refresh_tokens;
\ No newline at end of file
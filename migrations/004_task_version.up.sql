@@ -0,0 +1,2 @@
+-- Optimistic concurrency version, incremented on every update
+ALTER TABLE tasks ADD COLUMN version INTEGER NOT NULL DEFAULT 1;
\ No newline at end of file
@@ -0,0 +1,7 @@
+-- Due date, separate from the time the task is scheduled for
+ALTER TABLE tasks ADD COLUMN due_at TIMESTAMPTZ;
+
+-- Priority from 0 (P0, most urgent) to 4 (P4)
+ALTER TABLE tasks ADD COLUMN priority SMALLINT NOT NULL DEFAULT 2 CHECK (priority BETWEEN 0 AND 4);
+
+CREATE INDEX tasks_user_priority_due_idx ON tasks (user_id, priority, due_at);
\ No newline at end of file
@@ -0,0 +1,15 @@
+-- Per-user tags and their assignment to tasks
+CREATE TABLE tags (
+    id SERIAL PRIMARY KEY,
+    user_id INT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    name VARCHAR(64) NOT NULL,
+    UNIQUE (user_id, name)
+);
+
+CREATE TABLE task_tags (
+    task_id INT NOT NULL REFERENCES tasks(id) ON DELETE CASCADE,
+    tag_id INT NOT NULL REFERENCES tags(id) ON DELETE CASCADE,
+    PRIMARY KEY (task_id, tag_id)
+);
+
+CREATE INDEX task_tags_tag_id_idx ON task_tags (tag_id);
\ No newline at end of file
@@ -0,0 +1,4 @@
+DROP TABLE IF EXISTS checklist_items;
+
+DROP INDEX IF EXISTS tasks_parent_id_idx;
+ALTER TABLE tasks DROP COLUMN IF EXISTS parent_id;
\ No newline at end of file
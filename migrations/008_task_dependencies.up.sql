@@ -0,0 +1,9 @@
+-- Task dependencies: task_id is blocked by depends_on_id
+CREATE TABLE task_dependencies (
+    task_id INT NOT NULL REFERENCES tasks(id) ON DELETE CASCADE,
+    depends_on_id INT NOT NULL REFERENCES tasks(id) ON DELETE CASCADE,
+    PRIMARY KEY (task_id, depends_on_id),
+    CHECK (task_id <> depends_on_id)
+);
+
+CREATE INDEX task_dependencies_depends_on_id_idx ON task_dependencies (depends_on_id);
\ No newline at end of file
@@ -0,0 +1,20 @@
+-- Recurring tasks: a series repeats by an RFC 5545 RRULE from dtstart and
+-- keeps the fields its new occurrences are created with
+CREATE TABLE task_series (
+    id SERIAL PRIMARY KEY,
+    user_id INT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    rrule TEXT NOT NULL,
+    dtstart TIMESTAMPTZ NOT NULL,
+    materialized_until TIMESTAMPTZ NOT NULL,
+    title VARCHAR(255) NOT NULL,
+    description TEXT NOT NULL DEFAULT '',
+    priority SMALLINT NOT NULL DEFAULT 2,
+    due_offset BIGINT
+);
+
+-- Occurrences of a series; occurrence_at is the time the rule produced,
+-- which stays put when a single occurrence is rescheduled
+ALTER TABLE tasks ADD COLUMN series_id INT REFERENCES task_series(id) ON DELETE SET NULL;
+ALTER TABLE tasks ADD COLUMN occurrence_at TIMESTAMPTZ;
+
+CREATE INDEX tasks_series_id_idx ON tasks (series_id, occurrence_at);
\ No newline at end of file
@@ -0,0 +1,3 @@
+DROP INDEX IF EXISTS tasks_scheduled_for_idx;
+
+DROP TABLE IF EXISTS reminders;
\ No newline at end of file
@@ -0,0 +1,14 @@
+-- Reminders fired ahead of the scheduled time of a task. A reminder is
+-- fired once per offset and scheduled time, so a rescheduled task is
+-- reminded again
+CREATE TABLE reminders (
+    id SERIAL PRIMARY KEY,
+    task_id INT NOT NULL REFERENCES tasks(id) ON DELETE CASCADE,
+    user_id INT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    remind_offset BIGINT NOT NULL,
+    remind_at TIMESTAMPTZ NOT NULL,
+    fired_at TIMESTAMPTZ NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    UNIQUE (task_id, remind_offset, remind_at)
+);
+
+CREATE INDEX tasks_scheduled_for_idx ON tasks (scheduled_for);
\ No newline at end of file
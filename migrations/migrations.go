@@ -0,0 +1,14 @@
+// Package migrations embeds the SQL schema migrations into the binary.
+package migrations
+
+import "embed"
+
+// Postgres holds the Postgres migrations at the root of the FS.
+//
+//go:embed *.sql
+var Postgres embed.FS
+
+// SQLite holds the SQLite migrations under sqlite/.
+//
+//go:embed sqlite/*.sql
+var SQLite embed.FS
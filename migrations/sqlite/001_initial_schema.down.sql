@@ -0,0 +1,5 @@
+-- Drop tasks table
+DROP TABLE IF EXISTS tasks;
+
+-- Drop users table
+DROP TABLE IF EXISTS users;
\ No newline at end of file
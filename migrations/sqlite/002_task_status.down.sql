@@ -0,0 +1,2 @@
+-- Drop task lifecycle status
+ALTER TABLE tasks DROP COLUMN status;
\ No newline at end of file
@@ -0,0 +1,20 @@
+-- Rotating refresh tokens, stored as SHA-256 hashes
+CREATE TABLE refresh_tokens (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    user_id INTEGER NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    token_hash CHAR(64) NOT NULL UNIQUE,
+    created_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    expires_at DATETIME NOT NULL,
+    revoked_at DATETIME
+);
+
+CREATE INDEX refresh_tokens_user_id_idx ON refresh_tokens (user_id);
+
+-- Access tokens revoked before their expiry, by jti
+CREATE TABLE revoked_tokens (
+    jti VARCHAR(64) PRIMARY KEY,
+    expires_at DATETIME NOT NULL
+);
+
+-- Access tokens issued before this moment are rejected
+ALTER TABLE users ADD COLUMN tokens_revoked_at DATETIME;
\ No newline at end of file
@@ -0,0 +1,3 @@
+DROP INDEX IF EXISTS tasks_user_priority_due_idx;
+ALTER TABLE tasks DROP COLUMN priority;
+ALTER TABLE tasks DROP COLUMN due_at;
\ No newline at end of file
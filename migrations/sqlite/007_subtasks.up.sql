@@ -0,0 +1,15 @@
+-- Subtasks, deleted along with their parent
+ALTER TABLE tasks ADD COLUMN parent_id INTEGER REFERENCES tasks(id) ON DELETE CASCADE;
+
+CREATE INDEX tasks_parent_id_idx ON tasks (parent_id);
+
+-- Checklist items of a task, in position order
+CREATE TABLE checklist_items (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    task_id INTEGER NOT NULL REFERENCES tasks(id) ON DELETE CASCADE,
+    text VARCHAR(255) NOT NULL,
+    done BOOLEAN NOT NULL DEFAULT FALSE,
+    position INTEGER NOT NULL
+);
+
+CREATE INDEX checklist_items_task_id_idx ON checklist_items (task_id, position);
\ No newline at end of file
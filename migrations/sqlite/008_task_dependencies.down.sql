@@ -0,0 +1 @@
+DROP TABLE IF EXISTS task_dependencies;
\ No newline at end of file
@@ -0,0 +1,5 @@
+DROP INDEX IF EXISTS tasks_series_id_idx;
+ALTER TABLE tasks DROP COLUMN occurrence_at;
+ALTER TABLE tasks DROP COLUMN series_id;
+
+DROP TABLE IF EXISTS task_series;
\ No newline at end of file
@@ -0,0 +1,14 @@
+-- Reminders fired ahead of the scheduled time of a task. A reminder is
+-- fired once per offset and scheduled time, so a rescheduled task is
+-- reminded again
+CREATE TABLE reminders (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    task_id INTEGER NOT NULL REFERENCES tasks(id) ON DELETE CASCADE,
+    user_id INTEGER NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    remind_offset INTEGER NOT NULL,
+    remind_at DATETIME NOT NULL,
+    fired_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    UNIQUE (task_id, remind_offset, remind_at)
+);
+
+CREATE INDEX tasks_scheduled_for_idx ON tasks (scheduled_for);
\ No newline at end of file